@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sigs.k8s.io/yaml"
+)
+
+func newAppCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "app",
+		Aliases: []string{"application", "apps"},
+		Short:   "Manage applications",
+	}
+	cmd.AddCommand(newAppListCommand(), newAppGetCommand(), newAppCreateCommand())
+	return cmd
+}
+
+func newAppListCommand() *cobra.Command {
+	var project, status string
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List applications",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			setQuery(query, "project_name", project)
+			setQuery(query, "status", status)
+			if limit > 0 {
+				query.Set("limit", strconv.Itoa(limit))
+			}
+
+			var apps []model.Application
+			raw, err := c.Get(cmd.Context(), "/applications", query, &apps)
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"ID", "NAME", "PROJECT", "TYPE", "STATUS", "ACTIVE MANIFEST", "AGE"}}
+			for _, app := range apps {
+				t.add(app.ID.Hex(), app.Name, app.ProjectName, string(app.Type), orDash(app.Status),
+					orDash(app.ActiveManifestName), age(app.CreatedAt))
+			}
+			return render(cmd.OutOrStdout(), opts.output, raw, t)
+		},
+	}
+	cmd.Flags().StringVar(&project, "project", "", "filter by project name")
+	cmd.Flags().StringVar(&status, "status", "", "filter by status")
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum number of applications to return")
+	return cmd
+}
+
+func newAppGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get NAME|ID",
+		Short: "Show an application",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			id, err := resolveApplication(cmd.Context(), c, args[0])
+			if err != nil {
+				return err
+			}
+
+			var app model.Application
+			raw, err := c.Get(cmd.Context(), "/applications/"+id, nil, &app)
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"FIELD", "VALUE"}}
+			t.add("ID", app.ID.Hex())
+			t.add("NAME", app.Name)
+			t.add("PROJECT", app.ProjectName)
+			t.add("REPO", app.RepoURL)
+			t.add("TYPE", string(app.Type))
+			t.add("STATUS", orDash(app.Status))
+			t.add("ACTIVE MANIFEST", orDash(app.ActiveManifestName))
+			t.add("CREATED", app.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+			return render(cmd.OutOrStdout(), opts.output, raw, t)
+		},
+	}
+}
+
+func newAppCreateCommand() *cobra.Command {
+	var file, name, project, repoURL, releaseType string
+	cmd := &cobra.Command{
+		Use:   "create (-f FILE | --name NAME --project PROJECT --repo-url URL)",
+		Short: "Create an application from a YAML/JSON file or flags",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var body any
+			if file != "" {
+				// 文件支持完整的 Application 字段（configurations / service / internet 等）
+				data, err := readInput(file)
+				if err != nil {
+					return err
+				}
+				raw, err := yaml.YAMLToJSON(data)
+				if err != nil {
+					return fmt.Errorf("parse %s: %w", file, err)
+				}
+				body = json.RawMessage(raw)
+			} else {
+				if name == "" || project == "" || repoURL == "" {
+					return errors.New("either -f or --name, --project and --repo-url are required")
+				}
+				body = &model.Application{
+					Name:        name,
+					ProjectName: project,
+					RepoURL:     repoURL,
+					Type:        model.ReleaseType(releaseType),
+				}
+			}
+
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			id, err := c.Create(cmd.Context(), "/applications", nil, body)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "application %s created\n", id)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "application definition (YAML or JSON, - for stdin)")
+	cmd.Flags().StringVar(&name, "name", "", "application name")
+	cmd.Flags().StringVar(&project, "project", "", "project name")
+	cmd.Flags().StringVar(&repoURL, "repo-url", "", "git repository URL")
+	cmd.Flags().StringVar(&releaseType, "type", string(model.Normal), "release type: normal | canary | blue-green")
+	return cmd
+}
+
+// resolveApplication 参数为 ObjectID 时直接使用，否则按名称查找
+func resolveApplication(ctx context.Context, c *Client, nameOrID string) (string, error) {
+	if primitive.IsValidObjectID(nameOrID) {
+		return nameOrID, nil
+	}
+	var apps []model.Application
+	if _, err := c.Get(ctx, "/applications", url.Values{"name": {nameOrID}}, &apps); err != nil {
+		return "", err
+	}
+	switch len(apps) {
+	case 0:
+		return "", fmt.Errorf("application %q not found", nameOrID)
+	case 1:
+		return apps[0].ID.Hex(), nil
+	}
+	return "", fmt.Errorf("application name %q is ambiguous (%d matches), use the ID", nameOrID, len(apps))
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
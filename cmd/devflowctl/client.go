@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+// Client devflow REST API 客户端
+type Client struct {
+	server string
+	token  string
+	http   *http.Client
+	// stream 用于日志与事件长连接，不设超时
+	stream *http.Client
+}
+
+// APIError 服务端返回的非 2xx 响应
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+func NewClient(server, token string) *Client {
+	return &Client{
+		server: strings.TrimSuffix(server, "/"),
+		token:  token,
+		http:   &http.Client{Timeout: requestTimeout},
+		stream: &http.Client{},
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body any) (*http.Request, error) {
+	u := c.server + "/api/v1" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("User-Agent", "devflowctl")
+	return req, nil
+}
+
+// Do 发送请求并返回原始 JSON 响应体
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body any) (json.RawMessage, error) {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, apiError(resp.StatusCode, data)
+	}
+	return data, nil
+}
+
+// Get 请求并解码到 out
+func (c *Client) Get(ctx context.Context, path string, query url.Values, out any) (json.RawMessage, error) {
+	data, err := c.Do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Create POST 创建资源，返回服务端分配的 id
+func (c *Client) Create(ctx context.Context, path string, query url.Values, body any) (string, error) {
+	data, err := c.Do(ctx, http.MethodPost, path, query, body)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// Stream 打开长连接（日志 / SSE），调用方负责关闭
+func (c *Client) Stream(ctx context.Context, path string, query url.Values, accept string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := c.stream.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, apiError(resp.StatusCode, data)
+	}
+	return resp.Body, nil
+}
+
+func apiError(status int, body []byte) error {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	msg := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		msg = payload.Error
+	}
+	return &APIError{StatusCode: status, Message: msg}
+}
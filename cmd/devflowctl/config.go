@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Context 一个 devflow 服务端及其访问 token
+type Context struct {
+	Name   string `json:"name"`
+	Server string `json:"server"`
+	Token  string `json:"token,omitempty"`
+}
+
+// CLIConfig 本地配置文件，默认 ~/.config/devflow/config.yaml，可用 DEVFLOW_CONFIG 或 --config 指定
+type CLIConfig struct {
+	CurrentContext string    `json:"current-context"`
+	Contexts       []Context `json:"contexts"`
+}
+
+func defaultConfigPath() string {
+	if p := os.Getenv("DEVFLOW_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "devflow", "config.yaml")
+}
+
+// loadConfig 文件不存在时返回空配置
+func loadConfig(path string) (*CLIConfig, error) {
+	cfg := &CLIConfig{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// save 配置含 token，以 0600 写入
+func (c *CLIConfig) save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (c *CLIConfig) context(name string) *Context {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i]
+		}
+	}
+	return nil
+}
+
+// upsert 新增或覆盖同名 context，空字段保留原值
+func (c *CLIConfig) upsert(ctx Context) {
+	if existing := c.context(ctx.Name); existing != nil {
+		if ctx.Server != "" {
+			existing.Server = ctx.Server
+		}
+		if ctx.Token != "" {
+			existing.Token = ctx.Token
+		}
+		return
+	}
+	c.Contexts = append(c.Contexts, ctx)
+}
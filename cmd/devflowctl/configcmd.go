@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage server contexts in the devflowctl config file",
+	}
+	cmd.AddCommand(newSetContextCommand(), newUseContextCommand(), newGetContextsCommand())
+	return cmd
+}
+
+func newSetContextCommand() *cobra.Command {
+	var server, token string
+	var use bool
+	cmd := &cobra.Command{
+		Use:   "set-context NAME --server URL [--token TOKEN]",
+		Short: "Create or update a context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			if cfg.context(args[0]) == nil && server == "" {
+				return fmt.Errorf("--server is required for new context %q", args[0])
+			}
+			cfg.upsert(Context{Name: args[0], Server: server, Token: token})
+			// 第一个 context 自动设为当前
+			if use || cfg.CurrentContext == "" {
+				cfg.CurrentContext = args[0]
+			}
+			if err := cfg.save(opts.configPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "context %q saved\n", args[0])
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&server, "server", "", "DevFlow server URL")
+	cmd.Flags().StringVar(&token, "token", "", "bearer token")
+	cmd.Flags().BoolVar(&use, "use", false, "switch to this context")
+	return cmd
+}
+
+func newUseContextCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-context NAME",
+		Short: "Switch the current context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			if cfg.context(args[0]) == nil {
+				return fmt.Errorf("context %q not found", args[0])
+			}
+			cfg.CurrentContext = args[0]
+			if err := cfg.save(opts.configPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "switched to context %q\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newGetContextsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List contexts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"CURRENT", "NAME", "SERVER"}}
+			for _, c := range cfg.Contexts {
+				current := ""
+				if c.Name == cfg.CurrentContext {
+					current = "*"
+				}
+				t.add(current, c.Name, c.Server)
+			}
+			// json / yaml 输出不包含 token
+			raw, err := marshalRaw(contextsView(cfg))
+			if err != nil {
+				return err
+			}
+			return render(cmd.OutOrStdout(), opts.output, raw, t)
+		},
+	}
+}
+
+func contextsView(cfg *CLIConfig) CLIConfig {
+	view := CLIConfig{CurrentContext: cfg.CurrentContext}
+	for _, c := range cfg.Contexts {
+		view.Contexts = append(view.Contexts, Context{Name: c.Name, Server: c.Server})
+	}
+	return view
+}
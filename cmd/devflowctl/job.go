@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/spf13/cobra"
+)
+
+func newJobCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "job",
+		Aliases: []string{"jobs"},
+		Short:   "Deploy, roll back and inspect jobs",
+	}
+	cmd.AddCommand(newJobDeployCommand(), newJobRollbackCommand(), newJobGetCommand(), newJobListCommand())
+	return cmd
+}
+
+func newJobDeployCommand() *cobra.Command {
+	var manifestID, env, jobType string
+	var allowDowngrade, dryRun, wait bool
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "deploy --manifest ID --env ENV [--wait]",
+		Short: "Deploy a manifest to an environment",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			// application_id 由服务端根据 manifest 补全
+			body := map[string]string{"manifest_id": manifestID, "env": env, "type": jobType}
+			query := url.Values{}
+			if allowDowngrade {
+				query.Set("allow_downgrade", "true")
+			}
+			if dryRun {
+				query.Set("dry_run", "true")
+				return printDryRun(cmd.Context(), c, query, body, cmd.OutOrStdout())
+			}
+			return createJob(cmd.Context(), c, query, body, wait, timeout, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&manifestID, "manifest", "", "manifest ID to deploy")
+	cmd.Flags().StringVar(&env, "env", "", "target environment")
+	cmd.Flags().StringVar(&jobType, "type", model.JobUpgrade, "job type: Install | Upgrade")
+	cmd.Flags().BoolVar(&allowDowngrade, "allow-downgrade", false, "allow deploying a version lower than the current one")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the rendered resources without creating a job")
+	cmd.Flags().BoolVar(&wait, "wait", false, "follow deployment progress until it finishes")
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultWaitTimeout, "maximum time to wait with --wait")
+	_ = cmd.MarkFlagRequired("manifest")
+	_ = cmd.MarkFlagRequired("env")
+	return cmd
+}
+
+func newJobRollbackCommand() *cobra.Command {
+	var app, env, manifestID string
+	var wait bool
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "rollback --app APP --env ENV [--manifest ID] [--wait]",
+		Short: "Roll an application back to the previous (or given) manifest",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			appID, err := resolveApplication(cmd.Context(), c, app)
+			if err != nil {
+				return err
+			}
+
+			body := map[string]string{"application_id": appID, "env": env, "type": model.JobRollback}
+			setBody(body, "manifest_id", manifestID)
+			return createJob(cmd.Context(), c, nil, body, wait, timeout, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&app, "app", "", "application name or ID")
+	cmd.Flags().StringVar(&env, "env", "", "target environment")
+	cmd.Flags().StringVar(&manifestID, "manifest", "", "manifest ID to roll back to (default: chosen by the server from release history)")
+	cmd.Flags().BoolVar(&wait, "wait", false, "follow rollback progress until it finishes")
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultWaitTimeout, "maximum time to wait with --wait")
+	_ = cmd.MarkFlagRequired("app")
+	_ = cmd.MarkFlagRequired("env")
+	return cmd
+}
+
+func newJobGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Show a job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			return showJob(cmd.Context(), c, args[0], cmd.OutOrStdout())
+		},
+	}
+}
+
+func newJobListCommand() *cobra.Command {
+	var app, status, jobType string
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List jobs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			if app != "" {
+				appID, err := resolveApplication(cmd.Context(), c, app)
+				if err != nil {
+					return err
+				}
+				query.Set("application_id", appID)
+			}
+			setQuery(query, "status", status)
+			setQuery(query, "type", jobType)
+			if limit > 0 {
+				query.Set("limit", strconv.Itoa(limit))
+			}
+
+			var jobs []model.Job
+			raw, err := c.Get(cmd.Context(), "/jobs", query, &jobs)
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"ID", "APPLICATION", "MANIFEST", "TYPE", "ENV", "STATUS", "AGE"}}
+			for _, job := range jobs {
+				t.add(job.ID.Hex(), job.ApplicationName, orDash(job.ManifestName), job.Type, job.Env,
+					string(job.Status), age(job.CreatedAt))
+			}
+			return render(cmd.OutOrStdout(), opts.output, raw, t)
+		},
+	}
+	cmd.Flags().StringVar(&app, "app", "", "filter by application name or ID")
+	cmd.Flags().StringVar(&status, "status", "", "filter by status")
+	cmd.Flags().StringVar(&jobType, "type", "", "filter by job type")
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum number of jobs to return")
+	return cmd
+}
+
+// createJob 创建 Job；wait 时跟踪到结束并输出最终状态，失败以非零退出
+func createJob(ctx context.Context, c *Client, query url.Values, body map[string]string, wait bool, timeout time.Duration, w io.Writer) error {
+	id, err := c.Create(ctx, "/jobs", query, body)
+	if err != nil {
+		return err
+	}
+	if !wait {
+		fmt.Fprintf(w, "job %s created\n", id)
+		return nil
+	}
+
+	progress("job %s created", id)
+	_, waitErr := watchJob(ctx, c, id, timeout)
+	if waitErr != nil && !errors.Is(waitErr, errFailed) {
+		return waitErr
+	}
+	if err := showJob(ctx, c, id, w); err != nil {
+		return err
+	}
+	return waitErr
+}
+
+// printDryRun dry_run 时服务端直接返回渲染后的 YAML
+func printDryRun(ctx context.Context, c *Client, query url.Values, body map[string]string, w io.Writer) error {
+	data, err := c.Do(ctx, http.MethodPost, "/jobs", query, body)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func showJob(ctx context.Context, c *Client, id string, w io.Writer) error {
+	var job model.Job
+	raw, err := c.Get(ctx, "/jobs/"+id, nil, &job)
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"FIELD", "VALUE"}}
+	t.add("ID", job.ID.Hex())
+	t.add("APPLICATION", job.ApplicationName)
+	t.add("PROJECT", job.ProjectName)
+	t.add("MANIFEST", orDash(job.ManifestName))
+	t.add("TYPE", job.Type)
+	t.add("ENV", job.Env)
+	t.add("STATUS", string(job.Status))
+	t.add("CREATED", job.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	return render(w, opts.output, raw, t)
+}
@@ -0,0 +1,103 @@
+// devflowctl DevFlow 命令行客户端：管理 Application、构建 Manifest、发起部署并跟踪实时进度
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// globalOptions 全局参数，优先级：命令行 > 环境变量 > 配置文件 context
+type globalOptions struct {
+	configPath string
+	context    string
+	server     string
+	token      string
+	output     string
+}
+
+var opts = &globalOptions{}
+
+// client 根据全局参数解析服务端地址与 token
+func (o *globalOptions) client() (*Client, error) {
+	cfg, err := loadConfig(o.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	server, token := o.server, o.token
+	if server == "" {
+		server = os.Getenv("DEVFLOW_SERVER")
+	}
+	if token == "" {
+		token = os.Getenv("DEVFLOW_TOKEN")
+	}
+
+	name := o.context
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name != "" {
+		current := cfg.context(name)
+		if current == nil && o.context != "" {
+			return nil, fmt.Errorf("context %q not found in %s", name, o.configPath)
+		}
+		if current != nil {
+			if server == "" {
+				server = current.Server
+			}
+			if token == "" {
+				token = current.Token
+			}
+		}
+	}
+
+	if server == "" {
+		return nil, errors.New("no server configured: use --server, DEVFLOW_SERVER or `devflowctl config set-context`")
+	}
+	return NewClient(server, token), nil
+}
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "devflowctl",
+		Short:         "DevFlow command-line client",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateOutput(opts.output)
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.configPath, "config", defaultConfigPath(), "path to the devflowctl config file")
+	flags.StringVar(&opts.context, "context", "", "config context to use (defaults to current-context)")
+	flags.StringVar(&opts.server, "server", "", "DevFlow server URL, overrides the context")
+	flags.StringVar(&opts.token, "token", "", "bearer token, overrides the context")
+	flags.StringVarP(&opts.output, "output", "o", OutputTable, "output format: table | json | yaml")
+
+	root.AddCommand(
+		newConfigCommand(),
+		newAppCommand(),
+		newManifestCommand(),
+		newJobCommand(),
+	)
+	return root
+}
+
+func main() {
+	// Ctrl-C 取消进行中的请求与 --wait 跟踪
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		stop()
+		os.Exit(1)
+	}
+}
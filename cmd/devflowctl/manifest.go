@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultWaitTimeout --wait 的默认超时
+const defaultWaitTimeout = 30 * time.Minute
+
+func newManifestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "manifest",
+		Aliases: []string{"manifests", "mf"},
+		Short:   "Build and inspect manifests",
+	}
+	cmd.AddCommand(newManifestBuildCommand(), newManifestGetCommand(), newManifestListCommand(), newManifestLogsCommand())
+	return cmd
+}
+
+func newManifestBuildCommand() *cobra.Command {
+	var app, branch, tag string
+	var wait bool
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "build --app APP (--branch BRANCH | --tag TAG) [--wait]",
+		Short: "Start a manifest build",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (branch == "") == (tag == "") {
+				return errors.New("exactly one of --branch or --tag is required")
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			appID, err := resolveApplication(cmd.Context(), c, app)
+			if err != nil {
+				return err
+			}
+
+			body := map[string]string{"application_id": appID}
+			setBody(body, "branch", branch)
+			setBody(body, "tag", tag)
+			id, err := c.Create(cmd.Context(), "/manifests", nil, body)
+			if err != nil {
+				return err
+			}
+			if !wait {
+				fmt.Fprintf(cmd.OutOrStdout(), "manifest %s created\n", id)
+				return nil
+			}
+
+			progress("manifest %s created", id)
+			_, waitErr := watchManifest(cmd.Context(), c, id, timeout)
+			if waitErr != nil && !errors.Is(waitErr, errFailed) {
+				return waitErr
+			}
+			// 失败时同样输出最终状态，便于查看失败步骤
+			if err := showManifest(cmd.Context(), c, id, cmd.OutOrStdout()); err != nil {
+				return err
+			}
+			return waitErr
+		},
+	}
+	cmd.Flags().StringVar(&app, "app", "", "application name or ID")
+	cmd.Flags().StringVar(&branch, "branch", "", "git branch to build")
+	cmd.Flags().StringVar(&tag, "tag", "", "git tag to build")
+	cmd.Flags().BoolVar(&wait, "wait", false, "follow build progress until it finishes")
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultWaitTimeout, "maximum time to wait with --wait")
+	_ = cmd.MarkFlagRequired("app")
+	return cmd
+}
+
+func newManifestGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Show a manifest and its build steps",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			return showManifest(cmd.Context(), c, args[0], cmd.OutOrStdout())
+		},
+	}
+}
+
+func newManifestListCommand() *cobra.Command {
+	var app, status, branch string
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List manifests",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			if app != "" {
+				appID, err := resolveApplication(cmd.Context(), c, app)
+				if err != nil {
+					return err
+				}
+				query.Set("application_id", appID)
+			}
+			setQuery(query, "status", status)
+			setQuery(query, "branch", branch)
+			if limit > 0 {
+				query.Set("limit", strconv.Itoa(limit))
+			}
+
+			var manifests []manifest
+			raw, err := c.Get(cmd.Context(), "/manifests", query, &manifests)
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"ID", "NAME", "APPLICATION", "REF", "COMMIT", "STATUS", "AGE"}}
+			for _, m := range manifests {
+				t.add(m.ID.Hex(), m.Name, m.ApplicationName, m.ref(), orDash(shortCommit(m.CommitHash)),
+					string(m.Status), age(m.CreatedAt))
+			}
+			return render(cmd.OutOrStdout(), opts.output, raw, t)
+		},
+	}
+	cmd.Flags().StringVar(&app, "app", "", "filter by application name or ID")
+	cmd.Flags().StringVar(&status, "status", "", "filter by status")
+	cmd.Flags().StringVar(&branch, "branch", "", "filter by branch")
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum number of manifests to return")
+	return cmd
+}
+
+func newManifestLogsCommand() *cobra.Command {
+	var step, container, since string
+	var follow, timestamps bool
+	var tail int
+	cmd := &cobra.Command{
+		Use:   "logs ID [--step TASK] [-f]",
+		Short: "Print build step logs",
+		Long:  "Print build step logs. Without --step, logs of every started step are printed in order; with -f new steps are followed until the build finishes.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			setQuery(query, "container", container)
+			setQuery(query, "since", since)
+			if follow {
+				query.Set("follow", "true")
+			}
+			if timestamps {
+				query.Set("timestamps", "true")
+			}
+			if tail > 0 {
+				query.Set("tail_lines", strconv.Itoa(tail))
+			}
+
+			id, out := args[0], cmd.OutOrStdout()
+			if step != "" {
+				return streamStepLogs(cmd.Context(), c, id, step, query, out)
+			}
+			return streamAllStepLogs(cmd.Context(), c, id, query, follow, out)
+		},
+	}
+	cmd.Flags().StringVar(&step, "step", "", "task name of the step (default: all started steps)")
+	cmd.Flags().StringVar(&container, "container", "", "container in the step pod")
+	cmd.Flags().StringVar(&since, "since", "", "only return logs newer than a relative duration like 10m")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "stream logs until the step finishes")
+	cmd.Flags().BoolVar(&timestamps, "timestamps", false, "include timestamps on each line")
+	cmd.Flags().IntVar(&tail, "tail", 0, "number of recent lines to show")
+	return cmd
+}
+
+// showManifest 读取并输出 Manifest，表格模式下附带步骤列表
+func showManifest(ctx context.Context, c *Client, id string, w io.Writer) error {
+	var m manifest
+	raw, err := c.Get(ctx, "/manifests/"+id, nil, &m)
+	if err != nil {
+		return err
+	}
+	if opts.output != OutputTable {
+		return render(w, opts.output, raw, nil)
+	}
+
+	summary := &table{header: []string{"FIELD", "VALUE"}}
+	summary.add("ID", m.ID.Hex())
+	summary.add("NAME", m.Name)
+	summary.add("APPLICATION", m.ApplicationName)
+	summary.add("REF", m.ref())
+	summary.add("COMMIT", orDash(m.CommitHash))
+	summary.add("DIGEST", orDash(m.Digest))
+	summary.add("STATUS", string(m.Status))
+	summary.add("CREATED", m.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if err := render(w, OutputTable, raw, summary); err != nil {
+		return err
+	}
+	if len(m.Steps) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	steps := &table{header: []string{"STEP", "STATUS", "DURATION", "MESSAGE"}}
+	for _, s := range m.Steps {
+		steps.add(s.TaskName, string(s.Status), duration(s.StartTime, s.EndTime), orDash(s.Message))
+	}
+	return render(w, OutputTable, raw, steps)
+}
+
+func streamStepLogs(ctx context.Context, c *Client, id, step string, query url.Values, w io.Writer) error {
+	body, err := c.Stream(ctx, "/manifests/"+id+"/steps/"+url.PathEscape(step)+"/logs", query, "")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// streamAllStepLogs 依次输出已开始的步骤；follow 时持续等待后续步骤直到构建结束
+func streamAllStepLogs(ctx context.Context, c *Client, id string, query url.Values, follow bool, w io.Writer) error {
+	done := map[string]bool{}
+	for {
+		m, err := getManifest(ctx, c, id)
+		if err != nil {
+			return err
+		}
+		final := isFinalManifest(m.Status)
+
+		printed := false
+		for _, s := range m.Steps {
+			if done[s.TaskName] || s.StartTime == nil {
+				continue
+			}
+			fmt.Fprintf(w, "==> %s <==\n", s.TaskName)
+			if err := streamStepLogs(ctx, c, id, s.TaskName, query, w); err != nil {
+				return err
+			}
+			done[s.TaskName] = true
+			printed = true
+		}
+
+		if !follow || (final && !printed) {
+			return nil
+		}
+		if !printed {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}
+}
+
+// ref 构建使用的 branch 或 tag
+func (m *manifest) ref() string {
+	if m.Tag != "" {
+		return "tag:" + m.Tag
+	}
+	return orDash(m.Branch)
+}
+
+func shortCommit(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+func setBody(body map[string]string, key, value string) {
+	if value != "" {
+		body[key] = value
+	}
+}
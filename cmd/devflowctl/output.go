@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// table 表格输出；json / yaml 输出直接转换服务端原始 JSON，以保留全部字段
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cols ...string) {
+	t.rows = append(t.rows, cols)
+}
+
+func validateOutput(format string) error {
+	switch format {
+	case OutputTable, OutputJSON, OutputYAML:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q (table | json | yaml)", format)
+}
+
+// render 按 format 输出：table 使用 t，json / yaml 直接转换服务端返回的 raw
+func render(w io.Writer, format string, raw json.RawMessage, t *table) error {
+	switch format {
+	case OutputJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, raw, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(w)
+		return err
+	case OutputYAML:
+		data, err := yaml.JSONToYAML(raw)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// age 以 kubectl 风格显示距今时长
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// duration 步骤耗时，未结束时按当前时间计算
+func duration(start, end *time.Time) string {
+	if start == nil {
+		return "-"
+	}
+	stop := time.Now()
+	if end != nil {
+		stop = *end
+	}
+	return stop.Sub(*start).Truncate(time.Second).String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// marshalRaw 本地数据转为 render 使用的 JSON
+func marshalRaw(v any) (json.RawMessage, error) {
+	return json.Marshal(v)
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bsonger/devflow-common/model"
+)
+
+// 与服务端 pkg/events 中的事件类型一致
+const (
+	eventManifestStep   = "manifest.step"
+	eventManifestStatus = "manifest.status"
+	eventJobStatus      = "job.status"
+)
+
+const (
+	// streamBufferSize 单条 SSE 事件的最大长度
+	streamBufferSize = 1 << 20
+	// pollInterval 与事件流并行轮询当前状态的周期，事件被丢弃或流断开时仍能结束等待
+	pollInterval = 15 * time.Second
+)
+
+// event /events/stream 推送的事件
+type event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Resource   string          `json:"resource"`
+	ResourceID string          `json:"resource_id"`
+	Time       time.Time       `json:"time"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// stepData manifest.step 事件的 data
+type stepData struct {
+	TaskName  string     `json:"task_name"`
+	Status    string     `json:"status"`
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Message   string     `json:"message"`
+}
+
+// statusData manifest.status / job.status 事件的 data
+type statusData struct {
+	Status string `json:"status"`
+}
+
+// errFailed 资源以失败状态结束，命令以非零退出
+var errFailed = errors.New("finished with a failure status")
+
+// subscribe 订阅单个资源的事件；连接建立后才返回，调用方随后读取当前状态，避免遗漏两者之间的变化
+func subscribe(ctx context.Context, c *Client, resource, id string) (<-chan event, <-chan error, error) {
+	query := url.Values{"resource": {resource}, "id": {id}}
+	body, err := c.Stream(ctx, "/events/stream", query, "text/event-stream")
+	if err != nil {
+		return nil, nil, fmt.Errorf("subscribe events: %w", err)
+	}
+
+	out := make(chan event, 64)
+	errs := make(chan error, 1)
+	go func() {
+		defer body.Close()
+		defer close(out)
+		errs <- readSSE(body, func(e event) {
+			select {
+			case out <- e:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return out, errs, nil
+}
+
+// readSSE 逐条解析 SSE，只关心 data 字段，忽略心跳注释
+func readSSE(r io.Reader, fn func(event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), streamBufferSize)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				var e event
+				if err := json.Unmarshal([]byte(data.String()), &e); err == nil {
+					fn(e)
+				}
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// manifest 包含 devflow 扩展字段的 Manifest
+type manifest struct {
+	model.Manifest
+	Tag     string `json:"tag,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// watchManifest 跟踪构建进度直到 Succeeded / Failed，进度输出到 stderr
+func watchManifest(ctx context.Context, c *Client, id string, timeout time.Duration) (*manifest, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	events, errs, err := subscribe(ctx, c, "manifest", id)
+	if err != nil {
+		progress("%v, falling back to polling", err)
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	printer := &stepPrinter{seen: map[string]string{}}
+	current, err := getManifest(ctx, c, id)
+	if err != nil {
+		return nil, err
+	}
+	printer.printSteps(current.Steps)
+	if isFinalManifest(current.Status) {
+		return current, manifestResult(current)
+	}
+	progress("manifest %s: %s", current.Name, current.Status)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return current, waitError(ctx, "manifest", id)
+		case err := <-errs:
+			progress("event stream closed: %v, falling back to polling", err)
+			errs = nil
+		case <-ticker.C:
+			m, err := getManifest(ctx, c, id)
+			if err != nil {
+				progress("poll manifest %s failed: %v", id, err)
+				continue
+			}
+			current = m
+			printer.printSteps(current.Steps)
+			if isFinalManifest(current.Status) {
+				return current, manifestResult(current)
+			}
+		case e, ok := <-events:
+			if !ok {
+				// 流结束后等待 errs 返回原因
+				events = nil
+				continue
+			}
+			switch e.Type {
+			case eventManifestStep:
+				var step stepData
+				if json.Unmarshal(e.Data, &step) == nil {
+					printer.print(step.TaskName, step.Status, step.StartTime, step.EndTime, step.Message)
+				}
+			case eventManifestStatus:
+				var s statusData
+				if json.Unmarshal(e.Data, &s) != nil || !isFinalManifest(model.ManifestStatus(s.Status)) {
+					continue
+				}
+				// 重新读取以获得 commit / digest 等最终字段
+				if current, err = getManifest(ctx, c, id); err != nil {
+					return nil, err
+				}
+				printer.printSteps(current.Steps)
+				return current, manifestResult(current)
+			}
+		}
+	}
+}
+
+// watchJob 跟踪部署进度直到结束状态，进度输出到 stderr
+func watchJob(ctx context.Context, c *Client, id string, timeout time.Duration) (*model.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	events, errs, err := subscribe(ctx, c, "job", id)
+	if err != nil {
+		progress("%v, falling back to polling", err)
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	current, err := getJob(ctx, c, id)
+	if err != nil {
+		return nil, err
+	}
+	progress("job %s (%s %s): %s", id, current.Type, current.Env, current.Status)
+	if isFinalJob(current.Status) {
+		return current, jobResult(current)
+	}
+
+	last := current.Status
+	for {
+		select {
+		case <-ctx.Done():
+			return current, waitError(ctx, "job", id)
+		case err := <-errs:
+			progress("event stream closed: %v, falling back to polling", err)
+			errs = nil
+		case <-ticker.C:
+			job, err := getJob(ctx, c, id)
+			if err != nil {
+				progress("poll job %s failed: %v", id, err)
+				continue
+			}
+			current = job
+			if current.Status != last {
+				last = current.Status
+				progress("job %s: %s", id, last)
+			}
+			if isFinalJob(current.Status) {
+				return current, jobResult(current)
+			}
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if e.Type != eventJobStatus {
+				continue
+			}
+			var s statusData
+			if json.Unmarshal(e.Data, &s) != nil || model.JobStatus(s.Status) == last {
+				continue
+			}
+			last = model.JobStatus(s.Status)
+			progress("job %s: %s", id, last)
+			if !isFinalJob(last) {
+				continue
+			}
+			if current, err = getJob(ctx, c, id); err != nil {
+				return nil, err
+			}
+			return current, jobResult(current)
+		}
+	}
+}
+
+func getManifest(ctx context.Context, c *Client, id string) (*manifest, error) {
+	m := &manifest{}
+	if _, err := c.Get(ctx, "/manifests/"+id, nil, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func getJob(ctx context.Context, c *Client, id string) (*model.Job, error) {
+	job := &model.Job{}
+	if _, err := c.Get(ctx, "/jobs/"+id, nil, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func isFinalManifest(s model.ManifestStatus) bool {
+	return s == model.ManifestSucceeded || s == model.ManifestFailed
+}
+
+func isFinalJob(s model.JobStatus) bool {
+	switch s {
+	case model.JobSucceeded, model.JobFailed, model.JobSyncFailed, model.JobRolledBack:
+		return true
+	}
+	return false
+}
+
+func manifestResult(m *manifest) error {
+	if m.Status != model.ManifestSucceeded {
+		return fmt.Errorf("manifest %s %w: %s", m.Name, errFailed, m.Status)
+	}
+	return nil
+}
+
+// jobResult RolledBack 表示部署失败后已自动回滚，同样视为失败
+func jobResult(job *model.Job) error {
+	if job.Status != model.JobSucceeded {
+		return fmt.Errorf("job %s %w: %s", job.ID.Hex(), errFailed, job.Status)
+	}
+	return nil
+}
+
+func waitError(ctx context.Context, resource, id string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out waiting for %s %s", resource, id)
+	}
+	return ctx.Err()
+}
+
+// stepPrinter 每个步骤的每种状态只输出一次
+type stepPrinter struct {
+	seen map[string]string
+}
+
+func (p *stepPrinter) printSteps(steps []model.ManifestStep) {
+	for _, s := range steps {
+		p.print(s.TaskName, string(s.Status), s.StartTime, s.EndTime, s.Message)
+	}
+}
+
+func (p *stepPrinter) print(task, status string, start, end *time.Time, message string) {
+	if task == "" || p.seen[task] == status || status == string(model.StepPending) {
+		return
+	}
+	p.seen[task] = status
+
+	line := fmt.Sprintf("step %-24s %s", task, status)
+	if end != nil {
+		line += " (" + duration(start, end) + ")"
+	}
+	if message != "" && status == string(model.StepFailed) {
+		line += ": " + message
+	}
+	progress("%s", line)
+}
+
+// progress 进度信息写 stderr，stdout 保留给 -o 输出
+func progress(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "%s  "+format+"\n", append([]any{time.Now().Format("15:04:05")}, args...)...)
+}
@@ -2,12 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/bsonger/devflow-common/client/logging"
 	_ "github.com/bsonger/devflow/docs" // swagger docs 自动生成
 	"github.com/bsonger/devflow/pkg/config"
+	"github.com/bsonger/devflow/pkg/events"
 	"github.com/bsonger/devflow/pkg/router"
+	"github.com/bsonger/devflow/pkg/service"
 	"go.uber.org/zap"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// readinessDelay 收到 SIGTERM 后先让 /readyz 失败的时间，等待负载均衡摘除本实例后再停止接收请求
+	readinessDelay = 5 * time.Second
+	// drainTimeout 等待进行中请求与后台任务退出的总时间；readinessDelay + drainTimeout + flushTimeout 应小于 Pod terminationGracePeriodSeconds
+	drainTimeout = 20 * time.Second
+	// flushTimeout 退出前刷新遥测数据的时间
+	flushTimeout = 5 * time.Second
 )
 
 // @title			DevFlow CD Platform API
@@ -21,22 +37,95 @@ import (
 // @license.url	http://www.apache.org/licenses/LICENSE-2.0.html
 // @schemes		http https
 func main() {
+	// SIGINT / SIGTERM 取消 ctx 后开始退出；后台任务使用独立的 workerCtx，HTTP 请求处理完后才取消
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+
 	cfg, err := config.Load()
 	if err != nil {
 		panic(err)
 	}
-	err = config.InitConfig(context.Background(), cfg)
+	err = config.InitConfig(ctx, cfg)
 	if err != nil {
 		panic(err)
 	}
+	if err := service.EnsureIndexes(ctx); err != nil {
+		panic(err)
+	}
+
+	// 1️⃣ 后台任务
+	if cfg.Retention != nil {
+		retention := time.Duration(cfg.Retention.Days) * 24 * time.Hour
+		service.StartRetentionPurger(workerCtx, retention, cfg.Retention.Interval)
+	}
+	service.StartOutboxDispatcher(workerCtx)
+	service.StartEventStreamer(workerCtx)
+	service.StartWebhookDispatcher(workerCtx)
+	service.StartDoraExporter(workerCtx)
+	if cfg.Drift != nil && cfg.Drift.Enabled {
+		service.StartDriftScanner(workerCtx, cfg.Drift.Interval, cfg.Drift.AutoResync)
+	}
+
+	go func() {
+		if err := service.StartArgoCdInformer(workerCtx); err != nil {
+			logging.Logger.Error("start argo cd informer failed", zap.Error(err))
+		}
+	}()
 
-	router.StartMetricsServer(":9090")
-	r := router.NewRouter()
+	// 2️⃣ HTTP 服务
+	metricsServer := router.StartMetricsServer(":9090")
 
 	port := cfg.Server.Port
-	logging.Logger.Info("server start")
-	logging.Logger.Info("starting server", zap.Int("port", port))
-	if err := r.Run(fmt.Sprintf(":%d", port)); err != nil {
-		logging.Logger.Fatal("failed to run server", zap.Error(err))
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           router.NewRouter(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// 事件推送是长连接，Shutdown 不会主动结束，关闭订阅让其立即返回
+	srv.RegisterOnShutdown(events.CloseAll)
+
+	go func() {
+		logging.Logger.Info("starting server", zap.Int("port", port))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Logger.Error("failed to run server", zap.Error(err))
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	logging.Logger.Info("shutting down",
+		zap.Duration("readiness_delay", readinessDelay),
+		zap.Duration("drain_timeout", drainTimeout),
+	)
+
+	// 3️⃣ /readyz 先返回 503，负载均衡摘除本实例前仍正常处理请求
+	service.HealthService.SetDraining()
+	time.Sleep(readinessDelay)
+
+	// 4️⃣ 等待进行中的请求完成，再停止后台任务并等待其退出，共用 drainTimeout
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		logging.Logger.Warn("http server drain incomplete", zap.Error(err))
+		_ = srv.Close()
+	}
+	cancelWorkers()
+	if err := service.WaitWorkers(drainCtx); err != nil {
+		logging.Logger.Warn("background workers did not stop in time", zap.Error(err))
+	}
+	if err := metricsServer.Shutdown(drainCtx); err != nil {
+		_ = metricsServer.Close()
+	}
+
+	// 5️⃣ 刷新 trace / metric / profile 并断开 Mongo
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancelFlush()
+	if err := config.Shutdown(flushCtx); err != nil {
+		logging.Logger.Warn("flush telemetry failed", zap.Error(err))
 	}
+	logging.Logger.Info("server stopped")
+	_ = logging.Logger.Sync()
 }
@@ -4,11 +4,12 @@ import (
 	"errors"
 	"net/http"
 
-	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
 	"github.com/bsonger/devflow/pkg/service"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 var ApplicationRouteApi = NewApplicationHandler()
@@ -30,11 +31,11 @@ type UpdateActiveManifestRequest struct {
 // @Tags Application
 // @Accept json
 // @Produce json
-// @Param data body model.Application true "Application Data"
+// @Param data body domain.Application true "Application Data"
 // @Success 200 {object} map[string]string
 // @Router /api/v1/applications [post]
 func (h *ApplicationHandler) Create(c *gin.Context) {
-	var app *model.Application
+	var app *domain.Application
 	if err := c.ShouldBindJSON(&app); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -42,6 +43,12 @@ func (h *ApplicationHandler) Create(c *gin.Context) {
 	app.WithCreateDefault()
 	id, err := service.ApplicationService.Create(c.Request.Context(), app)
 	if err != nil {
+		if errors.Is(err, service.ErrConfigurationNotFound) || errors.Is(err, service.ErrSecretNotFound) ||
+			errors.Is(err, service.ErrSecretProjectMismatch) ||
+			errors.Is(err, domain.ErrInvalidAutoDeployRule) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -53,7 +60,7 @@ func (h *ApplicationHandler) Create(c *gin.Context) {
 // @Summary	获取应用
 // @Tags		Application
 // @Param		id	path		string	true	"Application ID"
-// @Success	200	{object}	model.Application
+// @Success	200	{object}	domain.Application
 // @Router		/api/v1/applications/{id} [get]
 func (h *ApplicationHandler) Get(c *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
@@ -75,7 +82,7 @@ func (h *ApplicationHandler) Get(c *gin.Context) {
 // @Summary	更新应用
 // @Tags		Application
 // @Param		id		path		string				true	"Application ID"
-// @Param		data	body		model.Application	true	"Application Data"
+// @Param		data	body		domain.Application	true	"Application Data"
 // @Success	200		{object}	map[string]string
 // @Router		/api/v1/applications/{id} [put]
 func (h *ApplicationHandler) Update(c *gin.Context) {
@@ -85,7 +92,7 @@ func (h *ApplicationHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var app model.Application
+	var app domain.Application
 	if err := c.ShouldBindJSON(&app); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -94,6 +101,12 @@ func (h *ApplicationHandler) Update(c *gin.Context) {
 	app.SetID(id)
 
 	if err := service.ApplicationService.Update(c.Request.Context(), &app); err != nil {
+		if errors.Is(err, service.ErrConfigurationNotFound) || errors.Is(err, service.ErrSecretNotFound) ||
+			errors.Is(err, service.ErrSecretProjectMismatch) ||
+			errors.Is(err, domain.ErrInvalidAutoDeployRule) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -103,8 +116,10 @@ func (h *ApplicationHandler) Update(c *gin.Context) {
 
 // Delete
 // @Summary	删除应用
+// @Description	软删除应用并级联隐藏其 Manifest / Job，teardown=true 时同时删除 Argo CD Application
 // @Tags		Application
-// @Param		id	path		string	true	"Application ID"
+// @Param		id			path		string	true	"Application ID"
+// @Param		teardown	query		bool	false	"是否删除 Argo CD Application"
 // @Success	200	{object}	map[string]string
 // @Router		/api/v1/applications/{id} [delete]
 func (h *ApplicationHandler) Delete(c *gin.Context) {
@@ -114,7 +129,15 @@ func (h *ApplicationHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := service.ApplicationService.Delete(c.Request.Context(), id); err != nil {
+	opts := service.DeleteOptions{
+		Teardown: queryBool(c, "teardown"),
+	}
+
+	if err := service.ApplicationService.Delete(c.Request.Context(), id, opts); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -122,6 +145,194 @@ func (h *ApplicationHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
+// Restore
+// @Summary	恢复已删除的应用
+// @Description	恢复应用及随其级联删除的 Manifest / Job
+// @Tags		Application
+// @Param		id	path		string	true	"Application ID"
+// @Success	200	{object}	map[string]string
+// @Router		/api/v1/applications/{id}/restore [post]
+func (h *ApplicationHandler) Restore(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := service.ApplicationService.Restore(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrResourceNotDeleted) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "restored"})
+}
+
+// Status
+// @Summary	获取应用实时状态
+// @Description	从 Argo CD 读取同步状态、健康状态、资源树（Deployment 下含 Pod）、当前 revision / 镜像与最近的同步操作，结果短暂缓存
+// @Tags		Application
+// @Param		id	path		string	true	"Application ID"
+// @Success	200	{object}	service.ApplicationLiveStatus
+// @Router		/api/v1/applications/{id}/status [get]
+func (h *ApplicationHandler) Status(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	status, err := service.ApplicationStatusService.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrApplicationNotDeployed) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Logs
+// @Summary	应用运行日志
+// @Description	读取 Deployment 各 Pod 的容器日志并合并输出，每行以 [pod] 前缀区分；需携带可访问该项目的 Bearer Token，未配置鉴权时拒绝访问。默认 chunked text/plain，Accept: text/event-stream 或 format=sse 时以 SSE 推送
+// @Tags		Application
+// @Produce	plain
+// @Param		id			path	string	true	"Application ID"
+// @Param		env			query	string	false	"环境，与当前部署环境不一致时 404"
+// @Param		container	query	string	false	"容器名，默认与 Application 同名的主容器"
+// @Param		since		query	string	false	"只返回最近一段时间，如 10m"
+// @Param		follow		query	bool	false	"持续跟随"
+// @Param		tail_lines	query	int		false	"每个 Pod 只返回最后 N 行"
+// @Param		timestamps	query	bool	false	"每行附带时间戳"
+// @Param		format		query	string	false	"sse"
+// @Success	200	{string}	string
+// @Failure	401	{object}	map[string]string
+// @Failure	403	{object}	map[string]string
+// @Router		/api/v1/applications/{id}/logs [get]
+func (h *ApplicationHandler) Logs(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	opts, err := parseLogOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rc, err := service.LogService.ApplicationLogs(c.Request.Context(), id, c.Query("env"), opts)
+	if err != nil {
+		if writeAuthError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, service.ErrApplicationNotDeployed),
+			errors.Is(err, service.ErrNoRunningPods), k8sErrors.IsNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrContainerNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	writeLogStream(c, rc)
+}
+
+// Diff
+// @Summary	对比当前版本与候选 Manifest
+// @Description	返回 active Manifest 与候选 Manifest 的字段差异、提交范围与渲染 YAML 的 unified diff；live=true 时附带集群实际资源的差异
+// @Tags		Application
+// @Param		id			path		string	true	"Application ID"
+// @Param		manifest_id	query		string	true	"候选 Manifest ID"
+// @Param		env			query		string	false	"环境，默认 prod"
+// @Param		live		query		bool	false	"是否对比集群实际资源"
+// @Success	200	{object}	service.ManifestDiff
+// @Router		/api/v1/applications/{id}/diff [get]
+func (h *ApplicationHandler) Diff(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	manifestID, err := primitive.ObjectIDFromHex(c.Query("manifest_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid manifest_id"})
+		return
+	}
+
+	diff, err := service.DiffService.Compare(c.Request.Context(), id, manifestID, c.Query("env"), queryBool(c, "live"))
+	if err != nil {
+		if errors.Is(err, service.ErrManifestNotForApplication) || errors.Is(err, domain.ErrInvalidEnv) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// ListAutoDeploys
+// @Summary	获取应用的自动部署记录
+// @Tags		Application
+// @Param		id	path		string	true	"Application ID"
+// @Param		env	query		string	false	"环境"
+// @Success	200	{array}		domain.AutoDeployRecord
+// @Router		/api/v1/applications/{id}/auto_deploys [get]
+func (h *ApplicationHandler) ListAutoDeploys(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	filter := primitive.M{"application_id": id}
+	if env := c.Query("env"); env != "" {
+		filter["env"] = env
+	}
+
+	records, err := service.AutoDeployService.ListRecords(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	paging, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := len(records)
+	records = paginateSlice(records, paging)
+	setPaginationHeaders(c, total, paging)
+
+	c.JSON(http.StatusOK, records)
+}
+
 // UpdateActiveManifest
 // @Summary	更新应用的 Active Manifest
 // @Tags		Application
@@ -149,7 +360,7 @@ func (h *ApplicationHandler) UpdateActiveManifest(c *gin.Context) {
 	}
 
 	if err := service.ApplicationService.UpdateActiveManifest(c.Request.Context(), appID, manifestID); err != nil {
-		if errors.Is(err, service.ErrManifestNotForApplication) {
+		if errors.Is(err, service.ErrManifestNotForApplication) || errors.Is(err, domain.ErrInvalidEnv) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -167,7 +378,7 @@ func (h *ApplicationHandler) UpdateActiveManifest(c *gin.Context) {
 // List
 // @Summary 获取应用列表
 // @Tags    Application
-// @Success 200 {array} model.Application
+// @Success 200 {array} domain.Application
 // @Router  /api/v1/applications [get]
 func (h *ApplicationHandler) List(c *gin.Context) {
 	filter := primitive.M{}
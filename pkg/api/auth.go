@@ -0,0 +1,31 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bsonger/devflow/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// writeAuthError 鉴权错误写回 401 / 403，返回是否为鉴权错误
+func writeAuthError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated), errors.Is(err, auth.ErrInvalidToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrForbidden), errors.Is(err, auth.ErrNotConfigured):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// authorizeProject 校验调用方能否访问 project，失败时已写回响应
+func authorizeProject(c *gin.Context, project string) bool {
+	if err := auth.AuthorizeProject(c.Request.Context(), project); err != nil {
+		writeAuthError(c, err)
+		return false
+	}
+	return true
+}
@@ -1,12 +1,15 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
-	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
 	"github.com/bsonger/devflow/pkg/service"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var ConfigurationRouteApi = NewConfigurationHandler()
@@ -14,6 +17,16 @@ var ConfigurationRouteApi = NewConfigurationHandler()
 type ConfigurationHandler struct {
 }
 
+type RevertConfigurationRequest struct {
+	Revision int `json:"revision" binding:"required,min=1"`
+}
+
+type ConfigurationDiffResponse struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
+
 func NewConfigurationHandler() *ConfigurationHandler {
 	return &ConfigurationHandler{}
 }
@@ -24,11 +37,11 @@ func NewConfigurationHandler() *ConfigurationHandler {
 // @Tags Configuration
 // @Accept json
 // @Produce json
-// @Param data body model.Configuration true "Configuration Data"
+// @Param data body domain.Configuration true "Configuration Data"
 // @Success 200 {object} map[string]string
 // @Router /api/v1/configurations [post]
 func (h *ConfigurationHandler) Create(c *gin.Context) {
-	var cfg *model.Configuration
+	var cfg *domain.Configuration
 	if err := c.ShouldBindJSON(&cfg); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -49,7 +62,7 @@ func (h *ConfigurationHandler) Create(c *gin.Context) {
 // @Summary 获取配置
 // @Tags    Configuration
 // @Param   id path string true "Configuration ID"
-// @Success 200 {object} model.Configuration
+// @Success 200 {object} domain.Configuration
 // @Router  /api/v1/configurations/{id} [get]
 func (h *ConfigurationHandler) Get(c *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
@@ -71,7 +84,7 @@ func (h *ConfigurationHandler) Get(c *gin.Context) {
 // @Summary 更新配置
 // @Tags    Configuration
 // @Param   id   path string               true "Configuration ID"
-// @Param   data body model.Configuration true "Configuration Data"
+// @Param   data body domain.Configuration true "Configuration Data"
 // @Success 200  {object} map[string]string
 // @Router  /api/v1/configurations/{id} [put]
 func (h *ConfigurationHandler) Update(c *gin.Context) {
@@ -81,7 +94,7 @@ func (h *ConfigurationHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var cfg model.Configuration
+	var cfg domain.Configuration
 	if err := c.ShouldBindJSON(&cfg); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -90,6 +103,10 @@ func (h *ConfigurationHandler) Update(c *gin.Context) {
 	cfg.SetID(id)
 
 	if err := service.ConfigurationService.Update(c.Request.Context(), &cfg); err != nil {
+		if errors.Is(err, service.ErrConfigurationConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -118,10 +135,39 @@ func (h *ConfigurationHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
+// Restore
+// @Summary 恢复已删除的配置
+// @Tags    Configuration
+// @Param   id path string true "Configuration ID"
+// @Success 200 {object} map[string]string
+// @Router  /api/v1/configurations/{id}/restore [post]
+func (h *ConfigurationHandler) Restore(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := service.ConfigurationService.Restore(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrResourceNotDeleted) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "restored"})
+}
+
 // List
 // @Summary 获取配置列表
 // @Tags    Configuration
-// @Success 200 {array} model.Configuration
+// @Success 200 {array} domain.Configuration
 // @Router  /api/v1/configurations [get]
 func (h *ConfigurationHandler) List(c *gin.Context) {
 	filter := primitive.M{}
@@ -150,3 +196,145 @@ func (h *ConfigurationHandler) List(c *gin.Context) {
 
 	c.JSON(http.StatusOK, cfgs)
 }
+
+// ListRevisions
+// @Summary 获取配置修订历史
+// @Tags    Configuration
+// @Param   id path string true "Configuration ID"
+// @Success 200 {array} domain.ConfigurationRevision
+// @Router  /api/v1/configurations/{id}/revisions [get]
+func (h *ConfigurationHandler) ListRevisions(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	revisions, err := service.ConfigurationService.ListRevisions(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	paging, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := len(revisions)
+	revisions = paginateSlice(revisions, paging)
+	setPaginationHeaders(c, total, paging)
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision
+// @Summary 获取配置的指定修订
+// @Tags    Configuration
+// @Param   id       path string true "Configuration ID"
+// @Param   revision path int    true "Revision"
+// @Success 200 {object} domain.ConfigurationRevision
+// @Router  /api/v1/configurations/{id}/revisions/{revision} [get]
+func (h *ConfigurationHandler) GetRevision(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return
+	}
+
+	rev, err := service.ConfigurationService.GetRevision(c.Request.Context(), id, revision)
+	if err != nil {
+		if errors.Is(err, service.ErrRevisionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rev)
+}
+
+// Diff
+// @Summary 对比配置的两个修订
+// @Description 返回 from → to 的 unified diff
+// @Tags    Configuration
+// @Param   id   path  string true "Configuration ID"
+// @Param   from query int    true "起始修订"
+// @Param   to   query int    true "目标修订"
+// @Success 200 {object} ConfigurationDiffResponse
+// @Router  /api/v1/configurations/{id}/diff [get]
+func (h *ConfigurationHandler) Diff(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+
+	diff, err := service.ConfigurationService.Diff(c.Request.Context(), id, from, to)
+	if err != nil {
+		if errors.Is(err, service.ErrRevisionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ConfigurationDiffResponse{From: from, To: to, Diff: diff})
+}
+
+// Revert
+// @Summary 回退配置到指定修订
+// @Description 以目标修订的内容生成新的修订
+// @Tags    Configuration
+// @Param   id   path string                     true "Configuration ID"
+// @Param   data body RevertConfigurationRequest true "Revert Data"
+// @Success 200 {object} domain.Configuration
+// @Router  /api/v1/configurations/{id}/revert [post]
+func (h *ConfigurationHandler) Revert(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req RevertConfigurationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg, err := service.ConfigurationService.Revert(c.Request.Context(), id, req.Revision)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRevisionNotFound), errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		case errors.Is(err, service.ErrConfigurationConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, cfg)
+}
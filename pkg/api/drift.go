@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/bsonger/devflow/pkg/service"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var DriftRouteApi = NewDriftHandler()
+
+type DriftHandler struct {
+}
+
+func NewDriftHandler() *DriftHandler {
+	return &DriftHandler{}
+}
+
+// List
+// @Summary 获取漂移事件
+// @Tags    Drift
+// @Param   application_id query string false "Application ID"
+// @Param   open           query bool   false "仅返回未恢复的事件"
+// @Success 200 {array} domain.DriftEvent
+// @Router  /api/v1/drift_events [get]
+func (h *DriftHandler) List(c *gin.Context) {
+	filter := primitive.M{}
+	if appID := c.Query("application_id"); appID != "" {
+		id, err := primitive.ObjectIDFromHex(appID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid application_id"})
+			return
+		}
+		filter["application_id"] = id
+	}
+	if queryBool(c, "open") {
+		filter["resolved_at"] = primitive.M{"$exists": false}
+	}
+
+	events, err := service.DriftService.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	paging, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := len(events)
+	events = paginateSlice(events, paging)
+	setPaginationHeaders(c, total, paging)
+
+	c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow/pkg/auth"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// eventHeartbeat 空闲时的心跳间隔，避免代理断开长连接
+const eventHeartbeat = 15 * time.Second
+
+var EventRouteApi = NewEventHandler()
+
+type EventHandler struct {
+	upgrader websocket.Upgrader
+}
+
+func NewEventHandler() *EventHandler {
+	return &EventHandler{
+		upgrader: websocket.Upgrader{CheckOrigin: checkOrigin},
+	}
+}
+
+// checkOrigin 非浏览器客户端（无 Origin）与同源请求放行，跨域来源须配置在 auth.allowed_origins 中
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host) || auth.OriginAllowed(origin)
+}
+
+var eventTypes = map[events.Type]bool{
+	events.ManifestCreated:          true,
+	events.ManifestStepChanged:      true,
+	events.ManifestStatusChanged:    true,
+	events.JobCreated:               true,
+	events.JobStatusChanged:         true,
+	events.ActiveManifestChanged:    true,
+	events.ApplicationHealthChanged: true,
+}
+
+var eventResources = map[events.Resource]bool{
+	events.ResourceManifest:    true,
+	events.ResourceJob:         true,
+	events.ResourceApplication: true,
+}
+
+// parseEventFilter 解析 types / resource / id / application_id
+func parseEventFilter(c *gin.Context) (events.Filter, error) {
+	f := events.Filter{
+		Resource:      events.Resource(strings.TrimSpace(c.Query("resource"))),
+		ResourceID:    strings.TrimSpace(c.Query("id")),
+		ApplicationID: strings.TrimSpace(c.Query("application_id")),
+	}
+	if f.Resource != "" && !eventResources[f.Resource] {
+		return f, fmt.Errorf("invalid resource %q", f.Resource)
+	}
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !eventTypes[events.Type(t)] {
+			return f, fmt.Errorf("invalid event type %q", t)
+		}
+		f.Types = append(f.Types, events.Type(t))
+	}
+	return f, nil
+}
+
+// Ticket
+// @Summary 签发事件流票据
+// @Description 浏览器 EventSource / WebSocket 无法携带 Authorization 头：先以 Bearer Token 调用本接口，再以 ?ticket= 建立事件流。
+// @Description 票据 1 分钟内有效，只在建立连接时校验，权限与签发它的 token 相同
+// @Tags    Event
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/events/ticket [post]
+func (h *EventHandler) Ticket(c *gin.Context) {
+	ticket, expiresAt, err := auth.IssueTicket(c.Request.Context())
+	if err != nil {
+		if !writeAuthError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expires_at": expiresAt.Format(time.RFC3339)})
+}
+
+// Stream
+// @Summary 订阅 Manifest / Job / Application 状态事件
+// @Description 默认以 SSE 推送（event 为事件类型，data 为事件 JSON）；携带 WebSocket Upgrade 头时改用 WebSocket，每条消息一个事件 JSON。
+// @Description 只推送调用方可访问项目的事件；凭据为 Authorization: Bearer 头，或 POST /api/v1/events/ticket 签发的 ticket 查询参数（浏览器 EventSource / WebSocket）。
+// @Description WebSocket 跨域来源须配置在 auth.allowed_origins 中
+// @Tags    Event
+// @Produce text/event-stream
+// @Param   ticket         query string false "事件流票据，未携带 Authorization 头时使用"
+// @Param   types          query string false "事件类型，逗号分隔：manifest.created,manifest.step,manifest.status,job.created,job.status,application.active_manifest,application.health"
+// @Param   resource       query string false "manifest | job | application"
+// @Param   id             query string false "资源 ID"
+// @Param   application_id query string false "Application ID"
+// @Success 200 {object} events.Event
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/events/stream [get]
+func (h *EventHandler) Stream(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 只推送调用方可访问项目的事件
+	projects, all, err := auth.ProjectScope(c.Request.Context())
+	if err != nil {
+		writeAuthError(c, err)
+		return
+	}
+	if !all {
+		filter.Projects = append([]string{}, projects...)
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.streamWebSocket(c, filter)
+		return
+	}
+	h.streamSSE(c, filter)
+}
+
+func (h *EventHandler) streamSSE(c *gin.Context, filter events.Filter) {
+	sub := events.Subscribe(filter)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(eventHeartbeat)
+	defer ticker.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-ticker.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func (h *EventHandler) streamWebSocket(c *gin.Context, filter events.Filter) {
+	log := logging.LoggerWithContext(c.Request.Context()).With(zap.String("operation", "event_stream"))
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已写回错误响应
+		log.Warn("websocket upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	sub := events.Subscribe(filter)
+	defer sub.Close()
+
+	// 读循环只用于感知客户端关闭与处理 pong
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(eventHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
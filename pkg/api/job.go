@@ -1,12 +1,15 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
 	"github.com/bsonger/devflow/pkg/service"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var JobRouteApi = NewJobHandler()
@@ -20,11 +23,14 @@ func NewJobHandler() *JobHandler {
 
 // Create
 // @Summary 创建Job
-// @Description 创建一个新的Job
+// @Description 创建一个新的Job，type 支持 Install / Upgrade / Rollback / Uninstall；Uninstall / Rollback 可只传 application_id
 // @Tags Job
 // @Accept json
 // @Produce json
 // @Param data body model.Job true "Job Data"
+// @Param cascade query string false "Uninstall 删除策略：foreground | background | none"
+// @Param allow_downgrade query bool false "允许 Install / Upgrade 部署低于当前的版本"
+// @Param dry_run query bool false "为 true 时仅返回渲染结果（YAML），不创建 Job、不同步"
 // @Success 200 {object} map[string]string
 // @Router /api/v1/jobs [post]
 func (h *JobHandler) Create(c *gin.Context) {
@@ -33,21 +39,52 @@ func (h *JobHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+
+	opts := service.CreateJobOptions{
+		Cascade:        c.Query("cascade"),
+		AllowDowngrade: queryBool(c, "allow_downgrade"),
+	}
+
+	if queryBool(c, "dry_run") {
+		bundle, err := service.JobService.DryRun(c.Request.Context(), job, opts)
+		if err != nil {
+			writeJobCreateError(c, err)
+			return
+		}
+		writeBundle(c, bundle)
+		return
+	}
+
 	job.WithCreateDefault()
-	id, err := service.JobService.Create(c.Request.Context(), job)
+	id, err := service.JobService.Create(c.Request.Context(), job, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeJobCreateError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"id": id.Hex()})
 }
 
+func writeJobCreateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCascade), errors.Is(err, service.ErrApplicationRequired),
+		errors.Is(err, service.ErrNoRollbackTarget), errors.Is(err, service.ErrDryRunUnsupported),
+		errors.Is(err, domain.ErrInvalidEnv):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, service.ErrVersionDowngrade):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // Get
 // @Summary	获取Job
 // @Tags		Job
 // @Param		id	path		string	true	"Job ID"
-// @Success	200	{object}	model.Job
+// @Success	200	{object}	domain.Job
 // @Router		/api/v1/jobs/{id} [get]
 func (h *JobHandler) Get(c *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
@@ -119,7 +156,7 @@ func (h *JobHandler) Delete(c *gin.Context) {
 // List
 // @Summary 获取Job列表
 // @Tags    Job
-// @Success 200 {array} model.Job
+// @Success 200 {array} domain.Job
 // @Router  /api/v1/jobs [get]
 func (h *JobHandler) List(c *gin.Context) {
 	filter := primitive.M{}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsonger/devflow/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// parseLogOptions 解析日志查询参数：container / follow / tail_lines / since / timestamps
+func parseLogOptions(c *gin.Context) (service.LogOptions, error) {
+	opts := service.LogOptions{
+		Container:  strings.TrimSpace(c.Query("container")),
+		Follow:     queryBool(c, "follow"),
+		Timestamps: queryBool(c, "timestamps"),
+	}
+
+	if s := strings.TrimSpace(c.Query("tail_lines")); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("invalid tail_lines")
+		}
+		opts.TailLines = &n
+	}
+
+	if s := strings.TrimSpace(c.Query("since")); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < time.Second {
+			return opts, fmt.Errorf("invalid since")
+		}
+		sec := int64(d.Seconds())
+		opts.SinceSeconds = &sec
+	}
+
+	return opts, nil
+}
+
+// wantsSSE 客户端通过 Accept: text/event-stream 或 format=sse 请求 SSE
+func wantsSSE(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream") || c.Query("format") == "sse"
+}
+
+// writeLogStream 按行转发日志：默认 chunked text/plain，SSE 模式下每行一个 `log` 事件，结束时发送 `end` 事件
+func writeLogStream(c *gin.Context, rc io.ReadCloser) {
+	defer rc.Close()
+
+	sse := wantsSSE(c)
+	if sse {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+	} else {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+	}
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	reader := bufio.NewReader(rc)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if sse {
+				c.SSEvent("log", strings.TrimRight(line, "\n"))
+			} else if _, werr := io.WriteString(c.Writer, line); werr != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+		if err != nil {
+			if sse {
+				if err == io.EOF {
+					c.SSEvent("end", "")
+				} else {
+					c.SSEvent("error", err.Error())
+				}
+				c.Writer.Flush()
+			}
+			return
+		}
+		if c.Request.Context().Err() != nil {
+			return
+		}
+	}
+}
@@ -2,11 +2,13 @@ package api
 
 import (
 	"errors"
-	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/render"
 	"github.com/bsonger/devflow/pkg/service"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"net/http"
 )
 
@@ -21,17 +23,18 @@ func NewManifestHandler() *ManifestHandler {
 
 // Create
 // @Summary      创建 Manifest
-// @Description  根据 Manifest 创建 Manifest，自动生成名称
+// @Description  根据 Manifest 创建 Manifest，自动生成名称；指定 tag（semver）时以 <app>-<tag> 命名
 // @Tags         Manifest
 // @Accept       json
 // @Produce      json
-// @Param        data            body  model.Manifest    true "Manifest 数据（branch 必填）"
-// @Success      200  {object}  model.Manifest
+// @Param        data            body  domain.Manifest    true "Manifest 数据（branch 或 tag）"
+// @Success      200  {object}  domain.Manifest
 // @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
 // @Router       /api/v1/manifests [post]
 func (h *ManifestHandler) Create(c *gin.Context) {
 
-	var m model.Manifest
+	var m domain.Manifest
 	if err := c.ShouldBindJSON(&m); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -40,7 +43,14 @@ func (h *ManifestHandler) Create(c *gin.Context) {
 	// 保存 Manifest
 	id, err := service.ManifestService.CreateManifest(c.Request.Context(), &m)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrConfigurationNotFound), errors.Is(err, service.ErrInvalidVersionTag):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrVersionExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -50,7 +60,7 @@ func (h *ManifestHandler) Create(c *gin.Context) {
 // List
 // @Summary 获取应用列表
 // @Tags    Manifest
-// @Success 200 {array} model.Manifest
+// @Success 200 {array} domain.Manifest
 // @Router  /api/v1/manifests [get]
 func (h *ManifestHandler) List(c *gin.Context) {
 	filter := primitive.M{}
@@ -77,6 +87,12 @@ func (h *ManifestHandler) List(c *gin.Context) {
 	if name := c.Query("name"); name != "" {
 		filter["name"] = name
 	}
+	if tag := c.Query("tag"); tag != "" {
+		filter["tag"] = tag
+	}
+	if version := c.Query("version"); version != "" {
+		filter["version"] = version
+	}
 
 	manifests, err := service.ManifestService.List(c.Request.Context(), filter)
 	if err != nil {
@@ -101,7 +117,7 @@ func (h *ManifestHandler) List(c *gin.Context) {
 // @Summary	获取应用
 // @Tags		Manifest
 // @Param		id	path		string	true	"Manifest ID"
-// @Success	200	{object}	model.Manifest
+// @Success	200	{object}	domain.Manifest
 // @Router		/api/v1/manifests/{id} [get]
 func (h *ManifestHandler) Get(c *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
@@ -119,17 +135,114 @@ func (h *ManifestHandler) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, app)
 }
 
+// Render
+// @Summary 预览 Manifest 渲染结果
+// @Description 返回部署到 env 时的 ConfigMap / Deployment / Service / HTTPRoute 与 Argo CD Application（多文档 YAML，不含 Secret）；
+// @Description 未启用 GitOps 时 Argo CD 经 CMP 插件渲染，结果为近似，响应带 X-Devflow-Render-Approximate: true
+// @Tags    Manifest
+// @Produce application/yaml
+// @Param   id  path  string true  "Manifest ID"
+// @Param   env query string false "环境，默认 prod"
+// @Success 200 {string} string
+// @Router  /api/v1/manifests/{id}/render [get]
+func (h *ManifestHandler) Render(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	bundle, err := service.ManifestService.Render(c.Request.Context(), id, c.Query("env"))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidEnv) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeBundle(c, bundle)
+}
+
+// writeBundle 以多文档 YAML 输出渲染结果；结果为近似时设置 X-Devflow-Render-Approximate 头
+func writeBundle(c *gin.Context, bundle *render.Bundle) {
+	out, err := bundle.YAML()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if bundle.Approximate {
+		c.Header("X-Devflow-Render-Approximate", "true")
+	}
+	c.Data(http.StatusOK, "application/yaml", out)
+}
+
+// StepLogs
+// @Summary 构建步骤日志
+// @Description 读取步骤对应 TaskRun Pod 的容器日志；默认 chunked text/plain，Accept: text/event-stream 或 format=sse 时以 SSE 推送
+// @Tags    Manifest
+// @Produce plain
+// @Param   id         path  string true  "Manifest ID"
+// @Param   task       path  string true  "步骤 task_name"
+// @Param   container  query string false "容器名（可省略 step- 前缀），默认按顺序输出全部 step 容器"
+// @Param   follow     query bool   false "持续跟随直到容器结束"
+// @Param   tail_lines query int    false "只返回最后 N 行"
+// @Param   since      query string false "只返回最近一段时间，如 10m"
+// @Param   timestamps query bool   false "每行附带时间戳"
+// @Param   format     query string false "sse"
+// @Success 200 {string} string
+// @Router  /api/v1/manifests/{id}/steps/{task}/logs [get]
+func (h *ManifestHandler) StepLogs(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	opts, err := parseLogOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rc, err := service.LogService.StepLogs(c.Request.Context(), id, c.Param("task"), opts)
+	if err != nil {
+		if writeAuthError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, service.ErrStepNotFound), k8sErrors.IsNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrContainerNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrStepNotStarted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	writeLogStream(c, rc)
+}
+
 // Patch
 // @Summary		Patch Manifest
-// @Description	部分更新 Manifest（仅支持 digest / commit_hash）
+// @Description	部分更新 Manifest（支持 digest / commit_hash / status）；状态变更写入 ManifestStatusChanged 事件，终态不再变更
 // @Tags		Manifest
 // @Accept		json
 // @Produce		json
 // @Param		id		path		string			true	"Manifest ID"
-// @Param		data	body		model.PatchManifestRequest	false	"Patch 数据"
+// @Param		data	body		domain.ManifestPatch	false	"Patch 数据"
 // @Success		200		{object}	map[string]string
 // @Failure		400		{object}	map[string]string
 // @Failure		404		{object}	map[string]string
+// @Failure		409		{object}	map[string]string
 // @Failure		500		{object}	map[string]string
 // @Router		/api/v1/manifests/{id} [patch]
 func (h *ManifestHandler) Patch(c *gin.Context) {
@@ -142,11 +255,15 @@ func (h *ManifestHandler) Patch(c *gin.Context) {
 	}
 
 	// 2️⃣ 解析 Patch Body
-	var patch model.PatchManifestRequest
+	var patch domain.ManifestPatch
 	if err := c.ShouldBindJSON(&patch); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := patch.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 3️⃣ 调用 Service Patch
 	err = service.ManifestService.Patch(
@@ -160,6 +277,10 @@ func (h *ManifestHandler) Patch(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "manifest not found"})
 			return
 		}
+		if errors.Is(err, service.ErrManifestStatusConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
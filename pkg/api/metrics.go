@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bsonger/devflow/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultDoraRange 未指定 from 时的统计范围
+const defaultDoraRange = 30 * 24 * time.Hour
+
+var MetricsRouteApi = NewMetricsHandler()
+
+type MetricsHandler struct {
+}
+
+func NewMetricsHandler() *MetricsHandler {
+	return &MetricsHandler{}
+}
+
+// Dora
+// @Summary DORA 指标
+// @Description 部署频率、前置时间、变更失败率、恢复时间，按 project / application / env 与时间桶统计
+// @Tags    Metrics
+// @Produce json
+// @Param   project query string false "项目"
+// @Param   env     query string false "环境"
+// @Param   from    query string false "起始时间（RFC3339 或 2006-01-02），默认 30 天前"
+// @Param   to      query string false "结束时间（RFC3339 或 2006-01-02），默认当前时间"
+// @Param   bucket  query string false "day | week | month，默认 week"
+// @Success 200 {object} service.DoraReport
+// @Router  /api/v1/metrics/dora [get]
+func (h *MetricsHandler) Dora(c *gin.Context) {
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultDoraRange)
+	if v := c.Query("from"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return
+		}
+		from = t
+	}
+
+	report, err := service.DoraService.Compute(c.Request.Context(), service.DoraQuery{
+		Project: c.Query("project"),
+		Env:     c.Query("env"),
+		From:    from,
+		To:      to,
+		Bucket:  service.DoraBucket(c.Query("bucket")),
+	})
+	if errors.Is(err, service.ErrInvalidDoraQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseTimeParam 支持 RFC3339 与日期（UTC 零点）
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", v)
+}
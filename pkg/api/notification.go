@@ -0,0 +1,282 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bsonger/devflow/pkg/auth"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/notify"
+	"github.com/bsonger/devflow/pkg/secret"
+	"github.com/bsonger/devflow/pkg/service"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var NotificationRouteApi = NewNotificationHandler()
+
+type NotificationHandler struct {
+}
+
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{}
+}
+
+// NotificationChannelRequest 创建 / 更新渠道的请求体，url 传入 "******" 或留空表示保留原值
+type NotificationChannelRequest struct {
+	Name        string                    `json:"name" binding:"required"`
+	Type        notify.ChannelType        `json:"type" binding:"required"`
+	ProjectName string                    `json:"project_name" binding:"required"`
+	URL         string                    `json:"url"`
+	Recipients  []string                  `json:"recipients"`
+	Rules       []domain.NotificationRule `json:"rules"`
+	Template    string                    `json:"template"`
+	// Enabled 默认 true
+	Enabled *bool `json:"enabled"`
+}
+
+func (r *NotificationChannelRequest) channel() *domain.NotificationChannel {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &domain.NotificationChannel{
+		Name:        r.Name,
+		Type:        r.Type,
+		ProjectName: r.ProjectName,
+		Recipients:  r.Recipients,
+		Rules:       r.Rules,
+		Template:    r.Template,
+		Enabled:     enabled,
+	}
+}
+
+// NotificationChannelResponse 渠道响应，url 显示为 "******"
+type NotificationChannelResponse struct {
+	domain.NotificationChannel
+	URL string `json:"url,omitempty"`
+}
+
+func newNotificationChannelResponse(ch *domain.NotificationChannel) NotificationChannelResponse {
+	resp := NotificationChannelResponse{NotificationChannel: *ch}
+	if ch.URL != nil {
+		resp.URL = domain.MaskedValue
+	}
+	return resp
+}
+
+// Create
+// @Summary 创建通知渠道
+// @Description type 为 slack | webhook | email，rules 为空时所有 Manifest / Job 事件都会通知
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Param data body NotificationChannelRequest true "Channel Data"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/v1/notification_channels [post]
+func (h *NotificationHandler) Create(c *gin.Context) {
+	var req NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeProject(c, req.ProjectName) {
+		return
+	}
+
+	id, err := service.NotificationService.Create(c.Request.Context(), req.channel(), req.URL)
+	if err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id.Hex()})
+}
+
+// Get
+// @Summary 获取通知渠道
+// @Tags    Notification
+// @Param   id path string true "Channel ID"
+// @Success 200 {object} NotificationChannelResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/notification_channels/{id} [get]
+func (h *NotificationHandler) Get(c *gin.Context) {
+	ch, ok := authorizedChannel(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newNotificationChannelResponse(ch))
+}
+
+// Update
+// @Summary 更新通知渠道
+// @Tags    Notification
+// @Param   id   path string                     true "Channel ID"
+// @Param   data body NotificationChannelRequest true "Channel Data"
+// @Success 200  {object} map[string]string
+// @Failure 401  {object} map[string]string
+// @Failure 403  {object} map[string]string
+// @Router  /api/v1/notification_channels/{id} [put]
+func (h *NotificationHandler) Update(c *gin.Context) {
+	current, ok := authorizedChannel(c)
+	if !ok {
+		return
+	}
+
+	var req NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// 移到其他项目时同样需要目标项目的权限
+	if req.ProjectName != current.ProjectName && !authorizeProject(c, req.ProjectName) {
+		return
+	}
+
+	ch := req.channel()
+	ch.SetID(current.GetID())
+
+	if err := service.NotificationService.Update(c.Request.Context(), ch, req.URL); err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// Delete
+// @Summary 删除通知渠道
+// @Tags    Notification
+// @Param   id path string true "Channel ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/notification_channels/{id} [delete]
+func (h *NotificationHandler) Delete(c *gin.Context) {
+	ch, ok := authorizedChannel(c)
+	if !ok {
+		return
+	}
+
+	if err := service.NotificationService.Delete(c.Request.Context(), ch.GetID()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// List
+// @Summary 获取通知渠道列表
+// @Tags    Notification
+// @Param   type         query string false "slack | webhook | email"
+// @Param   project_name query string false "项目"
+// @Success 200 {array} NotificationChannelResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/notification_channels [get]
+func (h *NotificationHandler) List(c *gin.Context) {
+	filter := primitive.M{}
+	if !includeDeleted(c) {
+		filter["deleted_at"] = primitive.M{"$exists": false}
+	}
+	if kind := c.Query("type"); kind != "" {
+		filter["type"] = kind
+	}
+	projects, all, err := auth.ProjectScope(c.Request.Context())
+	if err != nil {
+		writeAuthError(c, err)
+		return
+	}
+	if projectName := c.Query("project_name"); projectName != "" {
+		if !authorizeProject(c, projectName) {
+			return
+		}
+		filter["project_name"] = projectName
+	} else if !all {
+		filter["project_name"] = primitive.M{"$in": projects}
+	}
+
+	channels, err := service.NotificationService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	paging, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := len(channels)
+	channels = paginateSlice(channels, paging)
+	setPaginationHeaders(c, total, paging)
+
+	resp := make([]NotificationChannelResponse, 0, len(channels))
+	for i := range channels {
+		resp = append(resp, newNotificationChannelResponse(&channels[i]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Test
+// @Summary 发送测试通知
+// @Description 忽略路由规则与 enabled，直接向渠道发送一条测试消息
+// @Tags    Notification
+// @Param   id path string true "Channel ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/notification_channels/{id}/test [post]
+func (h *NotificationHandler) Test(c *gin.Context) {
+	ch, ok := authorizedChannel(c)
+	if !ok {
+		return
+	}
+
+	if err := service.NotificationService.Test(c.Request.Context(), ch.GetID()); err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sent"})
+}
+
+// authorizedChannel 读取渠道并校验调用方对其项目的权限，失败时已写回响应
+func authorizedChannel(c *gin.Context) (*domain.NotificationChannel, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+	ch, err := service.NotificationService.Get(c.Request.Context(), id)
+	if err != nil {
+		writeNotificationError(c, err)
+		return nil, false
+	}
+	if !authorizeProject(c, ch.ProjectName) {
+		return nil, false
+	}
+	return ch, true
+}
+
+func writeNotificationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidNotificationChannel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, secret.ErrProviderNotInitialized), errors.Is(err, notify.ErrSMTPNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, notify.ErrNotificationFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -113,5 +113,9 @@ func setPaginationHeaders(c *gin.Context, total int, p pagination) {
 }
 
 func includeDeleted(c *gin.Context) bool {
-	return strings.EqualFold(strings.TrimSpace(c.Query("include_deleted")), "true")
+	return queryBool(c, "include_deleted")
+}
+
+func queryBool(c *gin.Context, key string) bool {
+	return strings.EqualFold(strings.TrimSpace(c.Query(key)), "true")
 }
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bsonger/devflow/pkg/auth"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/secret"
+	"github.com/bsonger/devflow/pkg/service"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sigs.k8s.io/yaml"
+)
+
+var SecretRouteApi = NewSecretHandler()
+
+type SecretHandler struct {
+}
+
+func NewSecretHandler() *SecretHandler {
+	return &SecretHandler{}
+}
+
+// SecretRequest 创建 / 更新 Secret 的请求体，data 为明文，传入 "******" 表示保留原值
+type SecretRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	ProjectName string            `json:"project_name"`
+	Type        string            `json:"type"`
+	Data        map[string]string `json:"data"`
+}
+
+// SecretResponse Secret 响应，data 中的值始终被屏蔽
+type SecretResponse struct {
+	domain.Secret
+	Data map[string]string `json:"data"`
+}
+
+func newSecretResponse(sec *domain.Secret) SecretResponse {
+	return SecretResponse{Secret: *sec, Data: sec.Masked()}
+}
+
+// Create
+// @Summary 创建 Secret
+// @Description 值使用信封加密后保存，响应中不返回明文
+// @Tags Secret
+// @Accept json
+// @Produce json
+// @Param data body SecretRequest true "Secret Data"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/v1/secrets [post]
+func (h *SecretHandler) Create(c *gin.Context) {
+	var req SecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !authorizeProject(c, req.ProjectName) {
+		return
+	}
+
+	sec := &domain.Secret{Name: req.Name, ProjectName: req.ProjectName, Type: req.Type}
+	sec.WithCreateDefault()
+
+	id, err := service.SecretService.Create(c.Request.Context(), sec, req.Data)
+	if err != nil {
+		writeSecretError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id.Hex()})
+}
+
+// Get
+// @Summary 获取 Secret（值已屏蔽）
+// @Tags    Secret
+// @Param   id path string true "Secret ID"
+// @Success 200 {object} SecretResponse
+// @Failure  401 {object} map[string]string
+// @Failure  403 {object} map[string]string
+// @Router  /api/v1/secrets/{id} [get]
+func (h *SecretHandler) Get(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	sec, err := service.SecretService.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !authorizeProject(c, sec.ProjectName) {
+		return
+	}
+
+	c.JSON(http.StatusOK, newSecretResponse(sec))
+}
+
+// Update
+// @Summary 更新 Secret
+// @Tags    Secret
+// @Param   id   path string        true "Secret ID"
+// @Param   data body SecretRequest true "Secret Data"
+// @Success 200  {object} map[string]string
+// @Failure  401 {object} map[string]string
+// @Failure  403 {object} map[string]string
+// @Router  /api/v1/secrets/{id} [put]
+func (h *SecretHandler) Update(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req SecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.authorizeSecret(c, id) || !authorizeProject(c, req.ProjectName) {
+		return
+	}
+
+	sec := &domain.Secret{Name: req.Name, ProjectName: req.ProjectName, Type: req.Type}
+	sec.SetID(id)
+
+	if err := service.SecretService.Update(c.Request.Context(), sec, req.Data); err != nil {
+		writeSecretError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// Delete
+// @Summary 删除 Secret
+// @Tags    Secret
+// @Param   id path string true "Secret ID"
+// @Success 200 {object} map[string]string
+// @Failure  401 {object} map[string]string
+// @Failure  403 {object} map[string]string
+// @Router  /api/v1/secrets/{id} [delete]
+func (h *SecretHandler) Delete(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if !h.authorizeSecret(c, id) {
+		return
+	}
+
+	if err := service.SecretService.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// List
+// @Summary 获取 Secret 列表（值已屏蔽）
+// @Tags    Secret
+// @Success 200 {array} SecretResponse
+// @Failure  401 {object} map[string]string
+// @Failure  403 {object} map[string]string
+// @Router  /api/v1/secrets [get]
+func (h *SecretHandler) List(c *gin.Context) {
+	filter := primitive.M{}
+	if !includeDeleted(c) {
+		filter["deleted_at"] = primitive.M{"$exists": false}
+	}
+	if name := c.Query("name"); name != "" {
+		filter["name"] = name
+	}
+	projects, all, err := auth.ProjectScope(c.Request.Context())
+	if err != nil {
+		writeAuthError(c, err)
+		return
+	}
+	if projectName := c.Query("project_name"); projectName != "" {
+		if !authorizeProject(c, projectName) {
+			return
+		}
+		filter["project_name"] = projectName
+	} else if !all {
+		filter["project_name"] = primitive.M{"$in": projects}
+	}
+
+	secrets, err := service.SecretService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	paging, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := len(secrets)
+	secrets = paginateSlice(secrets, paging)
+	setPaginationHeaders(c, total, paging)
+
+	resp := make([]SecretResponse, 0, len(secrets))
+	for i := range secrets {
+		resp = append(resp, newSecretResponse(&secrets[i]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Render
+// @Summary 渲染 Secret
+// @Description 输出以 sealed-secrets 公钥加密的 SealedSecret，不返回明文
+// @Tags    Secret
+// @Produce application/yaml
+// @Param   id        path  string true  "Secret ID"
+// @Param   namespace query string true  "目标 namespace"
+// @Param   format    query string false "sealed"
+// @Success 200 {string} string
+// @Failure  401 {object} map[string]string
+// @Failure  403 {object} map[string]string
+// @Router  /api/v1/secrets/{id}/render [get]
+func (h *SecretHandler) Render(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	namespace := c.Query("namespace")
+	if namespace == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "namespace is required"})
+		return
+	}
+
+	if !h.authorizeSecret(c, id) {
+		return
+	}
+
+	obj, err := service.SecretService.Render(c.Request.Context(), id, namespace, c.Query("format"))
+	if err != nil {
+		writeSecretError(c, err)
+		return
+	}
+
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/yaml", out)
+}
+
+// authorizeSecret 校验调用方能否访问 Secret 所属项目，失败时已写回响应
+func (h *SecretHandler) authorizeSecret(c *gin.Context, id primitive.ObjectID) bool {
+	sec, err := service.SecretService.Get(c.Request.Context(), id)
+	if err != nil {
+		writeSecretError(c, err)
+		return false
+	}
+	return authorizeProject(c, sec.ProjectName)
+}
+
+func writeSecretError(c *gin.Context, err error) {
+	if writeAuthError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidSecretValue), errors.Is(err, service.ErrInvalidSecretFormat):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, secret.ErrProviderNotInitialized), errors.Is(err, secret.ErrSealingCertNotLoaded):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/bsonger/devflow/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+var SystemRouteApi = NewSystemHandler()
+
+type SystemHandler struct {
+}
+
+func NewSystemHandler() *SystemHandler {
+	return &SystemHandler{}
+}
+
+// Healthz
+// @Summary 存活检查
+// @Description 进程能处理请求即返回 200，不检查外部依赖
+// @Tags    System
+// @Success 200 {object} map[string]string
+// @Router  /healthz [get]
+func (h *SystemHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz
+// @Summary 就绪检查
+// @Description 检查 Mongo、Kubernetes API 与 Argo CD informer 同步状态，任一失败返回 503
+// @Tags    System
+// @Success 200 {object} service.SystemStatus
+// @Failure 503 {object} service.SystemStatus
+// @Router  /readyz [get]
+func (h *SystemHandler) Readyz(c *gin.Context) {
+	status := service.HealthService.Ready(c.Request.Context())
+	if !status.Ready {
+		c.JSON(http.StatusServiceUnavailable, status)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// Status
+// @Summary 依赖状态
+// @Description 各依赖（mongo、kubernetes、argo_informer、tekton、argo）的延迟、当前错误与最近一次错误
+// @Tags    System
+// @Success 200 {object} service.SystemStatus
+// @Router  /api/v1/system/status [get]
+func (h *SystemHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, service.HealthService.Status(c.Request.Context()))
+}
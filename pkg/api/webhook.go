@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bsonger/devflow/pkg/gitprovider"
+	"github.com/bsonger/devflow/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+var WebhookRouteApi = NewWebhookHandler()
+
+type WebhookHandler struct {
+}
+
+func NewWebhookHandler() *WebhookHandler {
+	return &WebhookHandler{}
+}
+
+type GitWebhookResponse struct {
+	Message     string   `json:"message"`
+	ManifestIDs []string `json:"manifest_ids"`
+}
+
+// Git
+// @Summary     Git push webhook
+// @Description 接收 GitHub / GitLab / Gitea push 事件，为匹配仓库与触发规则的 Application 创建 Manifest
+// @Tags        Webhook
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} GitWebhookResponse
+// @Failure     401 {object} map[string]string
+// @Router      /api/v1/webhooks/git [post]
+func (h *WebhookHandler) Git(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider, err := gitprovider.DetectProvider(c.Request.Header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := gitprovider.VerifySignature(provider, c.Request.Header, body, gitprovider.C.WebhookSecret); err != nil {
+		if errors.Is(err, gitprovider.ErrSecretNotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := gitprovider.ParsePush(provider, c.Request.Header, body)
+	if err != nil {
+		// ping 等非 push 事件直接忽略
+		if errors.Is(err, gitprovider.ErrUnsupportedEvent) {
+			c.JSON(http.StatusOK, GitWebhookResponse{Message: "ignored", ManifestIDs: []string{}})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids, err := service.GitWebhookService.HandlePush(c.Request.Context(), event)
+	resp := GitWebhookResponse{Message: "accepted", ManifestIDs: make([]string, 0, len(ids))}
+	for _, id := range ids {
+		resp.ManifestIDs = append(resp.ManifestIDs, id.Hex())
+	}
+	if err != nil {
+		resp.Message = err.Error()
+		c.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bsonger/devflow/pkg/auth"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/secret"
+	"github.com/bsonger/devflow/pkg/service"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var WebhookSubscriptionRouteApi = NewWebhookSubscriptionHandler()
+
+type WebhookSubscriptionHandler struct {
+}
+
+func NewWebhookSubscriptionHandler() *WebhookSubscriptionHandler {
+	return &WebhookSubscriptionHandler{}
+}
+
+// WebhookSubscriptionRequest 创建 / 更新订阅的请求体，secret 传入 "******" 或留空表示保留原值
+type WebhookSubscriptionRequest struct {
+	Name        string        `json:"name" binding:"required"`
+	URL         string        `json:"url" binding:"required"`
+	Secret      string        `json:"secret"`
+	EventTypes  []events.Type `json:"event_types"`
+	ProjectName string        `json:"project_name" binding:"required"`
+	// Enabled 默认 true
+	Enabled *bool `json:"enabled"`
+}
+
+func (r *WebhookSubscriptionRequest) subscription() *domain.WebhookSubscription {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &domain.WebhookSubscription{
+		Name:        r.Name,
+		URL:         r.URL,
+		EventTypes:  r.EventTypes,
+		ProjectName: r.ProjectName,
+		Enabled:     enabled,
+	}
+}
+
+// WebhookSubscriptionResponse 订阅响应，已配置签名密钥时 secret 显示为 "******"
+type WebhookSubscriptionResponse struct {
+	domain.WebhookSubscription
+	Secret string `json:"secret,omitempty"`
+}
+
+func newWebhookSubscriptionResponse(sub *domain.WebhookSubscription) WebhookSubscriptionResponse {
+	resp := WebhookSubscriptionResponse{WebhookSubscription: *sub}
+	if sub.Secret != nil {
+		resp.Secret = domain.MaskedValue
+	}
+	return resp
+}
+
+// Create
+// @Summary 创建 Webhook 订阅
+// @Description 匹配的领域事件以 JSON POST 到 url，配置 secret 时携带 X-Devflow-Signature-256（HMAC-SHA256）
+// @Tags WebhookSubscription
+// @Accept json
+// @Produce json
+// @Param data body WebhookSubscriptionRequest true "Subscription Data"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/v1/webhook_subscriptions [post]
+func (h *WebhookSubscriptionHandler) Create(c *gin.Context) {
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeProject(c, req.ProjectName) {
+		return
+	}
+
+	id, err := service.WebhookSubscriptionService.Create(c.Request.Context(), req.subscription(), req.Secret)
+	if err != nil {
+		writeWebhookSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id.Hex()})
+}
+
+// Get
+// @Summary 获取 Webhook 订阅
+// @Tags    WebhookSubscription
+// @Param   id path string true "Subscription ID"
+// @Success 200 {object} WebhookSubscriptionResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/webhook_subscriptions/{id} [get]
+func (h *WebhookSubscriptionHandler) Get(c *gin.Context) {
+	sub, ok := authorizedSubscription(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newWebhookSubscriptionResponse(sub))
+}
+
+// Update
+// @Summary 更新 Webhook 订阅
+// @Tags    WebhookSubscription
+// @Param   id   path string                     true "Subscription ID"
+// @Param   data body WebhookSubscriptionRequest true "Subscription Data"
+// @Success 200  {object} map[string]string
+// @Failure 401  {object} map[string]string
+// @Failure 403  {object} map[string]string
+// @Router  /api/v1/webhook_subscriptions/{id} [put]
+func (h *WebhookSubscriptionHandler) Update(c *gin.Context) {
+	current, ok := authorizedSubscription(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// 移到其他项目时同样需要目标项目的权限
+	if req.ProjectName != current.ProjectName && !authorizeProject(c, req.ProjectName) {
+		return
+	}
+
+	sub := req.subscription()
+	sub.SetID(current.GetID())
+
+	if err := service.WebhookSubscriptionService.Update(c.Request.Context(), sub, req.Secret); err != nil {
+		writeWebhookSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// Delete
+// @Summary 删除 Webhook 订阅
+// @Tags    WebhookSubscription
+// @Param   id path string true "Subscription ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/webhook_subscriptions/{id} [delete]
+func (h *WebhookSubscriptionHandler) Delete(c *gin.Context) {
+	sub, ok := authorizedSubscription(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := service.WebhookSubscriptionService.Delete(c.Request.Context(), sub.GetID()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// List
+// @Summary 获取 Webhook 订阅列表
+// @Tags    WebhookSubscription
+// @Param   project_name query string false "项目"
+// @Success 200 {array} WebhookSubscriptionResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/webhook_subscriptions [get]
+func (h *WebhookSubscriptionHandler) List(c *gin.Context) {
+	filter := primitive.M{}
+	if !includeDeleted(c) {
+		filter["deleted_at"] = primitive.M{"$exists": false}
+	}
+	projects, all, err := auth.ProjectScope(c.Request.Context())
+	if err != nil {
+		writeAuthError(c, err)
+		return
+	}
+	if projectName := c.Query("project_name"); projectName != "" {
+		if !authorizeProject(c, projectName) {
+			return
+		}
+		filter["project_name"] = projectName
+	} else if !all {
+		filter["project_name"] = primitive.M{"$in": projects}
+	}
+
+	subs, err := service.WebhookSubscriptionService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	paging, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := len(subs)
+	subs = paginateSlice(subs, paging)
+	setPaginationHeaders(c, total, paging)
+
+	resp := make([]WebhookSubscriptionResponse, 0, len(subs))
+	for i := range subs {
+		resp = append(resp, newWebhookSubscriptionResponse(&subs[i]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Deliveries
+// @Summary 获取订阅的投递记录
+// @Tags    WebhookSubscription
+// @Param   id     path  string true  "Subscription ID"
+// @Param   status query string false "Pending | Succeeded | Failed"
+// @Success 200 {array} domain.WebhookDelivery
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/webhook_subscriptions/{id}/deliveries [get]
+func (h *WebhookSubscriptionHandler) Deliveries(c *gin.Context) {
+	sub, ok := authorizedSubscription(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	filter := primitive.M{"subscription_id": sub.GetID()}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+
+	deliveries, err := service.WebhookDeliveryService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	paging, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := len(deliveries)
+	deliveries = paginateSlice(deliveries, paging)
+	setPaginationHeaders(c, total, paging)
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// GetDelivery
+// @Summary 获取投递记录（含最近的尝试）
+// @Tags    WebhookSubscription
+// @Param   id path string true "Delivery ID"
+// @Success 200 {object} domain.WebhookDelivery
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/webhook_deliveries/{id} [get]
+func (h *WebhookSubscriptionHandler) GetDelivery(c *gin.Context) {
+	delivery, ok := authorizedDelivery(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver
+// @Summary 重新投递
+// @Description 以原请求体重新发送，重试次数清零
+// @Tags    WebhookSubscription
+// @Param   id path string true "Delivery ID"
+// @Success 202 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router  /api/v1/webhook_deliveries/{id}/redeliver [post]
+func (h *WebhookSubscriptionHandler) Redeliver(c *gin.Context) {
+	delivery, ok := authorizedDelivery(c)
+	if !ok {
+		return
+	}
+
+	if err := service.WebhookDeliveryService.Redeliver(c.Request.Context(), delivery.GetID()); err != nil {
+		writeWebhookSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "redelivery scheduled"})
+}
+
+// authorizedSubscription 读取订阅并校验调用方对其项目的权限，失败时已写回响应
+func authorizedSubscription(c *gin.Context, hexID string) (*domain.WebhookSubscription, bool) {
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+	sub, err := service.WebhookSubscriptionService.Get(c.Request.Context(), id)
+	if err != nil {
+		writeWebhookSubscriptionError(c, err)
+		return nil, false
+	}
+	if !authorizeProject(c, sub.ProjectName) {
+		return nil, false
+	}
+	return sub, true
+}
+
+// authorizedDelivery 读取投递记录并校验调用方对所属订阅项目的权限，失败时已写回响应
+func authorizedDelivery(c *gin.Context) (*domain.WebhookDelivery, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+	delivery, err := service.WebhookDeliveryService.Get(c.Request.Context(), id)
+	if err != nil {
+		writeWebhookSubscriptionError(c, err)
+		return nil, false
+	}
+	if _, ok := authorizedSubscription(c, delivery.SubscriptionID.Hex()); !ok {
+		return nil, false
+	}
+	return delivery, true
+}
+
+func writeWebhookSubscriptionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidWebhookSubscription):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, secret.ErrProviderNotInitialized):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrInvalidToken    = errors.New("invalid token")
+	ErrForbidden       = errors.New("permission denied")
+	ErrNotConfigured   = errors.New("authorization is not configured")
+)
+
+// allProjects Projects 中的通配符，表示可访问全部项目
+const allProjects = "*"
+
+// Config 静态 Bearer Token 鉴权；未配置任何 token 时按项目鉴权的接口一律拒绝
+type Config struct {
+	Tokens []Token `mapstructure:"tokens" json:"tokens" yaml:"tokens"`
+	// AllowedOrigins 允许建立事件流 WebSocket 的浏览器来源（如 https://devflow.example.com），同源请求始终允许
+	AllowedOrigins []string `mapstructure:"allowed_origins" json:"allowed_origins" yaml:"allowed_origins"`
+}
+
+// Token 调用方凭据及其可访问的项目
+type Token struct {
+	Name  string `mapstructure:"name"  json:"name"  yaml:"name"`
+	Token string `mapstructure:"token" json:"token" yaml:"token"`
+	// Projects 可访问的 project_name，`*` 表示全部
+	Projects []string `mapstructure:"projects" json:"projects" yaml:"projects"`
+}
+
+// Principal 已通过鉴权的调用方
+type Principal struct {
+	Name     string
+	Projects []string
+}
+
+// Allows 是否可访问 project
+func (p *Principal) Allows(project string) bool {
+	for _, v := range p.Projects {
+		if v == allProjects || v == project {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	tokens         []Token
+	allowedOrigins []string
+)
+
+func InitAuth(config *Config) error {
+	if config == nil {
+		return nil
+	}
+	for _, o := range config.AllowedOrigins {
+		allowedOrigins = append(allowedOrigins, strings.TrimSuffix(strings.ToLower(o), "/"))
+	}
+	seen := map[string]bool{}
+	for _, t := range config.Tokens {
+		if t.Name == "" || t.Token == "" {
+			return fmt.Errorf("auth token requires name and token")
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate auth token name %q", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	tokens = config.Tokens
+	return nil
+}
+
+// Enabled 是否配置了 token
+func Enabled() bool {
+	return len(tokens) > 0
+}
+
+// OriginAllowed origin 是否在 AllowedOrigins 中
+func OriginAllowed(origin string) bool {
+	origin = strings.TrimSuffix(strings.ToLower(origin), "/")
+	for _, o := range allowedOrigins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate 校验 Authorization 头（`Bearer <token>`），返回对应的 Principal
+func Authenticate(header string) (*Principal, error) {
+	raw, ok := strings.CutPrefix(strings.TrimSpace(header), "Bearer ")
+	if !ok || raw == "" {
+		return nil, ErrUnauthenticated
+	}
+	raw = strings.TrimSpace(raw)
+
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(raw)) == 1 {
+			return &Principal{Name: t.Name, Projects: t.Projects}, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// AuthorizeProject 校验 ctx 中的调用方能否访问 project；未配置 token 或未携带凭据时拒绝
+func AuthorizeProject(ctx context.Context, project string) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if !p.Allows(project) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ProjectScope 返回调用方可访问的项目，all 为 true 表示全部项目；用于列表接口按项目过滤
+func ProjectScope(ctx context.Context) (projects []string, all bool, err error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, v := range p.Projects {
+		if v == allProjects {
+			return nil, true, nil
+		}
+	}
+	return p.Projects, false, nil
+}
+
+func principal(ctx context.Context) (*Principal, error) {
+	if !Enabled() {
+		return nil, ErrNotConfigured
+	}
+	p := FromContext(ctx)
+	if p == nil {
+		return nil, ErrUnauthenticated
+	}
+	return p, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func useTokens(t *testing.T, ts ...Token) {
+	t.Helper()
+	prev := tokens
+	tokens = ts
+	t.Cleanup(func() { tokens = prev })
+}
+
+func TestTicketRoundTrip(t *testing.T) {
+	useTokens(t, Token{Name: "ci", Token: "s3cr3t", Projects: []string{"shop"}})
+	ctx := WithPrincipal(context.Background(), &Principal{Name: "ci", Projects: []string{"shop"}})
+
+	ticket, _, err := IssueTicket(ctx)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if strings.Contains(ticket, "s3cr3t") {
+		t.Fatal("ticket contains the token")
+	}
+	p, err := AuthenticateTicket(ticket)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if p.Name != "ci" || !p.Allows("shop") || p.Allows("billing") {
+		t.Errorf("principal = %+v", p)
+	}
+}
+
+func TestTicketRejected(t *testing.T) {
+	useTokens(t, Token{Name: "ci", Token: "s3cr3t", Projects: []string{"shop"}})
+	ctx := WithPrincipal(context.Background(), &Principal{Name: "ci"})
+	ticket, _, err := IssueTicket(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig, _ := cut(ticket)
+	encodedName, _, _ := strings.Cut(payload, ".")
+
+	tests := map[string]string{
+		"empty":           "",
+		"bad signature":   payload + "." + strings.Repeat("0", len(sig)),
+		"missing sig":     payload,
+		"expired":         encodedName + ".1." + signTicket("s3cr3t", encodedName+".1"),
+		"extended expiry": encodedName + ".99999999999." + sig,
+		"unknown name":    "eA.99999999999." + signTicket("s3cr3t", "eA.99999999999"),
+	}
+	for name, ticket := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := AuthenticateTicket(ticket); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+
+	t.Run("rotated token", func(t *testing.T) {
+		useTokens(t, Token{Name: "ci", Token: "rotated", Projects: []string{"shop"}})
+		if _, err := AuthenticateTicket(ticket); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("err = %v, want %v", err, ErrInvalidToken)
+		}
+	})
+}
+
+func TestIssueTicketRequiresPrincipal(t *testing.T) {
+	useTokens(t, Token{Name: "ci", Token: "s3cr3t"})
+	if _, _, err := IssueTicket(context.Background()); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("err = %v, want %v", err, ErrUnauthenticated)
+	}
+}
@@ -7,13 +7,21 @@ import (
 	"github.com/bsonger/devflow-common/client/logging"
 	"github.com/bsonger/devflow-common/client/mongo"
 	devflowOtel "github.com/bsonger/devflow-common/client/otel"
-	"github.com/bsonger/devflow-common/client/pyroscope"
 	"github.com/bsonger/devflow-common/client/tekton"
 	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/auth"
+	"github.com/bsonger/devflow/pkg/gitops"
+	"github.com/bsonger/devflow/pkg/gitprovider"
+	"github.com/bsonger/devflow/pkg/notify"
+	"github.com/bsonger/devflow/pkg/render"
+	"github.com/bsonger/devflow/pkg/secret"
+	"github.com/bsonger/devflow/pkg/store"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -32,6 +40,28 @@ type Config struct {
 	Repo      *model.Repo         `mapstructure:"repo"   json:"repo"   yaml:"repo"`
 	Consul    *model.Consul       `mapstructure:"consul" json:"consul" yaml:"consul"`
 	Pyroscope string              `mapstructure:"pyroscope" json:"pyroscope" yaml:"pyroscope"`
+	Retention *RetentionConfig    `mapstructure:"retention" json:"retention" yaml:"retention"`
+	Secret    *secret.Config      `mapstructure:"secret"    json:"secret"    yaml:"secret"`
+	Git       *gitprovider.Config `mapstructure:"git"       json:"git"       yaml:"git"`
+	GitOps    *gitops.Config      `mapstructure:"gitops"    json:"gitops"    yaml:"gitops"`
+	Render    *render.Config      `mapstructure:"render"    json:"render"    yaml:"render"`
+	Drift     *DriftConfig        `mapstructure:"drift"     json:"drift"     yaml:"drift"`
+	Auth      *auth.Config        `mapstructure:"auth"      json:"auth"      yaml:"auth"`
+	Notify    *notify.Config      `mapstructure:"notify"    json:"notify"    yaml:"notify"`
+}
+
+// RetentionConfig 软删除记录的保留策略，Days <= 0 表示不清理
+type RetentionConfig struct {
+	Days     int           `mapstructure:"days"     json:"days"     yaml:"days"`
+	Interval time.Duration `mapstructure:"interval" json:"interval" yaml:"interval"`
+}
+
+// DriftConfig 漂移扫描，Enabled 为 false 时不启动
+type DriftConfig struct {
+	Enabled  bool          `mapstructure:"enabled"     json:"enabled"     yaml:"enabled"`
+	Interval time.Duration `mapstructure:"interval"    json:"interval"    yaml:"interval"`
+	// AutoResync 新发现漂移时以 active Manifest 创建 Upgrade Job 重新同步
+	AutoResync bool `mapstructure:"auto_resync" json:"auto_resync" yaml:"auto_resync"`
 }
 
 func Load() (*Config, error) {
@@ -65,17 +95,35 @@ func Load() (*Config, error) {
 
 func InitConfig(ctx context.Context, config *Config) error {
 	logging.InitZapLogger(config.Log)
-	_, err := devflowOtel.InitOtel(ctx, config.Otel)
-	pyroscope.InitPyroscope("devflow", config.Pyroscope)
+	shutdownTracer, err := devflowOtel.InitOtel(ctx, config.Otel)
+	if err != nil {
+		logging.Logger.Warn("init otel tracing failed, traces are not exported", zap.Error(err))
+	} else {
+		onShutdown(shutdownTracer)
+	}
+	profiler, err := startPyroscope("devflow", config.Pyroscope)
+	if err == nil {
+		onShutdown(func(context.Context) error { return profiler.Stop() })
+	}
 	err = devflowOtel.InitMetricProvider()
 	if err != nil {
 		return err
 	}
+	onShutdown(shutdownMeterProvider)
 
-	_, err = mongo.InitMongo(ctx, config.Mongo, logging.Logger)
+	client, err := mongo.InitMongo(ctx, config.Mongo, logging.Logger)
+	if err != nil {
+		return err
+	}
+	onShutdown(client.Disconnect)
+	store.InitStore(client, config.Mongo.DBName)
+	tx, err := store.DetectTransactions(ctx)
 	if err != nil {
 		return err
 	}
+	if !tx {
+		logging.Logger.Warn("mongo does not support transactions, outbox events are written without a transaction")
+	}
 	kubeconfig, err := LoadKubeConfig()
 	err = tekton.InitTektonClient(ctx, kubeconfig, logging.Logger)
 	if err != nil {
@@ -86,6 +134,21 @@ func InitConfig(ctx context.Context, config *Config) error {
 		return err
 	}
 	model.InitConfigRepo(config.Repo)
+	gitops.InitGitOps(config.GitOps, config.Repo)
+	render.InitRender(config.Render)
+	notify.InitNotify(config.Notify)
+	err = gitprovider.InitGitProvider(config.Git)
+	if err != nil {
+		return err
+	}
+	err = secret.InitKeyProvider(config.Secret)
+	if err != nil {
+		return err
+	}
+	err = auth.InitAuth(config.Auth)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -126,7 +189,7 @@ func loadLocalKubeConfig() (*rest.Config, error) {
 func wrapK8sTransport() func(http.RoundTripper) http.RoundTripper {
 	return func(rt http.RoundTripper) http.RoundTripper {
 		return otelhttp.NewTransport(
-			rt,
+			&errorCountingTransport{next: rt},
 			otelhttp.WithTracerProvider(otel.GetTracerProvider()),
 			otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
 				// 更清晰的 span 名称
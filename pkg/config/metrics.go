@@ -0,0 +1,54 @@
+package config
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// k8sAPIErrors Tekton / Argo CD / Kubernetes API 调用失败次数；404 通常是预期结果，不计入
+var k8sAPIErrors, _ = otel.Meter("devflow").Int64Counter(
+	"devflow.k8s.api.errors",
+	metric.WithDescription("Failed Kubernetes API calls by API group, method and status code"),
+)
+
+// errorCountingTransport 统计传输错误与 4xx / 5xx 响应
+type errorCountingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *errorCountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+
+	code := ""
+	switch {
+	case err != nil:
+		code = "error"
+	case resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusNotFound:
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	if code != "" {
+		k8sAPIErrors.Add(req.Context(), 1, metric.WithAttributes(
+			attribute.String("api", apiGroup(req.URL.Path)),
+			attribute.String("method", req.Method),
+			attribute.String("code", code),
+		))
+	}
+	return resp, err
+}
+
+// apiGroup 按请求路径区分 tekton / argo / kubernetes
+func apiGroup(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/apis/tekton.dev/"):
+		return "tekton"
+	case strings.HasPrefix(path, "/apis/argoproj.io/"):
+		return "argo"
+	default:
+		return "kubernetes"
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/grafana/pyroscope-go"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownFuncs []func(context.Context) error
+)
+
+// onShutdown 注册退出时的清理函数，Shutdown 按注册的逆序执行
+func onShutdown(fn func(context.Context) error) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownFuncs = append(shutdownFuncs, fn)
+}
+
+// Shutdown 刷新 OTel trace / metric 与 Pyroscope 数据并断开 Mongo，应在 HTTP 服务与后台任务停止后调用
+func Shutdown(ctx context.Context) error {
+	shutdownMu.Lock()
+	funcs := shutdownFuncs
+	shutdownFuncs = nil
+	shutdownMu.Unlock()
+
+	var errs []error
+	for i := len(funcs) - 1; i >= 0; i-- {
+		if err := funcs[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// shutdownMeterProvider InitMetricProvider 未返回 provider，从全局取出后关闭
+func shutdownMeterProvider(ctx context.Context) error {
+	if mp, ok := otel.GetMeterProvider().(interface {
+		Shutdown(context.Context) error
+	}); ok {
+		return mp.Shutdown(ctx)
+	}
+	return nil
+}
+
+// startPyroscope 与 devflow-common pyroscope.InitPyroscope 配置一致，保留 Profiler 以便退出时上传剩余数据
+func startPyroscope(name, address string) (*pyroscope.Profiler, error) {
+	return pyroscope.Start(pyroscope.Config{
+		ApplicationName: name,
+		ServerAddress:   address,
+		Logger:          logging.NewZapAdapter(logging.Logger),
+
+		ProfileTypes: []pyroscope.ProfileType{
+			pyroscope.ProfileCPU,
+			pyroscope.ProfileAllocObjects,
+			pyroscope.ProfileAllocSpace,
+			pyroscope.ProfileInuseObjects,
+			pyroscope.ProfileInuseSpace,
+			pyroscope.ProfileGoroutines,
+			pyroscope.ProfileMutexCount,
+			pyroscope.ProfileMutexDuration,
+			pyroscope.ProfileBlockDuration,
+			pyroscope.ProfileBlockCount,
+		},
+	})
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"github.com/bsonger/devflow-common/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Application 在 devflow-common Application 的基础上扩展 devflow 自有字段，共用 applications 集合
+type Application struct {
+	model.Application `bson:",inline"`
+
+	// ConfigurationIDs 按环境引用的 Configuration，key 为环境名
+	ConfigurationIDs map[string][]primitive.ObjectID `bson:"configuration_ids,omitempty" json:"configuration_ids,omitempty"`
+	// SecretIDs 按环境引用的 Secret，部署时渲染为 Kubernetes Secret
+	SecretIDs map[string][]primitive.ObjectID `bson:"secret_ids,omitempty" json:"secret_ids,omitempty"`
+	// Trigger git push 自动构建规则
+	Trigger *GitTrigger `bson:"trigger,omitempty" json:"trigger,omitempty"`
+	// AutoDeploy Manifest 构建成功后自动创建 Job 的规则
+	AutoDeploy []AutoDeployRule `bson:"auto_deploy,omitempty" json:"auto_deploy,omitempty"`
+}
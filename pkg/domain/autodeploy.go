@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/bsonger/devflow-common/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidAutoDeployRule = errors.New("invalid auto deploy rule")
+
+// 自动部署审计结果
+const (
+	AutoDeployPending = "Pending"
+	AutoDeployCreated = "Created"
+	AutoDeploySkipped = "Skipped"
+	AutoDeployFailed  = "Failed"
+)
+
+// AutoDeployRule Manifest 构建成功后按分支 / tag 自动部署到指定环境的规则
+type AutoDeployRule struct {
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// Env 目标环境
+	Env string `bson:"env" json:"env"`
+	// Branch 分支通配（path.Match，如 main、release/*）
+	Branch string `bson:"branch,omitempty" json:"branch,omitempty"`
+	// Tag tag 通配（path.Match，如 v*），用于 tag 构建的 Manifest
+	Tag string `bson:"tag,omitempty" json:"tag,omitempty"`
+}
+
+// Validate 校验规则的环境与通配表达式
+func (r *AutoDeployRule) Validate() error {
+	if r.Env == "" {
+		return fmt.Errorf("%w: env is required", ErrInvalidAutoDeployRule)
+	}
+	if err := ValidateEnv(r.Env); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAutoDeployRule, err)
+	}
+	if r.Branch == "" && r.Tag == "" {
+		return fmt.Errorf("%w: branch or tag is required (env %s)", ErrInvalidAutoDeployRule, r.Env)
+	}
+	for _, p := range []string{r.Branch, r.Tag} {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("%w: bad pattern %q", ErrInvalidAutoDeployRule, p)
+		}
+	}
+	return nil
+}
+
+// Match 判断 Manifest 是否命中规则：tag 构建按 Tag 匹配，否则按 Branch 匹配
+func (r *AutoDeployRule) Match(m *Manifest) bool {
+	if !r.Enabled {
+		return false
+	}
+	if m.Tag != "" {
+		return r.Tag != "" && matchAny([]string{r.Tag}, m.Tag)
+	}
+	return r.Branch != "" && matchAny([]string{r.Branch}, m.Branch)
+}
+
+// Pattern 规则的可读描述，用于审计
+func (r *AutoDeployRule) Pattern() string {
+	if r.Tag != "" {
+		return "tag:" + r.Tag
+	}
+	return "branch:" + r.Branch
+}
+
+// AutoDeployRecord 自动部署评估的审计记录，(manifest_id, env) 唯一：
+// 评估前先写入 Pending 记录占位，重复投递的事件不会再次创建 Job；
+// 占位后进程退出遗留的 Pending 记录超过占位时限后可被重新领取
+type AutoDeployRecord struct {
+	model.BaseModel `bson:",inline"`
+
+	ApplicationID primitive.ObjectID  `bson:"application_id" json:"application_id"`
+	ManifestID    primitive.ObjectID  `bson:"manifest_id" json:"manifest_id"`
+	ManifestName  string              `bson:"manifest_name" json:"manifest_name"`
+	Env           string              `bson:"env" json:"env"`
+	Rule          string              `bson:"rule" json:"rule"`
+	Result        string              `bson:"result" json:"result"`
+	Reason        string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	JobID         *primitive.ObjectID `bson:"job_id,omitempty" json:"job_id,omitempty"`
+}
+
+func (AutoDeployRecord) CollectionName() string { return "auto_deploy_records" }
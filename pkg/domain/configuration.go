@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"github.com/bsonger/devflow-common/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Configuration 在 devflow-common Configuration 的基础上记录当前修订号，共用 configuration 集合
+type Configuration struct {
+	model.Configuration `bson:",inline"`
+
+	// Revision 当前内容对应的修订号，每次更新递增
+	Revision int `bson:"revision,omitempty" json:"revision,omitempty"`
+}
+
+// ConfigurationRevision Configuration 每次变更后的不可变副本
+type ConfigurationRevision struct {
+	model.BaseModel `bson:",inline"`
+
+	ConfigurationID primitive.ObjectID `bson:"configuration_id" json:"configuration_id"`
+	Revision        int                `bson:"revision" json:"revision"`
+	Name            string             `bson:"name" json:"name"`
+	Files           []*model.File      `bson:"files,omitempty" json:"files,omitempty"`
+}
+
+func (ConfigurationRevision) CollectionName() string { return "configuration_revisions" }
+
+// NewConfigurationRevision 以 Configuration 当前内容生成修订记录
+func NewConfigurationRevision(cfg *Configuration) *ConfigurationRevision {
+	rev := &ConfigurationRevision{
+		ConfigurationID: cfg.GetID(),
+		Revision:        cfg.Revision,
+		Name:            cfg.Name,
+		Files:           cfg.Files,
+	}
+	rev.WithCreateDefault()
+	return rev
+}
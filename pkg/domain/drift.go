@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/bsonger/devflow-common/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DriftEvent 集群实际状态偏离 active Manifest 的记录；同一 Application 同时只有一条未恢复的事件，
+// 由 open 上的唯一部分索引保证（Mongo 部分索引不支持 $exists: false，不能直接按 resolved_at 建）
+type DriftEvent struct {
+	model.BaseModel `bson:",inline"`
+
+	ApplicationID   primitive.ObjectID `bson:"application_id" json:"application_id"`
+	ApplicationName string             `bson:"application_name" json:"application_name"`
+	ManifestID      primitive.ObjectID `bson:"manifest_id" json:"manifest_id"`
+	ManifestName    string             `bson:"manifest_name" json:"manifest_name"`
+
+	SyncStatus string `bson:"sync_status" json:"sync_status"`
+	Health     string `bson:"health" json:"health"`
+	// ExpectedImage active Manifest 渲染出的镜像；LiveImages 与之不一致的 Pod 镜像
+	ExpectedImage string   `bson:"expected_image,omitempty" json:"expected_image,omitempty"`
+	LiveImages    []string `bson:"live_images,omitempty" json:"live_images,omitempty"`
+	Reasons       []string `bson:"reasons" json:"reasons"`
+
+	// Open 未恢复时为 true，恢复时移除
+	Open        bool                `bson:"open,omitempty" json:"-"`
+	LastSeenAt  time.Time           `bson:"last_seen_at" json:"last_seen_at"`
+	ResolvedAt  *time.Time          `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	ResyncJobID *primitive.ObjectID `bson:"resync_job_id,omitempty" json:"resync_job_id,omitempty"`
+}
+
+func (DriftEvent) CollectionName() string { return "drift_events" }
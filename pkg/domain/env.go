@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// DefaultEnv 默认部署环境，沿用 Application 名称与项目 namespace，兼容环境隔离之前的部署；
+// Application 的 active manifest 记录的也是该环境
+const DefaultEnv = "prod"
+
+var ErrInvalidEnv = errors.New("invalid env")
+
+// envPattern 环境名会拼入 namespace、Argo CD Application 名称与域名，须是简短的 DNS label
+var envPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,14}[a-z0-9])?$`)
+
+// ValidateEnv 校验环境名
+func ValidateEnv(env string) error {
+	if !envPattern.MatchString(env) {
+		return fmt.Errorf("%w: %q must be a lowercase DNS label of at most 16 characters", ErrInvalidEnv, env)
+	}
+	return nil
+}
+
+// ArgoAppName 环境对应的 Argo CD Application 名称：DefaultEnv 为 Application 名称，其他环境为 <name>-<env>
+func ArgoAppName(name, env string) string {
+	if env == "" || env == DefaultEnv {
+		return name
+	}
+	return name + "-" + env
+}
+
+// EnvNamespace 环境对应的部署 namespace：DefaultEnv 为项目名，其他环境为 <project>-<env>
+func EnvNamespace(project, env string) string {
+	if env == "" || env == DefaultEnv {
+		return project
+	}
+	return project + "-" + env
+}
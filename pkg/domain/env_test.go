@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateEnv(t *testing.T) {
+	tests := []struct {
+		env     string
+		wantErr bool
+	}{
+		{env: "prod"},
+		{env: "dev"},
+		{env: "staging-eu1"},
+		{env: "", wantErr: true},
+		{env: "Dev", wantErr: true},
+		{env: "-dev", wantErr: true},
+		{env: "dev_1", wantErr: true},
+		{env: "a-very-long-env-name", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			err := ValidateEnv(tt.env)
+			if tt.wantErr != errors.Is(err, ErrInvalidEnv) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvNames(t *testing.T) {
+	tests := []struct {
+		env           string
+		wantApp       string
+		wantNamespace string
+	}{
+		{env: "", wantApp: "web", wantNamespace: "shop"},
+		{env: DefaultEnv, wantApp: "web", wantNamespace: "shop"},
+		{env: "dev", wantApp: "web-dev", wantNamespace: "shop-dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			if got := ArgoAppName("web", tt.env); got != tt.wantApp {
+				t.Errorf("ArgoAppName = %q, want %q", got, tt.wantApp)
+			}
+			if got := EnvNamespace("shop", tt.env); got != tt.wantNamespace {
+				t.Errorf("EnvNamespace = %q, want %q", got, tt.wantNamespace)
+			}
+		})
+	}
+}
+
+func TestAutoDeployRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    AutoDeployRule
+		wantErr bool
+	}{
+		{name: "dev branch", rule: AutoDeployRule{Env: "dev", Branch: "main"}},
+		{name: "prod tag", rule: AutoDeployRule{Env: "prod", Tag: "v*"}},
+		{name: "missing env", rule: AutoDeployRule{Branch: "main"}, wantErr: true},
+		{name: "invalid env", rule: AutoDeployRule{Env: "Dev", Branch: "main"}, wantErr: true},
+		{name: "missing pattern", rule: AutoDeployRule{Env: "dev"}, wantErr: true},
+		{name: "bad pattern", rule: AutoDeployRule{Env: "dev", Branch: "release/["}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidAutoDeployRule) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
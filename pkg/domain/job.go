@@ -0,0 +1,11 @@
+package domain
+
+import "github.com/bsonger/devflow-common/model"
+
+// Job 在 devflow-common Job 的基础上记录 GitOps 提交，共用 job 集合
+type Job struct {
+	model.Job `bson:",inline"`
+
+	// GitOpsCommit GitOps 模式下渲染结果在 manifests 仓库中的提交 SHA
+	GitOpsCommit string `bson:"gitops_commit,omitempty" json:"gitops_commit,omitempty"`
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bsonger/devflow-common/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Manifest 在 devflow-common Manifest 的基础上扩展 devflow 自有字段，共用 manifests 集合
+type Manifest struct {
+	model.Manifest `bson:",inline"`
+
+	// Tag 以 git tag 构建时的 tag 名（如 v1.4.2）
+	Tag string `bson:"tag,omitempty" json:"tag,omitempty"`
+	// Version 由 semver tag 解析出的版本号（如 1.4.2），用于版本比较
+	Version string `bson:"version,omitempty" json:"version,omitempty"`
+
+	// Configurations 创建 Manifest 时引用 Configuration 的内容快照，key 为环境名
+	Configurations map[string][]ConfigurationSnapshot `bson:"configurations,omitempty" json:"configurations,omitempty"`
+}
+
+// ConfigurationSnapshot Manifest 创建时 Configuration 的只读副本
+type ConfigurationSnapshot struct {
+	ConfigurationID primitive.ObjectID `bson:"configuration_id" json:"configuration_id"`
+	Name            string             `bson:"name" json:"name"`
+	Revision        int                `bson:"revision" json:"revision"`
+	Files           []*model.File      `bson:"files,omitempty" json:"files,omitempty"`
+}
+
+// GetConfigurations 返回指定环境的 Configuration 快照
+func (m *Manifest) GetConfigurations(env string) []ConfigurationSnapshot {
+	return m.Configurations[env]
+}
+
+var ErrInvalidManifestPatch = errors.New("invalid manifest patch")
+
+// ManifestPatch 部分更新 Manifest：在 model.PatchManifestRequest 基础上支持 CI 回写状态
+type ManifestPatch struct {
+	model.PatchManifestRequest
+
+	// Status 为空表示不修改；已处于终态（Succeeded / Failed）的 Manifest 忽略该字段
+	Status model.ManifestStatus `json:"status,omitempty"`
+}
+
+func (p *ManifestPatch) Validate() error {
+	switch p.Status {
+	case "", model.ManifestPending, model.ManifestRunning, model.ManifestSucceeded, model.ManifestFailed:
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported status %q", ErrInvalidManifestPatch, p.Status)
+	}
+}
+
+func (p *ManifestPatch) IsEmpty() bool {
+	return p.PatchManifestRequest.IsEmpty() && p.Status == ""
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/netguard"
+	"github.com/bsonger/devflow/pkg/notify"
+	"github.com/bsonger/devflow/pkg/secret"
+)
+
+var ErrInvalidNotificationChannel = errors.New("invalid notification channel")
+
+// NotificationChannel 通知渠道：slack / webhook 发送到 URL，email 发送到 Recipients
+type NotificationChannel struct {
+	model.BaseModel `bson:",inline"`
+
+	Name string             `bson:"name" json:"name"`
+	Type notify.ChannelType `bson:"type" json:"type"`
+	// ProjectName 渠道所属项目，只通知该项目下 Application 的事件，必填
+	ProjectName string `bson:"project_name" json:"project_name"`
+	// URL slack incoming webhook / chat webhook 地址，地址本身即凭证，信封加密保存，响应中不返回
+	URL        *secret.EncryptedValue `bson:"url,omitempty" json:"-"`
+	Recipients []string               `bson:"recipients,omitempty" json:"recipients,omitempty"`
+	// Rules 任一规则匹配即发送，为空表示全部事件
+	Rules []NotificationRule `bson:"rules,omitempty" json:"rules,omitempty"`
+	// Template 自定义正文（text/template，字段见 notify.TemplateData），为空使用默认模板
+	Template string `bson:"template,omitempty" json:"template,omitempty"`
+	Enabled  bool   `bson:"enabled" json:"enabled"`
+}
+
+func (NotificationChannel) CollectionName() string { return "notification_channels" }
+
+// NotificationRule 路由规则，空字段表示不限制；ProjectName 只能为空或渠道所属项目；
+// Env 仅对 Job 事件生效，设置 Env 时不匹配 Manifest 事件
+type NotificationRule struct {
+	ProjectName string        `bson:"project_name,omitempty" json:"project_name,omitempty"`
+	Env         string        `bson:"env,omitempty" json:"env,omitempty"`
+	EventTypes  []events.Type `bson:"event_types,omitempty" json:"event_types,omitempty"`
+	// Statuses Manifest / Job 状态，如 Failed、Succeeded
+	Statuses []string `bson:"statuses,omitempty" json:"statuses,omitempty"`
+}
+
+// Validate 校验渠道配置；rawURL 为本次传入的明文地址，keepURL 表示沿用已保存的地址
+func (c *NotificationChannel) Validate(rawURL string, keepURL bool) error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidNotificationChannel)
+	}
+	if c.ProjectName == "" {
+		return fmt.Errorf("%w: project_name is required", ErrInvalidNotificationChannel)
+	}
+
+	switch c.Type {
+	case notify.Slack, notify.Webhook:
+		if keepURL {
+			break
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidNotificationChannel)
+		}
+		if netguard.InternalHost(u.Hostname()) {
+			return fmt.Errorf("%w: url must not point to an internal address", ErrInvalidNotificationChannel)
+		}
+	case notify.Email:
+		if len(c.Recipients) == 0 {
+			return fmt.Errorf("%w: recipients are required", ErrInvalidNotificationChannel)
+		}
+		for _, r := range c.Recipients {
+			if _, err := mail.ParseAddress(r); err != nil {
+				return fmt.Errorf("%w: invalid recipient %q", ErrInvalidNotificationChannel, r)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: type must be slack, webhook or email", ErrInvalidNotificationChannel)
+	}
+
+	for _, r := range c.Rules {
+		if r.ProjectName != "" && r.ProjectName != c.ProjectName {
+			return fmt.Errorf("%w: rule project_name must match the channel project", ErrInvalidNotificationChannel)
+		}
+		for _, t := range r.EventTypes {
+			if !notify.Supported(t) {
+				return fmt.Errorf("%w: unsupported event type %q", ErrInvalidNotificationChannel, t)
+			}
+		}
+	}
+	if c.Template != "" {
+		if err := notify.ParseTemplate(c.Template); err != nil {
+			return fmt.Errorf("%w: template: %v", ErrInvalidNotificationChannel, err)
+		}
+	}
+	return nil
+}
+
+// Match 只匹配渠道所属项目的事件，任一规则匹配即发送
+func (c *NotificationChannel) Match(data *notify.TemplateData) bool {
+	if !c.Enabled || c.ProjectName == "" || c.ProjectName != data.Project {
+		return false
+	}
+	if len(c.Rules) == 0 {
+		return true
+	}
+	for _, r := range c.Rules {
+		if r.match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r NotificationRule) match(data *notify.TemplateData) bool {
+	if r.ProjectName != "" && r.ProjectName != data.Project {
+		return false
+	}
+	if r.Env != "" && r.Env != data.Env {
+		return false
+	}
+	if len(r.EventTypes) > 0 && !slices.Contains(r.EventTypes, data.Event) {
+		return false
+	}
+	if len(r.Statuses) > 0 && !slices.Contains(r.Statuses, data.Status) {
+		return false
+	}
+	return true
+}
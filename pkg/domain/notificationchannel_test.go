@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bsonger/devflow/pkg/notify"
+)
+
+func TestNotificationChannelValidateRecipients(t *testing.T) {
+	tests := []struct {
+		name       string
+		recipients []string
+		wantErr    bool
+	}{
+		{name: "address", recipients: []string{"oncall@example.com"}},
+		{name: "named address", recipients: []string{"On Call <oncall@example.com>"}},
+		{name: "empty", wantErr: true},
+		{name: "not an address", recipients: []string{"oncall"}, wantErr: true},
+		{name: "header injection", recipients: []string{"oncall@example.com\r\nBcc: evil@example.com"}, wantErr: true},
+		{name: "one invalid", recipients: []string{"oncall@example.com", "@example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := &NotificationChannel{Name: "oncall", Type: notify.Email, ProjectName: "shop", Recipients: tt.recipients}
+			err := ch.Validate("", false)
+			if tt.wantErr != errors.Is(err, ErrInvalidNotificationChannel) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNotificationChannelValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ch      NotificationChannel
+		url     string
+		wantErr bool
+	}{
+		{name: "slack", ch: NotificationChannel{Name: "ci", Type: notify.Slack, ProjectName: "shop"}, url: "https://hooks.slack.com/services/x"},
+		{name: "missing project", ch: NotificationChannel{Name: "ci", Type: notify.Slack}, url: "https://hooks.slack.com/services/x", wantErr: true},
+		{name: "loopback url", ch: NotificationChannel{Name: "ci", Type: notify.Webhook, ProjectName: "shop"}, url: "http://127.0.0.1:8080/hook", wantErr: true},
+		{name: "metadata url", ch: NotificationChannel{Name: "ci", Type: notify.Webhook, ProjectName: "shop"}, url: "http://169.254.169.254/latest", wantErr: true},
+		{name: "cluster service url", ch: NotificationChannel{Name: "ci", Type: notify.Webhook, ProjectName: "shop"}, url: "http://chat.default.svc/hook", wantErr: true},
+		{
+			name:    "rule for another project",
+			ch:      NotificationChannel{Name: "ci", Type: notify.Slack, ProjectName: "shop", Rules: []NotificationRule{{ProjectName: "billing"}}},
+			url:     "https://hooks.slack.com/services/x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ch.Validate(tt.url, false)
+			if tt.wantErr != errors.Is(err, ErrInvalidNotificationChannel) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNotificationChannelMatchProject(t *testing.T) {
+	ch := &NotificationChannel{ProjectName: "shop", Enabled: true}
+	if !ch.Match(&notify.TemplateData{Project: "shop"}) {
+		t.Error("channel should match its own project")
+	}
+	if ch.Match(&notify.TemplateData{Project: "billing"}) {
+		t.Error("channel matched another project")
+	}
+	legacy := &NotificationChannel{Enabled: true}
+	if legacy.Match(&notify.TemplateData{Project: "shop"}) {
+		t.Error("channel without project matched")
+	}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "Pending"
+	OutboxDelivered OutboxStatus = "Delivered"
+	// OutboxFailed 超过最大重试次数，不再自动投递
+	OutboxFailed OutboxStatus = "Failed"
+)
+
+// OutboxEvent 与状态变更在同一事务内写入的领域事件，由分发器投递给进程内订阅者
+type OutboxEvent struct {
+	model.BaseModel `bson:",inline"`
+
+	Type          events.Type        `bson:"type" json:"type"`
+	AggregateType events.Resource    `bson:"aggregate_type" json:"aggregate_type"`
+	AggregateID   primitive.ObjectID `bson:"aggregate_id" json:"aggregate_id"`
+	ApplicationID primitive.ObjectID `bson:"application_id,omitempty" json:"application_id,omitempty"`
+	Payload       bson.Raw           `bson:"payload" json:"-"`
+
+	Status OutboxStatus `bson:"status" json:"status"`
+	// Delivered 已成功处理的订阅者，重试时跳过
+	Delivered     []string   `bson:"delivered,omitempty" json:"delivered,omitempty"`
+	Attempts      int        `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time  `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty" json:"-"`
+	LastError     string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+}
+
+func (OutboxEvent) CollectionName() string { return "outbox_events" }
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"sort"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/secret"
+)
+
+// MaskedValue API 响应中代替 Secret 明文的占位符；更新时传入该值表示保留原值
+const MaskedValue = "******"
+
+// Secret 加密保存的敏感配置，部署时渲染为 Kubernetes Secret
+type Secret struct {
+	model.BaseModel `bson:",inline"`
+
+	Name        string `bson:"name" json:"name"`
+	ProjectName string `bson:"project_name" json:"project_name"`
+	// Type Kubernetes Secret 类型，默认 Opaque
+	Type string                            `bson:"type" json:"type"`
+	Data map[string]*secret.EncryptedValue `bson:"data,omitempty" json:"-"`
+}
+
+func (Secret) CollectionName() string { return "secrets" }
+
+// Keys 返回排序后的 key 列表
+func (s *Secret) Keys() []string {
+	keys := make([]string, 0, len(s.Data))
+	for k := range s.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Masked 返回屏蔽明文后的 key → 占位符
+func (s *Secret) Masked() map[string]string {
+	out := make(map[string]string, len(s.Data))
+	for k := range s.Data {
+		out[k] = MaskedValue
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "path"
+
+// GitTrigger git push 自动构建 Manifest 的规则
+type GitTrigger struct {
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// Branches 允许触发构建的分支，支持 path.Match 通配（如 release/*），为空表示全部分支
+	Branches []string `bson:"branches,omitempty" json:"branches,omitempty"`
+	// Tags 允许触发构建的 tag（如 v*），为空表示不按 tag 构建
+	Tags []string `bson:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// MatchBranch 判断分支是否命中触发规则
+func (t *GitTrigger) MatchBranch(branch string) bool {
+	if t == nil || !t.Enabled || branch == "" {
+		return false
+	}
+	if len(t.Branches) == 0 {
+		return true
+	}
+	return matchAny(t.Branches, branch)
+}
+
+// MatchTag 判断 tag 是否命中触发规则
+func (t *GitTrigger) MatchTag(tag string) bool {
+	if t == nil || !t.Enabled || tag == "" {
+		return false
+	}
+	return matchAny(t.Tags, tag)
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ParseVersion 解析 semver tag（允许 v 前缀），返回规范化后的版本号，如 v1.4.2 → 1.4.2
+func ParseVersion(tag string) (string, error) {
+	v, err := semver.NewVersion(tag)
+	if err != nil {
+		return "", fmt.Errorf("invalid semver tag %q: %w", tag, err)
+	}
+	return v.String(), nil
+}
+
+// CompareVersions 按 semver 优先级比较 a、b，返回 -1 / 0 / 1
+func CompareVersions(a, b string) (int, error) {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// VersionedManifestName 以 tag 构建的 Manifest 名称，如 web-portal-v1.4.2
+func VersionedManifestName(appName, tag string) string {
+	return fmt.Sprintf("%s-%s", appName, tag)
+}
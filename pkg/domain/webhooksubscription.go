@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/netguard"
+	"github.com/bsonger/devflow/pkg/secret"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidWebhookSubscription = errors.New("invalid webhook subscription")
+
+// WebhookSubscription 外部系统订阅领域事件，事件以 HMAC 签名的 JSON POST 到 URL
+type WebhookSubscription struct {
+	model.BaseModel `bson:",inline"`
+
+	Name string `bson:"name" json:"name"`
+	URL  string `bson:"url" json:"url"`
+	// Secret HMAC-SHA256 签名密钥，信封加密保存，响应中不返回
+	Secret *secret.EncryptedValue `bson:"secret,omitempty" json:"-"`
+	// EventTypes 订阅的事件类型，为空表示全部
+	EventTypes []events.Type `bson:"event_types,omitempty" json:"event_types,omitempty"`
+	// ProjectName 只投递该项目下 Application 的事件，必填
+	ProjectName string `bson:"project_name" json:"project_name"`
+	Enabled     bool   `bson:"enabled" json:"enabled"`
+}
+
+func (WebhookSubscription) CollectionName() string { return "webhook_subscriptions" }
+
+func (s *WebhookSubscription) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidWebhookSubscription)
+	}
+	if s.ProjectName == "" {
+		return fmt.Errorf("%w: project_name is required", ErrInvalidWebhookSubscription)
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidWebhookSubscription)
+	}
+	if netguard.InternalHost(u.Hostname()) {
+		return fmt.Errorf("%w: url must not point to an internal address", ErrInvalidWebhookSubscription)
+	}
+	for _, t := range s.EventTypes {
+		if _, err := events.NewDomainEvent(t); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookSubscription, err)
+		}
+	}
+	return nil
+}
+
+// Match 是否投递该事件；project 为事件所属 Application 的项目，不属于任何项目的事件不投递
+func (s *WebhookSubscription) Match(t events.Type, project string) bool {
+	if !s.Enabled {
+		return false
+	}
+	if s.ProjectName == "" || s.ProjectName != project {
+		return false
+	}
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, v := range s.EventTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "Pending"
+	DeliverySucceeded DeliveryStatus = "Succeeded"
+	// DeliveryFailed 超过最大重试次数，可通过 redeliver 重新投递
+	DeliveryFailed DeliveryStatus = "Failed"
+)
+
+// DeliveryAttemptHistory 每条投递保留的最近尝试记录条数
+const DeliveryAttemptHistory = 20
+
+// DeliveryAttempt 单次 POST 的结果
+type DeliveryAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// WebhookDelivery 一个事件到一个订阅的投递；同一订阅 / 事件只有一条
+type WebhookDelivery struct {
+	model.BaseModel `bson:",inline"`
+
+	SubscriptionID primitive.ObjectID `bson:"subscription_id" json:"subscription_id"`
+	EventID        primitive.ObjectID `bson:"event_id" json:"event_id"`
+	EventType      events.Type        `bson:"event_type" json:"event_type"`
+	// Payload 签名并发送的请求体，重试与重新投递时保持不变
+	Payload string `bson:"payload" json:"payload"`
+
+	Status        DeliveryStatus    `bson:"status" json:"status"`
+	RetryCount    int               `bson:"retry_count" json:"retry_count"`
+	NextAttemptAt time.Time         `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   *time.Time        `bson:"locked_until,omitempty" json:"-"`
+	Attempts      []DeliveryAttempt `bson:"attempts,omitempty" json:"attempts,omitempty"`
+	DeliveredAt   *time.Time        `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+}
+
+func (WebhookDelivery) CollectionName() string { return "webhook_deliveries" }
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWebhookSubscriptionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     WebhookSubscription
+		wantErr bool
+	}{
+		{name: "valid", sub: WebhookSubscription{Name: "ci", URL: "https://hooks.example.com/devflow", ProjectName: "shop"}},
+		{name: "missing project", sub: WebhookSubscription{Name: "ci", URL: "https://hooks.example.com/devflow"}, wantErr: true},
+		{name: "missing name", sub: WebhookSubscription{URL: "https://hooks.example.com/devflow", ProjectName: "shop"}, wantErr: true},
+		{name: "relative url", sub: WebhookSubscription{Name: "ci", URL: "/devflow", ProjectName: "shop"}, wantErr: true},
+		{name: "metadata address", sub: WebhookSubscription{Name: "ci", URL: "http://169.254.169.254/latest", ProjectName: "shop"}, wantErr: true},
+		{name: "cluster service", sub: WebhookSubscription{Name: "ci", URL: "http://api.default.svc", ProjectName: "shop"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sub.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidWebhookSubscription) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookSubscriptionMatchProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     WebhookSubscription
+		project string
+		want    bool
+	}{
+		{name: "same project", sub: WebhookSubscription{ProjectName: "shop", Enabled: true}, project: "shop", want: true},
+		{name: "other project", sub: WebhookSubscription{ProjectName: "shop", Enabled: true}, project: "billing"},
+		{name: "event without project", sub: WebhookSubscription{ProjectName: "shop", Enabled: true}},
+		{name: "legacy subscription without project", sub: WebhookSubscription{Enabled: true}, project: "shop"},
+		{name: "disabled", sub: WebhookSubscription{ProjectName: "shop"}, project: "shop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.Match("", tt.project); got != tt.want {
+				t.Fatalf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
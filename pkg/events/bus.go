@@ -0,0 +1,179 @@
+package events
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	ManifestStepChanged      Type = "manifest.step"
+	ManifestStatusChanged    Type = "manifest.status"
+	JobStatusChanged         Type = "job.status"
+	ApplicationHealthChanged Type = "application.health"
+)
+
+// Resource 事件所属资源
+type Resource string
+
+const (
+	ResourceManifest    Resource = "manifest"
+	ResourceJob         Resource = "job"
+	ResourceApplication Resource = "application"
+)
+
+// defaultBuffer 订阅者默认缓冲，消费过慢时丢弃新事件而不阻塞发布方
+const defaultBuffer = 64
+
+// Event 推送给订阅者的资源变更
+type Event struct {
+	ID            string   `json:"id"`
+	Type          Type     `json:"type"`
+	Resource      Resource `json:"resource"`
+	ResourceID    string   `json:"resource_id"`
+	ApplicationID string   `json:"application_id,omitempty"`
+	// Project 事件所属项目，订阅者按项目鉴权过滤
+	Project string         `json:"project,omitempty"`
+	Time    time.Time      `json:"time"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// Filter 订阅过滤条件，零值字段不参与过滤
+type Filter struct {
+	Types         []Type
+	Resource      Resource
+	ResourceID    string
+	ApplicationID string
+	// Projects 非 nil 时只匹配其中项目的事件（空切片不匹配任何事件），nil 表示不限制
+	Projects []string
+}
+
+func (f Filter) Match(e Event) bool {
+	if len(f.Types) > 0 {
+		matched := false
+		for _, t := range f.Types {
+			if t == e.Type {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.Resource != "" && f.Resource != e.Resource {
+		return false
+	}
+	if f.ResourceID != "" && f.ResourceID != e.ResourceID {
+		return false
+	}
+	if f.ApplicationID != "" && f.ApplicationID != e.ApplicationID {
+		return false
+	}
+	if f.Projects != nil && !slices.Contains(f.Projects, e.Project) {
+		return false
+	}
+	return true
+}
+
+// Bus 进程内事件总线，Publish 不阻塞
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+	seq  atomic.Uint64
+}
+
+// Default 全局事件总线，各 service 发布状态变更
+var Default = NewBus()
+
+func NewBus() *Bus {
+	return &Bus{subs: map[*Subscription]struct{}{}}
+}
+
+// Subscription 订阅句柄，使用完必须 Close
+type Subscription struct {
+	C       <-chan Event
+	ch      chan Event
+	filter  Filter
+	bus     *Bus
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Dropped 因缓冲已满被丢弃的事件数
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+func (b *Bus) Subscribe(filter Filter) *Subscription {
+	ch := make(chan Event, defaultBuffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter, bus: b}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Publish 投递给所有匹配的订阅者，未设置 ID / Time 时自动补全
+func (b *Bus) Publish(e Event) {
+	if e.ID == "" {
+		e.ID = strconv.FormatUint(b.seq.Add(1), 10)
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// CloseAll 关闭全部订阅，推送连接随之结束；用于服务关闭时释放长连接
+func (b *Bus) CloseAll() {
+	b.mu.RLock()
+	subs := make([]*Subscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
+
+func Publish(e Event) {
+	Default.Publish(e)
+}
+
+func Subscribe(filter Filter) *Subscription {
+	return Default.Subscribe(filter)
+}
+
+func CloseAll() {
+	Default.CloseAll()
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bsonger/devflow-common/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 领域事件类型，与状态变更在同一事务内写入 outbox
+const (
+	ManifestCreated       Type = "manifest.created"
+	JobCreated            Type = "job.created"
+	ActiveManifestChanged Type = "application.active_manifest"
+)
+
+// DomainEvent 持久化到 outbox 的领域事件
+type DomainEvent interface {
+	EventType() Type
+	// Aggregate 事件所属资源及其 ID
+	Aggregate() (Resource, primitive.ObjectID)
+	// Application 事件关联的 Application，用于过滤与项目鉴权
+	Application() primitive.ObjectID
+}
+
+type ManifestCreatedEvent struct {
+	ManifestID    primitive.ObjectID `bson:"manifest_id" json:"manifest_id"`
+	ApplicationID primitive.ObjectID `bson:"application_id" json:"application_id"`
+	Name          string             `bson:"name" json:"name"`
+	Branch        string             `bson:"branch,omitempty" json:"branch,omitempty"`
+	Tag           string             `bson:"tag,omitempty" json:"tag,omitempty"`
+	CommitHash    string             `bson:"commit_hash,omitempty" json:"commit_hash,omitempty"`
+	PipelineID    string             `bson:"pipeline_id" json:"pipeline_id"`
+}
+
+func (ManifestCreatedEvent) EventType() Type { return ManifestCreated }
+func (e ManifestCreatedEvent) Aggregate() (Resource, primitive.ObjectID) {
+	return ResourceManifest, e.ManifestID
+}
+func (e ManifestCreatedEvent) Application() primitive.ObjectID { return e.ApplicationID }
+
+type ManifestStatusChangedEvent struct {
+	ManifestID    primitive.ObjectID   `bson:"manifest_id" json:"manifest_id"`
+	ApplicationID primitive.ObjectID   `bson:"application_id" json:"application_id"`
+	Name          string               `bson:"name" json:"name"`
+	Status        model.ManifestStatus `bson:"status" json:"status"`
+}
+
+func (ManifestStatusChangedEvent) EventType() Type { return ManifestStatusChanged }
+func (e ManifestStatusChangedEvent) Aggregate() (Resource, primitive.ObjectID) {
+	return ResourceManifest, e.ManifestID
+}
+func (e ManifestStatusChangedEvent) Application() primitive.ObjectID { return e.ApplicationID }
+
+type StepStatusChangedEvent struct {
+	ManifestID    primitive.ObjectID `bson:"manifest_id" json:"manifest_id"`
+	ApplicationID primitive.ObjectID `bson:"application_id" json:"application_id"`
+	Name          string             `bson:"name" json:"name"`
+	TaskName      string             `bson:"task_name" json:"task_name"`
+	Status        model.StepStatus   `bson:"status" json:"status"`
+	Message       string             `bson:"message,omitempty" json:"message,omitempty"`
+	StartTime     *time.Time         `bson:"start_time,omitempty" json:"start_time,omitempty"`
+	EndTime       *time.Time         `bson:"end_time,omitempty" json:"end_time,omitempty"`
+}
+
+func (StepStatusChangedEvent) EventType() Type { return ManifestStepChanged }
+func (e StepStatusChangedEvent) Aggregate() (Resource, primitive.ObjectID) {
+	return ResourceManifest, e.ManifestID
+}
+func (e StepStatusChangedEvent) Application() primitive.ObjectID { return e.ApplicationID }
+
+type JobCreatedEvent struct {
+	JobID         primitive.ObjectID `bson:"job_id" json:"job_id"`
+	ApplicationID primitive.ObjectID `bson:"application_id" json:"application_id"`
+	ManifestID    primitive.ObjectID `bson:"manifest_id" json:"manifest_id"`
+	Type          string             `bson:"type" json:"type"`
+	Env           string             `bson:"env" json:"env"`
+}
+
+func (JobCreatedEvent) EventType() Type { return JobCreated }
+func (e JobCreatedEvent) Aggregate() (Resource, primitive.ObjectID) {
+	return ResourceJob, e.JobID
+}
+func (e JobCreatedEvent) Application() primitive.ObjectID { return e.ApplicationID }
+
+type JobStatusChangedEvent struct {
+	JobID         primitive.ObjectID `bson:"job_id" json:"job_id"`
+	ApplicationID primitive.ObjectID `bson:"application_id" json:"application_id"`
+	ManifestID    primitive.ObjectID `bson:"manifest_id" json:"manifest_id"`
+	Type          string             `bson:"type" json:"type"`
+	Env           string             `bson:"env" json:"env"`
+	Status        model.JobStatus    `bson:"status" json:"status"`
+}
+
+func (JobStatusChangedEvent) EventType() Type { return JobStatusChanged }
+func (e JobStatusChangedEvent) Aggregate() (Resource, primitive.ObjectID) {
+	return ResourceJob, e.JobID
+}
+func (e JobStatusChangedEvent) Application() primitive.ObjectID { return e.ApplicationID }
+
+type ActiveManifestChangedEvent struct {
+	ApplicationID primitive.ObjectID `bson:"application_id" json:"application_id"`
+	// ManifestID 为空表示 active manifest 被清空（Uninstall）
+	ManifestID   *primitive.ObjectID `bson:"manifest_id,omitempty" json:"manifest_id,omitempty"`
+	ManifestName string              `bson:"manifest_name,omitempty" json:"manifest_name,omitempty"`
+}
+
+func (ActiveManifestChangedEvent) EventType() Type { return ActiveManifestChanged }
+func (e ActiveManifestChangedEvent) Aggregate() (Resource, primitive.ObjectID) {
+	return ResourceApplication, e.ApplicationID
+}
+func (e ActiveManifestChangedEvent) Application() primitive.ObjectID { return e.ApplicationID }
+
+// domainEvents 事件类型 → 零值构造，用于从 outbox 解码
+var domainEvents = map[Type]func() DomainEvent{
+	ManifestCreated:       func() DomainEvent { return &ManifestCreatedEvent{} },
+	ManifestStatusChanged: func() DomainEvent { return &ManifestStatusChangedEvent{} },
+	ManifestStepChanged:   func() DomainEvent { return &StepStatusChangedEvent{} },
+	JobCreated:            func() DomainEvent { return &JobCreatedEvent{} },
+	JobStatusChanged:      func() DomainEvent { return &JobStatusChangedEvent{} },
+	ActiveManifestChanged: func() DomainEvent { return &ActiveManifestChangedEvent{} },
+}
+
+// NewDomainEvent 按类型构造待解码的领域事件
+func NewDomainEvent(t Type) (DomainEvent, error) {
+	f, ok := domainEvents[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown domain event type %q", t)
+	}
+	return f(), nil
+}
+
+// FromDomainEvent 转换为推送给订阅者的 Event，data 为事件字段
+func FromDomainEvent(id string, at time.Time, e DomainEvent) Event {
+	resource, aggregateID := e.Aggregate()
+	out := Event{
+		ID:         id,
+		Type:       e.EventType(),
+		Resource:   resource,
+		ResourceID: aggregateID.Hex(),
+		Time:       at,
+	}
+	if app := e.Application(); !app.IsZero() {
+		out.ApplicationID = app.Hex()
+	}
+	if raw, err := json.Marshal(e); err == nil {
+		_ = json.Unmarshal(raw, &out.Data)
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package gitops
+
+import (
+	"path"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/gitprovider"
+)
+
+// Config GitOps 模式：部署时将渲染后的资源提交到 repo.address，Argo CD 从 git 同步
+type Config struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	// Branch 写入的分支，默认 main
+	Branch string `mapstructure:"branch" json:"branch" yaml:"branch"`
+	// Username / Token HTTPS 推送凭据
+	Username    string `mapstructure:"username"     json:"username"     yaml:"username"`
+	Token       string `mapstructure:"token"        json:"token"        yaml:"token"`
+	AuthorName  string `mapstructure:"author_name"  json:"author_name"  yaml:"author_name"`
+	AuthorEmail string `mapstructure:"author_email" json:"author_email" yaml:"author_email"`
+}
+
+var (
+	C = &Config{}
+	// Repo manifests 仓库（config.repo）
+	Repo = &model.Repo{}
+	// DefaultWriter GitOps 模式下使用的 Writer
+	DefaultWriter Writer
+)
+
+func InitGitOps(config *Config, repo *model.Repo) {
+	if config == nil || !config.Enabled || repo == nil {
+		return
+	}
+	if config.Branch == "" {
+		config.Branch = "main"
+	}
+	if config.Username == "" {
+		config.Username = "git"
+	}
+	if config.AuthorName == "" {
+		config.AuthorName = "devflow"
+	}
+	if config.AuthorEmail == "" {
+		config.AuthorEmail = "devflow@localhost"
+	}
+
+	C = config
+	Repo = repo
+	DefaultWriter = NewGitWriter(repo.Address, config)
+}
+
+// Enabled GitOps 模式是否开启
+func Enabled() bool {
+	return C.Enabled && DefaultWriter != nil
+}
+
+// Dir Application 在 manifests 仓库中的目录：<repo.path>/<project>/<app>/<env>
+func Dir(project, app, env string) string {
+	return path.Join(Repo.Path, project, app, env)
+}
+
+// IsManifestRepo 判断仓库地址是否为 manifests 仓库，用于忽略 devflow 自己产生的 push
+func IsManifestRepo(repoURL string) bool {
+	if !Enabled() || Repo.Address == "" {
+		return false
+	}
+	return gitprovider.NormalizeRepoURL(repoURL) == gitprovider.NormalizeRepoURL(Repo.Address)
+}
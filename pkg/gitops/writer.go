@@ -0,0 +1,119 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// 并发部署被其他提交抢先时重新拉取后重试
+const maxPushAttempts = 3
+
+// Writer 将一组文件整体写入仓库目录并提交，返回提交 SHA；内容无变化时返回当前 HEAD
+type Writer interface {
+	Write(ctx context.Context, dir string, files map[string][]byte, message string) (string, error)
+}
+
+type gitWriter struct {
+	mu     sync.Mutex
+	url    string
+	config *Config
+}
+
+func NewGitWriter(url string, config *Config) Writer {
+	return &gitWriter{url: url, config: config}
+}
+
+func (w *gitWriter) Write(ctx context.Context, dir string, files map[string][]byte, message string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < maxPushAttempts; attempt++ {
+		var sha string
+		sha, err = w.write(ctx, dir, files, message)
+		if !errors.Is(err, git.ErrNonFastForwardUpdate) {
+			return sha, err
+		}
+	}
+	return "", err
+}
+
+func (w *gitWriter) write(ctx context.Context, dir string, files map[string][]byte, message string) (string, error) {
+	fs := memfs.New()
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), fs, &git.CloneOptions{
+		URL:           w.url,
+		Auth:          w.auth(),
+		ReferenceName: plumbing.NewBranchReferenceName(w.config.Branch),
+		SingleBranch:  true,
+		Depth:         1,
+	})
+	if err != nil {
+		return "", fmt.Errorf("clone %s: %w", w.url, err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	// 目录整体替换，已移除的资源不会残留
+	if err := util.RemoveAll(fs, dir); err != nil {
+		return "", err
+	}
+	for name, data := range files {
+		if err := util.WriteFile(fs, path.Join(dir, name), data, 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	if err := wt.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return "", err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return "", err
+	}
+	if status.IsClean() {
+		head, err := repo.Head()
+		if err != nil {
+			return "", err
+		}
+		return head.Hash().String(), nil
+	}
+
+	hash, err := wt.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  w.config.AuthorName,
+			Email: w.config.AuthorEmail,
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := repo.PushContext(ctx, &git.PushOptions{Auth: w.auth()}); err != nil {
+		return "", fmt.Errorf("push %s: %w", w.url, err)
+	}
+	return hash.String(), nil
+}
+
+func (w *gitWriter) auth() transport.AuthMethod {
+	if w.config.Token == "" {
+		return nil
+	}
+	return &githttp.BasicAuth{Username: w.config.Username, Password: w.config.Token}
+}
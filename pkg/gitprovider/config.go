@@ -0,0 +1,90 @@
+package gitprovider
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Provider git 托管平台类型
+type Provider string
+
+const (
+	GitHub Provider = "github"
+	GitLab Provider = "gitlab"
+	Gitea  Provider = "gitea"
+)
+
+const statusRequestTimeout = 10 * time.Second
+
+type Config struct {
+	// WebhookSecret GitHub / Gitea 的 HMAC 密钥，GitLab 的 X-Gitlab-Token
+	WebhookSecret string `mapstructure:"webhook_secret" json:"webhook_secret" yaml:"webhook_secret"`
+	// Status commit status 回写配置，为空时不回写
+	Status *StatusConfig `mapstructure:"status" json:"status" yaml:"status"`
+}
+
+type StatusConfig struct {
+	Provider Provider `mapstructure:"provider" json:"provider" yaml:"provider"`
+	// APIURL 平台 API 地址，如 https://api.github.com、https://gitlab.com/api/v4、https://gitea.example.com/api/v1
+	APIURL string `mapstructure:"api_url" json:"api_url" yaml:"api_url"`
+	Token  string `mapstructure:"token" json:"token" yaml:"token"`
+	// ExternalURL devflow 对外访问地址，用于生成 status 的跳转链接
+	ExternalURL string `mapstructure:"external_url" json:"external_url" yaml:"external_url"`
+	// Context status 名称前缀，默认 devflow
+	Context string `mapstructure:"context" json:"context" yaml:"context"`
+}
+
+var C = &Config{}
+
+func InitGitProvider(config *Config) error {
+	if config == nil {
+		return nil
+	}
+	C = config
+
+	if config.Status == nil || config.Status.Token == "" {
+		return nil
+	}
+	if config.Status.Context == "" {
+		config.Status.Context = "devflow"
+	}
+
+	n, err := NewStatusNotifier(config.Status, &http.Client{Timeout: statusRequestTimeout})
+	if err != nil {
+		return fmt.Errorf("init commit status notifier: %w", err)
+	}
+	Notifier = n
+	return nil
+}
+
+// StatusContext 拼接 status 名称，如 devflow/build
+func StatusContext(name string) string {
+	prefix := "devflow"
+	if C.Status != nil && C.Status.Context != "" {
+		prefix = C.Status.Context
+	}
+	return prefix + "/" + name
+}
+
+// TargetURL 拼接指向 devflow 的链接，未配置 external_url 时为空
+func TargetURL(path string) string {
+	if C.Status == nil || C.Status.ExternalURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(C.Status.ExternalURL, "/") + path
+}
+
+// CompareURL 两个 commit 之间的网页对比地址，按 status.provider 区分 GitLab 路径
+func CompareURL(repoURL, from, to string) string {
+	normalized := NormalizeRepoURL(repoURL)
+	if normalized == "" || from == "" || to == "" {
+		return ""
+	}
+	compare := "/compare/"
+	if C.Status != nil && C.Status.Provider == GitLab {
+		compare = "/-/compare/"
+	}
+	return "https://" + normalized + compare + from + "..." + to
+}
@@ -0,0 +1,169 @@
+package gitprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// State commit status 状态，取 GitHub / Gitea 的取值，GitLab 在发送时转换
+type State string
+
+const (
+	StatePending State = "pending"
+	StateSuccess State = "success"
+	StateFailure State = "failure"
+	StateError   State = "error"
+)
+
+// GitHub 限制 description 最长 140 字符
+const maxDescriptionLength = 140
+
+var ErrStatusRejected = errors.New("commit status rejected by git provider")
+
+// CommitStatus 一次 commit status 回写
+type CommitStatus struct {
+	RepoURL     string
+	Commit      string
+	State       State
+	Context     string
+	Description string
+	TargetURL   string
+}
+
+// StatusNotifier 将 commit status 回写到 git 平台
+type StatusNotifier interface {
+	SetCommitStatus(ctx context.Context, status *CommitStatus) error
+}
+
+// Notifier 当前使用的 StatusNotifier，未配置时不回写
+var Notifier StatusNotifier = noopNotifier{}
+
+type noopNotifier struct{}
+
+func (noopNotifier) SetCommitStatus(context.Context, *CommitStatus) error { return nil }
+
+// NewStatusNotifier 按平台创建基于 HTTP API 的 StatusNotifier，APIURL 可指向本地替身服务
+func NewStatusNotifier(cfg *StatusConfig, client *http.Client) (StatusNotifier, error) {
+	switch cfg.Provider {
+	case GitHub, GitLab, Gitea:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+	if cfg.APIURL == "" {
+		return nil, errors.New("git status api_url is required")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpNotifier{
+		provider: cfg.Provider,
+		apiURL:   strings.TrimSuffix(cfg.APIURL, "/"),
+		token:    cfg.Token,
+		client:   client,
+	}, nil
+}
+
+type httpNotifier struct {
+	provider Provider
+	apiURL   string
+	token    string
+	client   *http.Client
+}
+
+func (n *httpNotifier) SetCommitStatus(ctx context.Context, status *CommitStatus) error {
+	repo := repoPath(status.RepoURL)
+	if repo == "" || status.Commit == "" {
+		return fmt.Errorf("%w: repo and commit are required", ErrStatusRejected)
+	}
+
+	description := status.Description
+	if len(description) > maxDescriptionLength {
+		description = description[:maxDescriptionLength]
+	}
+
+	var (
+		endpoint string
+		payload  map[string]string
+	)
+	switch n.provider {
+	case GitLab:
+		endpoint = fmt.Sprintf("%s/projects/%s/statuses/%s", n.apiURL, url.PathEscape(repo), status.Commit)
+		payload = map[string]string{
+			"state":       gitlabState(status.State),
+			"name":        status.Context,
+			"description": description,
+			"target_url":  status.TargetURL,
+		}
+	default:
+		endpoint = fmt.Sprintf("%s/repos/%s/statuses/%s", n.apiURL, repo, status.Commit)
+		payload = map[string]string{
+			"state":       string(status.State),
+			"context":     status.Context,
+			"description": description,
+			"target_url":  status.TargetURL,
+		}
+	}
+	if payload["target_url"] == "" {
+		delete(payload, "target_url")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch n.provider {
+	case GitHub:
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	case GitLab:
+		req.Header.Set("PRIVATE-TOKEN", n.token)
+	case Gitea:
+		req.Header.Set("Authorization", "token "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%w: %s %d: %s", ErrStatusRejected, n.provider, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// gitlabState GitLab 使用 pending / running / success / failed / canceled
+func gitlabState(s State) string {
+	switch s {
+	case StateSuccess:
+		return "success"
+	case StateFailure, StateError:
+		return "failed"
+	default:
+		return "pending"
+	}
+}
+
+// repoPath 从仓库地址中取 owner/repo（GitLab 可能包含子组）
+func repoPath(repoURL string) string {
+	normalized := NormalizeRepoURL(repoURL)
+	slash := strings.Index(normalized, "/")
+	if slash < 0 {
+		return ""
+	}
+	return normalized[slash+1:]
+}
@@ -0,0 +1,160 @@
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetCommitStatus(t *testing.T) {
+	status := &CommitStatus{
+		RepoURL:     "https://git.example.com/team/sub/app.git",
+		Commit:      "0123456789abcdef",
+		State:       StateFailure,
+		Context:     "devflow/build",
+		Description: strings.Repeat("x", 150),
+		TargetURL:   "https://devflow.example.com/api/v1/manifests/1",
+	}
+
+	tests := []struct {
+		name       string
+		provider   Provider
+		wantPath   string
+		authHeader string
+		authValue  string
+		want       map[string]string
+	}{
+		{
+			name:       "github",
+			provider:   GitHub,
+			wantPath:   "/repos/team/sub/app/statuses/0123456789abcdef",
+			authHeader: "Authorization",
+			authValue:  "Bearer s3cret",
+			want: map[string]string{
+				"state":       "failure",
+				"context":     "devflow/build",
+				"description": strings.Repeat("x", maxDescriptionLength),
+				"target_url":  status.TargetURL,
+			},
+		},
+		{
+			name:       "gitlab",
+			provider:   GitLab,
+			wantPath:   "/projects/team%2Fsub%2Fapp/statuses/0123456789abcdef",
+			authHeader: "PRIVATE-TOKEN",
+			authValue:  "s3cret",
+			want: map[string]string{
+				"state":       "failed",
+				"name":        "devflow/build",
+				"description": strings.Repeat("x", maxDescriptionLength),
+				"target_url":  status.TargetURL,
+			},
+		},
+		{
+			name:       "gitea",
+			provider:   Gitea,
+			wantPath:   "/repos/team/sub/app/statuses/0123456789abcdef",
+			authHeader: "Authorization",
+			authValue:  "token s3cret",
+			want: map[string]string{
+				"state":       "failure",
+				"context":     "devflow/build",
+				"description": strings.Repeat("x", maxDescriptionLength),
+				"target_url":  status.TargetURL,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotPath string
+				gotAuth string
+				got     map[string]string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotAuth = r.URL.EscapedPath(), r.Header.Get(tt.authHeader)
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer srv.Close()
+
+			n, err := NewStatusNotifier(&StatusConfig{Provider: tt.provider, APIURL: srv.URL + "/", Token: "s3cret"}, srv.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := n.SetCommitStatus(context.Background(), status); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotPath != tt.wantPath {
+				t.Errorf("path = %q, want %q", gotPath, tt.wantPath)
+			}
+			if gotAuth != tt.authValue {
+				t.Errorf("%s = %q, want %q", tt.authHeader, gotAuth, tt.authValue)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("payload = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestSetCommitStatusRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	n, err := NewStatusNotifier(&StatusConfig{Provider: GitHub, APIURL: srv.URL}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		status *CommitStatus
+	}{
+		{name: "provider error", status: &CommitStatus{RepoURL: "https://github.com/team/app", Commit: "abc", State: StateSuccess}},
+		{name: "no commit", status: &CommitStatus{RepoURL: "https://github.com/team/app", State: StateSuccess}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := n.SetCommitStatus(context.Background(), tt.status); !errors.Is(err, ErrStatusRejected) {
+				t.Fatalf("err = %v, want ErrStatusRejected", err)
+			}
+		})
+	}
+}
+
+func TestSetCommitStatusOmitsEmptyTargetURL(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	n, err := NewStatusNotifier(&StatusConfig{Provider: Gitea, APIURL: srv.URL}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetCommitStatus(context.Background(), &CommitStatus{RepoURL: "git@gitea.example.com:team/app.git", Commit: "abc", State: StatePending}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["target_url"]; ok {
+		t.Errorf("target_url should be omitted: %v", got)
+	}
+}
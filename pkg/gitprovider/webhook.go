@@ -0,0 +1,235 @@
+package gitprovider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	branchRefPrefix = "refs/heads/"
+	tagRefPrefix    = "refs/tags/"
+	zeroCommit      = "0000000000000000000000000000000000000000"
+)
+
+var (
+	ErrUnknownProvider     = errors.New("unknown git provider")
+	ErrInvalidSignature    = errors.New("invalid webhook signature")
+	ErrUnsupportedEvent    = errors.New("unsupported webhook event")
+	ErrSecretNotConfigured = errors.New("webhook secret not configured")
+)
+
+// PushEvent 各平台 push 事件的统一表示
+type PushEvent struct {
+	Provider Provider
+	// RepoURLs 仓库的各种地址（http / ssh / web），用于匹配 Application.RepoURL
+	RepoURLs []string
+	Ref      string
+	Branch   string
+	Tag      string
+	Commit   string
+	// Deleted 分支或 tag 被删除
+	Deleted bool
+}
+
+// DetectProvider 根据事件头识别平台；Gitea 同时发送 GitHub 兼容头，需优先判断
+func DetectProvider(h http.Header) (Provider, error) {
+	switch {
+	case h.Get("X-Gitea-Event") != "":
+		return Gitea, nil
+	case h.Get("X-Gitlab-Event") != "":
+		return GitLab, nil
+	case h.Get("X-GitHub-Event") != "":
+		return GitHub, nil
+	default:
+		return "", ErrUnknownProvider
+	}
+}
+
+// VerifySignature 校验请求签名：GitHub 为 X-Hub-Signature-256，Gitea 为 X-Gitea-Signature，GitLab 为 X-Gitlab-Token
+func VerifySignature(p Provider, h http.Header, body []byte, secret string) error {
+	if secret == "" {
+		return ErrSecretNotConfigured
+	}
+
+	switch p {
+	case GitHub:
+		sig := strings.TrimPrefix(h.Get("X-Hub-Signature-256"), "sha256=")
+		return verifyHMAC(body, secret, sig)
+	case Gitea:
+		return verifyHMAC(body, secret, h.Get("X-Gitea-Signature"))
+	case GitLab:
+		if subtle.ConstantTimeCompare([]byte(h.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		return ErrUnknownProvider
+	}
+}
+
+// Sign 计算 HMAC-SHA256 十六进制签名
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyHMAC(body []byte, secret, signature string) error {
+	if signature == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(body, secret)), []byte(strings.ToLower(signature))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParsePush 解析 push 事件（分支与 tag），其他事件返回 ErrUnsupportedEvent
+func ParsePush(p Provider, h http.Header, body []byte) (*PushEvent, error) {
+	var event *PushEvent
+	var err error
+
+	switch p {
+	case GitHub, Gitea:
+		name := h.Get("X-GitHub-Event")
+		if p == Gitea {
+			name = h.Get("X-Gitea-Event")
+		}
+		if name != "push" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedEvent, name)
+		}
+		event, err = parseGitHubPush(body)
+	case GitLab:
+		name := h.Get("X-Gitlab-Event")
+		if name != "Push Hook" && name != "Tag Push Hook" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedEvent, name)
+		}
+		event, err = parseGitLabPush(body)
+	default:
+		return nil, ErrUnknownProvider
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event.Provider = p
+	switch {
+	case strings.HasPrefix(event.Ref, branchRefPrefix):
+		event.Branch = strings.TrimPrefix(event.Ref, branchRefPrefix)
+	case strings.HasPrefix(event.Ref, tagRefPrefix):
+		event.Tag = strings.TrimPrefix(event.Ref, tagRefPrefix)
+	}
+	if event.Commit == "" || event.Commit == zeroCommit {
+		event.Deleted = true
+	}
+	return event, nil
+}
+
+// GitHub 与 Gitea 的 push payload 结构一致；附注 tag 的 after 是 tag 对象的 SHA，构建需使用 head_commit 中的 commit
+func parseGitHubPush(body []byte) (*PushEvent, error) {
+	var payload struct {
+		Ref        string `json:"ref"`
+		After      string `json:"after"`
+		Deleted    bool   `json:"deleted"`
+		HeadCommit *struct {
+			ID string `json:"id"`
+		} `json:"head_commit"`
+		Repository struct {
+			CloneURL string `json:"clone_url"`
+			SSHURL   string `json:"ssh_url"`
+			HTMLURL  string `json:"html_url"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	commit := payload.After
+	if strings.HasPrefix(payload.Ref, tagRefPrefix) && !payload.Deleted && payload.HeadCommit != nil && payload.HeadCommit.ID != "" {
+		commit = payload.HeadCommit.ID
+	}
+
+	return &PushEvent{
+		RepoURLs: nonEmpty(payload.Repository.CloneURL, payload.Repository.SSHURL, payload.Repository.HTMLURL),
+		Ref:      payload.Ref,
+		Commit:   commit,
+		Deleted:  payload.Deleted,
+	}, nil
+}
+
+func parseGitLabPush(body []byte) (*PushEvent, error) {
+	var payload struct {
+		Ref         string `json:"ref"`
+		After       string `json:"after"`
+		CheckoutSHA string `json:"checkout_sha"`
+		Project     struct {
+			GitHTTPURL string `json:"git_http_url"`
+			GitSSHURL  string `json:"git_ssh_url"`
+			WebURL     string `json:"web_url"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	commit := payload.CheckoutSHA
+	if commit == "" {
+		commit = payload.After
+	}
+
+	return &PushEvent{
+		RepoURLs: nonEmpty(payload.Project.GitHTTPURL, payload.Project.GitSSHURL, payload.Project.WebURL),
+		Ref:      payload.Ref,
+		Commit:   commit,
+	}, nil
+}
+
+// NormalizeRepoURL 把 https / ssh / scp 风格地址统一为 host/owner/repo 便于比较
+func NormalizeRepoURL(raw string) string {
+	u := strings.ToLower(strings.TrimSpace(raw))
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://"} {
+		u = strings.TrimPrefix(u, prefix)
+	}
+	if at := strings.Index(u, "@"); at >= 0 {
+		u = u[at+1:]
+	}
+	// scp 风格 git@host:owner/repo
+	if colon := strings.Index(u, ":"); colon >= 0 {
+		rest := u[colon+1:]
+		if slash := strings.Index(rest, "/"); slash >= 0 && isPort(rest[:slash]) {
+			rest = rest[slash+1:]
+		}
+		u = u[:colon] + "/" + rest
+	}
+	u = strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+	return u
+}
+
+func isPort(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func nonEmpty(values ...string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
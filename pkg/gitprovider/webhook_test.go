@@ -0,0 +1,262 @@
+package gitprovider
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func headers(kv ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		h.Set(kv[i], kv[i+1])
+	}
+	return h
+}
+
+func TestDetectProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		want    Provider
+		wantErr error
+	}{
+		{name: "github", header: headers("X-GitHub-Event", "push"), want: GitHub},
+		{name: "gitlab", header: headers("X-Gitlab-Event", "Push Hook"), want: GitLab},
+		// Gitea 同时发送 GitHub 兼容头
+		{name: "gitea", header: headers("X-GitHub-Event", "push", "X-Gitea-Event", "push"), want: Gitea},
+		{name: "unknown", header: http.Header{}, wantErr: ErrUnknownProvider},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectProvider(tt.header)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("provider = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	const secret = "s3cret"
+	valid := Sign(body, secret)
+	wrongKey := Sign(body, "other")
+	tampered := Sign([]byte(`{"ref":"refs/heads/evil"}`), secret)
+
+	tests := []struct {
+		name     string
+		provider Provider
+		header   http.Header
+		secret   string
+		wantErr  error
+	}{
+		{name: "github valid", provider: GitHub, header: headers("X-Hub-Signature-256", "sha256="+valid), secret: secret},
+		{name: "github uppercase hex", provider: GitHub, header: headers("X-Hub-Signature-256", "sha256="+strings.ToUpper(valid)), secret: secret},
+		{name: "github wrong key", provider: GitHub, header: headers("X-Hub-Signature-256", "sha256="+wrongKey), secret: secret, wantErr: ErrInvalidSignature},
+		{name: "github tampered body", provider: GitHub, header: headers("X-Hub-Signature-256", "sha256="+tampered), secret: secret, wantErr: ErrInvalidSignature},
+		{name: "github missing", provider: GitHub, header: http.Header{}, secret: secret, wantErr: ErrInvalidSignature},
+		{name: "github legacy sha1 header only", provider: GitHub, header: headers("X-Hub-Signature", "sha1="+valid), secret: secret, wantErr: ErrInvalidSignature},
+		{name: "gitea valid", provider: Gitea, header: headers("X-Gitea-Signature", valid), secret: secret},
+		{name: "gitea wrong key", provider: Gitea, header: headers("X-Gitea-Signature", wrongKey), secret: secret, wantErr: ErrInvalidSignature},
+		{name: "gitea missing", provider: Gitea, header: http.Header{}, secret: secret, wantErr: ErrInvalidSignature},
+		{name: "gitlab valid", provider: GitLab, header: headers("X-Gitlab-Token", secret), secret: secret},
+		{name: "gitlab wrong token", provider: GitLab, header: headers("X-Gitlab-Token", "other"), secret: secret, wantErr: ErrInvalidSignature},
+		{name: "gitlab missing", provider: GitLab, header: http.Header{}, secret: secret, wantErr: ErrInvalidSignature},
+		{name: "secret not configured", provider: GitHub, header: headers("X-Hub-Signature-256", "sha256="+valid), wantErr: ErrSecretNotConfigured},
+		{name: "unknown provider", provider: "bitbucket", header: http.Header{}, secret: secret, wantErr: ErrUnknownProvider},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.provider, tt.header, body, tt.secret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePush(t *testing.T) {
+	const (
+		commit    = "1111111111111111111111111111111111111111"
+		tagObject = "2222222222222222222222222222222222222222"
+	)
+
+	tests := []struct {
+		name     string
+		provider Provider
+		header   http.Header
+		body     string
+		want     PushEvent
+		wantErr  error
+	}{
+		{
+			name:     "github branch",
+			provider: GitHub,
+			header:   headers("X-GitHub-Event", "push"),
+			body: `{"ref":"refs/heads/feature/login","after":"` + commit + `","head_commit":{"id":"` + commit + `"},
+				"repository":{"clone_url":"https://github.com/team/app.git","ssh_url":"git@github.com:team/app.git","html_url":"https://github.com/team/app"}}`,
+			want: PushEvent{
+				RepoURLs: []string{"https://github.com/team/app.git", "git@github.com:team/app.git", "https://github.com/team/app"},
+				Ref:      "refs/heads/feature/login",
+				Branch:   "feature/login",
+				Commit:   commit,
+			},
+		},
+		{
+			name:     "github annotated tag uses peeled commit",
+			provider: GitHub,
+			header:   headers("X-GitHub-Event", "push"),
+			body:     `{"ref":"refs/tags/v1.2.0","after":"` + tagObject + `","head_commit":{"id":"` + commit + `"},"repository":{"clone_url":"https://github.com/team/app.git"}}`,
+			want: PushEvent{
+				RepoURLs: []string{"https://github.com/team/app.git"},
+				Ref:      "refs/tags/v1.2.0",
+				Tag:      "v1.2.0",
+				Commit:   commit,
+			},
+		},
+		{
+			name:     "github lightweight tag without head commit",
+			provider: GitHub,
+			header:   headers("X-GitHub-Event", "push"),
+			body:     `{"ref":"refs/tags/v1.2.0","after":"` + commit + `","repository":{"clone_url":"https://github.com/team/app.git"}}`,
+			want: PushEvent{
+				RepoURLs: []string{"https://github.com/team/app.git"},
+				Ref:      "refs/tags/v1.2.0",
+				Tag:      "v1.2.0",
+				Commit:   commit,
+			},
+		},
+		{
+			name:     "github branch deleted",
+			provider: GitHub,
+			header:   headers("X-GitHub-Event", "push"),
+			body:     `{"ref":"refs/heads/old","after":"` + zeroCommit + `","deleted":true,"head_commit":null,"repository":{"clone_url":"https://github.com/team/app.git"}}`,
+			want: PushEvent{
+				RepoURLs: []string{"https://github.com/team/app.git"},
+				Ref:      "refs/heads/old",
+				Branch:   "old",
+				Commit:   zeroCommit,
+				Deleted:  true,
+			},
+		},
+		{
+			name:     "github ping",
+			provider: GitHub,
+			header:   headers("X-GitHub-Event", "ping"),
+			body:     `{}`,
+			wantErr:  ErrUnsupportedEvent,
+		},
+		{
+			name:     "gitea tag",
+			provider: Gitea,
+			header:   headers("X-GitHub-Event", "push", "X-Gitea-Event", "push"),
+			body:     `{"ref":"refs/tags/v2","after":"` + tagObject + `","head_commit":{"id":"` + commit + `"},"repository":{"clone_url":"https://gitea.example.com/team/app.git"}}`,
+			want: PushEvent{
+				RepoURLs: []string{"https://gitea.example.com/team/app.git"},
+				Ref:      "refs/tags/v2",
+				Tag:      "v2",
+				Commit:   commit,
+			},
+		},
+		{
+			name:     "gitea pull request",
+			provider: Gitea,
+			header:   headers("X-Gitea-Event", "pull_request"),
+			body:     `{}`,
+			wantErr:  ErrUnsupportedEvent,
+		},
+		{
+			name:     "gitlab branch",
+			provider: GitLab,
+			header:   headers("X-Gitlab-Event", "Push Hook"),
+			body: `{"ref":"refs/heads/main","after":"` + commit + `","checkout_sha":"` + commit + `",
+				"project":{"git_http_url":"https://gitlab.com/group/sub/app.git","git_ssh_url":"git@gitlab.com:group/sub/app.git","web_url":"https://gitlab.com/group/sub/app"}}`,
+			want: PushEvent{
+				RepoURLs: []string{"https://gitlab.com/group/sub/app.git", "git@gitlab.com:group/sub/app.git", "https://gitlab.com/group/sub/app"},
+				Ref:      "refs/heads/main",
+				Branch:   "main",
+				Commit:   commit,
+			},
+		},
+		{
+			name:     "gitlab annotated tag uses checkout sha",
+			provider: GitLab,
+			header:   headers("X-Gitlab-Event", "Tag Push Hook"),
+			body:     `{"ref":"refs/tags/v3","after":"` + tagObject + `","checkout_sha":"` + commit + `","project":{"git_http_url":"https://gitlab.com/group/app.git"}}`,
+			want: PushEvent{
+				RepoURLs: []string{"https://gitlab.com/group/app.git"},
+				Ref:      "refs/tags/v3",
+				Tag:      "v3",
+				Commit:   commit,
+			},
+		},
+		{
+			name:     "gitlab tag deleted",
+			provider: GitLab,
+			header:   headers("X-Gitlab-Event", "Tag Push Hook"),
+			body:     `{"ref":"refs/tags/v3","after":"` + zeroCommit + `","checkout_sha":null,"project":{"git_http_url":"https://gitlab.com/group/app.git"}}`,
+			want: PushEvent{
+				RepoURLs: []string{"https://gitlab.com/group/app.git"},
+				Ref:      "refs/tags/v3",
+				Tag:      "v3",
+				Commit:   zeroCommit,
+				Deleted:  true,
+			},
+		},
+		{
+			name:     "gitlab merge request",
+			provider: GitLab,
+			header:   headers("X-Gitlab-Event", "Merge Request Hook"),
+			body:     `{}`,
+			wantErr:  ErrUnsupportedEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePush(tt.provider, tt.header, []byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Provider != tt.provider {
+				t.Errorf("provider = %q, want %q", got.Provider, tt.provider)
+			}
+			if strings.Join(got.RepoURLs, ",") != strings.Join(tt.want.RepoURLs, ",") {
+				t.Errorf("repo urls = %v, want %v", got.RepoURLs, tt.want.RepoURLs)
+			}
+			if got.Ref != tt.want.Ref || got.Branch != tt.want.Branch || got.Tag != tt.want.Tag {
+				t.Errorf("ref = %q branch = %q tag = %q, want %q %q %q", got.Ref, got.Branch, got.Tag, tt.want.Ref, tt.want.Branch, tt.want.Tag)
+			}
+			if got.Commit != tt.want.Commit || got.Deleted != tt.want.Deleted {
+				t.Errorf("commit = %q deleted = %v, want %q %v", got.Commit, got.Deleted, tt.want.Commit, tt.want.Deleted)
+			}
+		})
+	}
+}
+
+func TestNormalizeRepoURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://github.com/Team/App.git", "github.com/team/app"},
+		{"git@github.com:team/app.git", "github.com/team/app"},
+		{"ssh://git@gitlab.example.com:2222/group/sub/app.git", "gitlab.example.com/group/sub/app"},
+		{"https://gitea.example.com/team/app/", "gitea.example.com/team/app"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRepoURL(tt.raw); got != tt.want {
+			t.Errorf("NormalizeRepoURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
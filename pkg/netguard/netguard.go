@@ -0,0 +1,84 @@
+// Package netguard 出站 HTTP 请求的内部地址防护（SSRF），Webhook 投递与通知渠道共用
+package netguard
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrInternalAddress = errors.New("url resolves to an internal address")
+
+// internalHostSuffixes 集群内 / 本机 / 云厂商内部域名
+var internalHostSuffixes = []string{".localhost", ".local", ".internal", ".svc", ".cluster.local"}
+
+// internalPrefixes PublicAddr 之外需要额外排除的网段：0.0.0.0/8 与 CGNAT
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// InternalHost host 是否为内部地址：非公网 IP、localhost、单标签主机名（集群内短域名）或内部域名后缀；
+// 只做静态检查，DNS 解析后的地址由 NewClient 的拨号器再校验
+func InternalHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return !PublicAddr(addr)
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range internalHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicAddr 是否为公网地址；回环、私有、链路本地（含 169.254.169.254 元数据服务）、CGNAT、未指定与组播地址均不是
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, p := range internalPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient 拨号前校验解析后的实际地址，防止域名解析或重定向到内部地址；命中时请求返回 ErrInternalAddress
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !PublicAddr(addr) {
+				return ErrInternalAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// 不走代理：代理地址本身通常在内网，且会绕过拨号校验
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
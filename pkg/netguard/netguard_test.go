@@ -0,0 +1,81 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":                true,
+		"2606:4700::1111":        true,
+		"127.0.0.1":              false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"0.1.2.3":                false,
+		"::1":                    false,
+		"fd00::1":                false,
+		"fe80::1":                false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:169.254.169.254": false,
+	}
+	for ip, want := range tests {
+		t.Run(ip, func(t *testing.T) {
+			if got := PublicAddr(netip.MustParseAddr(ip)); got != want {
+				t.Errorf("PublicAddr(%s) = %v, want %v", ip, got, want)
+			}
+		})
+	}
+}
+
+func TestInternalHost(t *testing.T) {
+	tests := map[string]bool{
+		"hooks.slack.com":               false,
+		"example.com.":                  false,
+		"localhost":                     true,
+		"api.localhost":                 true,
+		"devflow":                       true,
+		"api.default.svc":               true,
+		"api.default.svc.cluster.local": true,
+		"metadata.google.internal":      true,
+		"printer.local":                 true,
+		"10.0.0.1":                      true,
+		"LOCALHOST.":                    true,
+	}
+	for host, want := range tests {
+		t.Run(host, func(t *testing.T) {
+			if got := InternalHost(host); got != want {
+				t.Errorf("InternalHost(%s) = %v, want %v", host, got, want)
+			}
+		})
+	}
+}
+
+func TestNewClientRefusesInternalAddress(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewClient(time.Second).Do(req)
+	if !errors.Is(err, ErrInternalAddress) {
+		t.Fatalf("err = %v, want %v", err, ErrInternalAddress)
+	}
+	if called {
+		t.Error("request reached the loopback server")
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bsonger/devflow/pkg/netguard"
+)
+
+const sendTimeout = 10 * time.Second
+
+type Config struct {
+	// ExternalURL devflow 对外访问地址，用于生成消息中的 Manifest / Job 链接
+	ExternalURL string `mapstructure:"external_url" json:"external_url" yaml:"external_url"`
+	// SMTP email 渠道使用的发件服务器，为空时 email 渠道不可用
+	SMTP *SMTPConfig `mapstructure:"smtp" json:"smtp" yaml:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host string `mapstructure:"host" json:"host" yaml:"host"`
+	Port int    `mapstructure:"port" json:"port" yaml:"port"`
+	// Username 为空时不认证，适用于本地中继
+	Username string `mapstructure:"username" json:"username" yaml:"username"`
+	Password string `mapstructure:"password" json:"password" yaml:"password"`
+	From     string `mapstructure:"from" json:"from" yaml:"from"`
+}
+
+var (
+	C = &Config{}
+	// Client slack / webhook 渠道使用的 HTTP 客户端，拒绝连接内部地址（SSRF）
+	Client = netguard.NewClient(sendTimeout)
+)
+
+func InitNotify(config *Config) {
+	if config == nil {
+		return
+	}
+	C = config
+	if config.SMTP != nil && config.SMTP.Port == 0 {
+		config.SMTP.Port = 25
+	}
+}
+
+// Link 拼接指向 devflow 的链接，未配置 external_url 时为空
+func Link(path string) string {
+	if C.ExternalURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(C.ExternalURL, "/") + path
+}
@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelType 通知渠道类型
+type ChannelType string
+
+const (
+	Slack   ChannelType = "slack"
+	Webhook ChannelType = "webhook"
+	Email   ChannelType = "email"
+)
+
+var (
+	ErrUnknownChannelType = errors.New("unknown notification channel type")
+	ErrSMTPNotConfigured  = errors.New("smtp not configured")
+	ErrNotificationFailed = errors.New("notification rejected by provider")
+)
+
+// Message 渲染后的通知内容
+type Message struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	// Link 指向触发资源的链接，可能为空
+	Link string `json:"link,omitempty"`
+}
+
+// Target 渠道的投递目标：slack / webhook 使用 URL，email 使用 Recipients
+type Target struct {
+	URL        string
+	Recipients []string
+}
+
+// Provider 发送通知；默认 HTTP 客户端拒绝内部地址，测试可传入自定义 client 指向本地替身服务
+type Provider interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewProvider 按渠道类型创建 Provider，client 为空时使用 Client
+func NewProvider(kind ChannelType, target Target, client *http.Client) (Provider, error) {
+	if client == nil {
+		client = Client
+	}
+
+	switch kind {
+	case Slack:
+		return &slackProvider{url: target.URL, client: client}, nil
+	case Webhook:
+		return &webhookProvider{url: target.URL, client: client}, nil
+	case Email:
+		if C.SMTP == nil || C.SMTP.Host == "" {
+			return nil, ErrSMTPNotConfigured
+		}
+		return &smtpProvider{config: C.SMTP, to: target.Recipients, send: smtp.SendMail}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownChannelType, kind)
+	}
+}
+
+// slackProvider Slack incoming webhook，消息使用 mrkdwn 文本
+type slackProvider struct {
+	url    string
+	client *http.Client
+}
+
+func (p *slackProvider) Send(ctx context.Context, msg *Message) error {
+	text := "*" + msg.Subject + "*\n" + msg.Text
+	if msg.Link != "" {
+		text += "\n<" + msg.Link + "|View details>"
+	}
+	return postJSON(ctx, p.client, p.url, map[string]string{"text": text})
+}
+
+// webhookProvider 通用 chat webhook，直接 POST Message JSON
+type webhookProvider struct {
+	url    string
+	client *http.Client
+}
+
+func (p *webhookProvider) Send(ctx context.Context, msg *Message) error {
+	return postJSON(ctx, p.client, p.url, msg)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%w: %d: %s", ErrNotificationFailed, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// smtpProvider 通过 SMTP 发送纯文本邮件
+type smtpProvider struct {
+	config *SMTPConfig
+	to     []string
+	send   func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (p *smtpProvider) Send(ctx context.Context, msg *Message) error {
+	if len(p.to) == 0 {
+		return fmt.Errorf("%w: no recipients", ErrNotificationFailed)
+	}
+
+	addr := net.JoinHostPort(p.config.Host, strconv.Itoa(p.config.Port))
+	var auth smtp.Auth
+	if p.config.Username != "" {
+		auth = smtp.PlainAuth("", p.config.Username, p.config.Password, p.config.Host)
+	}
+
+	body := msg.Text
+	if msg.Link != "" {
+		body += "\n\n" + msg.Link
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", p.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(p.to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(msg.Subject)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	// net/smtp 不支持 context，放到 goroutine 中以便超时返回
+	done := make(chan error, 1)
+	go func() {
+		done <- p.send(addr, auth, p.config.From, p.to, buf.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// headerValue 去掉换行，避免模板内容注入邮件头
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+func TestHTTPProviders(t *testing.T) {
+	msg := &Message{Subject: "build Failed", Text: "Manifest: demo-1", Link: "https://devflow.example.com/m/1"}
+
+	tests := []struct {
+		name    string
+		kind    ChannelType
+		status  int
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "slack",
+			kind:   Slack,
+			status: http.StatusOK,
+			want:   map[string]string{"text": "*build Failed*\nManifest: demo-1\n<https://devflow.example.com/m/1|View details>"},
+		},
+		{
+			name:   "webhook",
+			kind:   Webhook,
+			status: http.StatusNoContent,
+			want:   map[string]string{"subject": "build Failed", "text": "Manifest: demo-1", "link": "https://devflow.example.com/m/1"},
+		},
+		{
+			name:    "rejected",
+			kind:    Webhook,
+			status:  http.StatusBadRequest,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("content type = %q", ct)
+				}
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			p, err := NewProvider(tt.kind, Target{URL: srv.URL}, srv.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.Send(context.Background(), msg)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotificationFailed) {
+					t.Fatalf("err = %v, want ErrNotificationFailed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestSMTPProvider(t *testing.T) {
+	config := &SMTPConfig{Host: "127.0.0.1", Port: 1025, From: "devflow@example.com"}
+	sendErr := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		to       []string
+		subject  string
+		sendErr  error
+		wantErr  error
+		wantBody []string
+	}{
+		{
+			name:     "sent",
+			to:       []string{"oncall@example.com", "dev@example.com"},
+			subject:  "build Failed",
+			wantBody: []string{"To: oncall@example.com, dev@example.com\r\n", "Subject: build Failed\r\n", "Manifest: demo-1\r\n\r\nhttps://devflow.example.com/m/1"},
+		},
+		{
+			name:     "subject newline stripped",
+			to:       []string{"oncall@example.com"},
+			subject:  "build\r\nBcc: evil@example.com",
+			wantBody: []string{"Subject: build  Bcc: evil@example.com\r\n"},
+		},
+		{
+			name:    "no recipients",
+			subject: "build Failed",
+			wantErr: ErrNotificationFailed,
+		},
+		{
+			name:    "send failed",
+			to:      []string{"oncall@example.com"},
+			subject: "build Failed",
+			sendErr: sendErr,
+			wantErr: sendErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotAddr string
+				gotTo   []string
+				gotMsg  string
+			)
+			p := &smtpProvider{
+				config: config,
+				to:     tt.to,
+				send: func(addr string, _ smtp.Auth, _ string, to []string, msg []byte) error {
+					gotAddr, gotTo, gotMsg = addr, to, string(msg)
+					return tt.sendErr
+				},
+			}
+
+			err := p.Send(context.Background(), &Message{Subject: tt.subject, Text: "Manifest: demo-1", Link: "https://devflow.example.com/m/1"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotAddr != "127.0.0.1:1025" {
+				t.Errorf("addr = %q", gotAddr)
+			}
+			if strings.Join(gotTo, ",") != strings.Join(tt.to, ",") {
+				t.Errorf("to = %v, want %v", gotTo, tt.to)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(gotMsg, want) {
+					t.Errorf("message missing %q:\n%s", want, gotMsg)
+				}
+			}
+		})
+	}
+}
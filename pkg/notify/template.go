@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/bsonger/devflow/pkg/events"
+)
+
+// TemplateData 模板可用字段，Job 相关字段仅 job.* 事件有值
+type TemplateData struct {
+	Event       events.Type
+	Project     string
+	Application string
+	Env         string
+	Status      string
+	JobID       string
+	JobType     string
+
+	ManifestName string
+	Branch       string
+	CommitHash   string
+	ShortCommit  string
+	Digest       string
+
+	ManifestURL string
+	JobURL      string
+}
+
+type messageTemplate struct {
+	subject string
+	text    string
+}
+
+const manifestText = `Manifest: {{.ManifestName}}
+Branch: {{.Branch}}
+Commit: {{.ShortCommit}}{{if .Digest}}
+Digest: {{.Digest}}{{end}}`
+
+const jobText = `Job: {{.JobType}} {{.ManifestName}} → {{.Env}}
+Commit: {{.ShortCommit}}{{if .Digest}}
+Digest: {{.Digest}}{{end}}{{if .JobURL}}
+Job: {{.JobURL}}{{end}}{{if .ManifestURL}}
+Manifest: {{.ManifestURL}}{{end}}`
+
+// defaultTemplates 各事件的默认消息，渠道配置 template 时替换正文
+var defaultTemplates = map[events.Type]messageTemplate{
+	events.ManifestCreated: {
+		subject: "[{{.Project}}] {{.Application}} build started",
+		text:    manifestText,
+	},
+	events.ManifestStatusChanged: {
+		subject: "[{{.Project}}] {{.Application}} build {{.Status}}",
+		text:    manifestText,
+	},
+	events.JobCreated: {
+		subject: "[{{.Project}}] {{.Application}} {{.JobType}} to {{.Env}} started",
+		text:    jobText,
+	},
+	events.JobStatusChanged: {
+		subject: "[{{.Project}}] {{.Application}} {{.JobType}} to {{.Env}} {{.Status}}",
+		text:    jobText,
+	},
+}
+
+// Supported 是否为该事件类型提供通知
+func Supported(t events.Type) bool {
+	_, ok := defaultTemplates[t]
+	return ok
+}
+
+// ParseTemplate 校验渠道自定义模板
+func ParseTemplate(text string) error {
+	_, err := template.New("custom").Option("missingkey=zero").Parse(text)
+	return err
+}
+
+// Render 渲染消息，custom 非空时作为正文模板
+func Render(data *TemplateData, custom string) (*Message, error) {
+	tmpl, ok := defaultTemplates[data.Event]
+	if !ok {
+		return nil, fmt.Errorf("no notification template for %q", data.Event)
+	}
+	if custom != "" {
+		tmpl.text = custom
+	}
+	if data.ShortCommit == "" && data.CommitHash != "" {
+		data.ShortCommit = data.CommitHash
+		if len(data.ShortCommit) > 8 {
+			data.ShortCommit = data.ShortCommit[:8]
+		}
+	}
+
+	subject, err := execute(tmpl.subject, data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := execute(tmpl.text, data)
+	if err != nil {
+		return nil, err
+	}
+
+	link := data.JobURL
+	if link == "" {
+		link = data.ManifestURL
+	}
+	return &Message{Subject: subject, Text: text, Link: link}, nil
+}
+
+func execute(text string, data *TemplateData) (string, error) {
+	t, err := template.New("message").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
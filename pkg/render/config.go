@@ -0,0 +1,28 @@
+package render
+
+// Config 渲染 Kubernetes 资源时使用的集群约定
+type Config struct {
+	// GatewayName / GatewayNamespace 外网应用 HTTPRoute 绑定的 Gateway
+	GatewayName      string `mapstructure:"gateway_name"      json:"gateway_name"      yaml:"gateway_name"`
+	GatewayNamespace string `mapstructure:"gateway_namespace" json:"gateway_namespace" yaml:"gateway_namespace"`
+	// Domain HTTPRoute 的域名后缀，hostname 为 <app>.<domain>，为空时不设置 hostnames
+	Domain string `mapstructure:"domain" json:"domain" yaml:"domain"`
+}
+
+var C = &Config{
+	GatewayName:      "devflow-gateway",
+	GatewayNamespace: "gateway",
+}
+
+func InitRender(config *Config) {
+	if config == nil {
+		return
+	}
+	if config.GatewayName == "" {
+		config.GatewayName = C.GatewayName
+	}
+	if config.GatewayNamespace == "" {
+		config.GatewayNamespace = C.GatewayNamespace
+	}
+	C = config
+}
@@ -0,0 +1,329 @@
+package render
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	managedByLabel  = "app.kubernetes.io/managed-by"
+	managedByValue  = "devflow"
+	appNameLabel    = "app.kubernetes.io/name"
+	manifestIDLabel = "devflow.io/manifest-id"
+	// EnvLabel 部署环境，运行时日志等接口据此校验 env
+	EnvLabel = "devflow.io/env"
+
+	// configHashAnnotation 配置内容变化时触发 Pod 滚动
+	configHashAnnotation = "devflow.io/config-hash"
+	// secretHashAnnotation 引用的 Secret 更新时触发 Pod 滚动
+	secretHashAnnotation = "devflow.io/secret-hash"
+
+	defaultMountRoot = "/etc"
+
+	approximateNotice = "# approximate: gitops is disabled, Argo CD renders the deployed resources with the CMP plugin and they may differ\n"
+)
+
+// SecretRef Application 在该环境引用的 Secret（部署时由 devflow 写入 namespace），Revision 变化时触发 Pod 滚动
+type SecretRef struct {
+	Name     string
+	Revision string
+}
+
+// Resource 渲染出的单个 Kubernetes 资源
+type Resource struct {
+	Kind   string
+	Name   string
+	Object any
+}
+
+// Bundle 一个 Application / Manifest / 环境渲染出的全部资源，顺序即 apply 顺序
+type Bundle struct {
+	Resources []Resource
+	// Approximate 实际部署的资源不由本包渲染（Argo CD CMP 插件），内容可能与集群中应用的不一致
+	Approximate bool
+}
+
+// Add 追加资源
+func (b *Bundle) Add(kind, name string, obj any) {
+	b.Resources = append(b.Resources, Resource{Kind: kind, Name: name, Object: obj})
+}
+
+// Files 每个资源一个文件，文件名为 <kind>-<name>.yaml
+func (b *Bundle) Files() (map[string][]byte, error) {
+	files := make(map[string][]byte, len(b.Resources))
+	for _, r := range b.Resources {
+		data, err := yaml.Marshal(r.Object)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s/%s: %w", r.Kind, r.Name, err)
+		}
+		files[fmt.Sprintf("%s-%s.yaml", strings.ToLower(r.Kind), r.Name)] = data
+	}
+	return files, nil
+}
+
+// YAML 全部资源拼接为多文档 YAML，Approximate 时以注释开头提示
+func (b *Bundle) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	if b.Approximate {
+		buf.WriteString(approximateNotice)
+	}
+	for i, r := range b.Resources {
+		data, err := yaml.Marshal(r.Object)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s/%s: %w", r.Kind, r.Name, err)
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// Render 按 Manifest 的快照渲染指定环境的 ConfigMap / Deployment / Service / HTTPRoute，资源位于环境的 namespace；
+// secrets 以 envFrom 注入主容器，Secret 本身不进入渲染结果
+func Render(app *domain.Application, m *domain.Manifest, env string, secrets ...SecretRef) (*Bundle, error) {
+	namespace := domain.EnvNamespace(app.ProjectName, env)
+	labels := map[string]string{
+		appNameLabel:    app.Name,
+		managedByLabel:  managedByValue,
+		manifestIDLabel: m.GetID().Hex(),
+		EnvLabel:        env,
+	}
+	selector := map[string]string{appNameLabel: app.Name}
+
+	b := &Bundle{}
+
+	volumes, mounts, configHash := renderConfigMaps(b, app, m, env, namespace, labels)
+
+	container := corev1.Container{
+		Name:         app.Name,
+		Image:        Image(m),
+		Env:          envVars(m.Envs[env]),
+		EnvFrom:      envFromSecrets(secrets),
+		VolumeMounts: mounts,
+	}
+	for _, p := range m.Service.Ports {
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			Name:          p.Name,
+			ContainerPort: int32(p.TargetPort),
+		})
+	}
+
+	podAnnotations := map[string]string{}
+	if configHash != "" {
+		podAnnotations[configHashAnnotation] = configHash
+	}
+	if len(secrets) > 0 {
+		hasher := sha256.New()
+		for _, s := range secrets {
+			_, _ = hasher.Write([]byte(s.Name))
+			_, _ = hasher.Write([]byte(s.Revision))
+		}
+		podAnnotations[secretHashAnnotation] = hex.EncodeToString(hasher.Sum(nil))[:16]
+	}
+
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: m.Replica,
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels, Annotations: podAnnotations},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes:    volumes,
+				},
+			},
+		},
+	}
+	b.Add(deployment.Kind, deployment.Name, deployment)
+
+	if len(m.Service.Ports) == 0 {
+		return b, nil
+	}
+
+	service := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: namespace, Labels: labels},
+		Spec:       corev1.ServiceSpec{Selector: selector},
+	}
+	for _, p := range m.Service.Ports {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       p.Name,
+			Port:       int32(p.Port),
+			TargetPort: intstr.FromInt32(int32(p.TargetPort)),
+		})
+	}
+	b.Add(service.Kind, service.Name, service)
+
+	if m.Internet == model.External {
+		b.Add("HTTPRoute", app.Name, httpRoute(app.Name, domain.ArgoAppName(app.Name, env), namespace, labels, m.Service.Ports[0].Port))
+	}
+	return b, nil
+}
+
+// Image 与构建流水线一致的镜像地址，有 digest 时固定到 digest
+func Image(m *domain.Manifest) string {
+	params := map[string]string{}
+	for _, p := range m.GeneratePipelineRunParams() {
+		params[p.Name] = p.Value.StringVal
+	}
+	tag := params["image-tag"]
+	if m.Tag != "" {
+		tag = m.Tag
+	}
+
+	image := fmt.Sprintf("%s/%s:%s", params["image-registry"], params["name"], tag)
+	if m.Digest != "" {
+		image += "@" + m.Digest
+	}
+	return image
+}
+
+// renderConfigMaps Configuration 快照渲染为 ConfigMap 并挂载；Manifest 中未快照的 ConfigMap 按名称引用已有资源
+func renderConfigMaps(b *Bundle, app *domain.Application, m *domain.Manifest, env, namespace string, labels map[string]string) ([]corev1.Volume, []corev1.VolumeMount, string) {
+	mountPaths := make(map[string]*model.ConfigMap, len(m.ConfigMaps))
+	for _, cm := range m.ConfigMaps {
+		if cm != nil {
+			mountPaths[cm.Name] = cm
+		}
+	}
+
+	var (
+		volumes []corev1.Volume
+		mounts  []corev1.VolumeMount
+		hasher  = sha256.New()
+	)
+	snapshots := m.GetConfigurations(env)
+	for _, snap := range snapshots {
+		name := fmt.Sprintf("%s-%s", app.Name, snap.Name)
+		data := make(map[string]string, len(snap.Files))
+		for _, f := range snap.Files {
+			data[f.Name] = f.Content
+		}
+
+		cm := &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Data:       data,
+		}
+		b.Add(cm.Kind, cm.Name, cm)
+		hashData(hasher, name, data)
+
+		mountPath := defaultMountRoot + "/" + snap.Name
+		if ref, ok := mountPaths[snap.Name]; ok && ref.MountPath != "" {
+			mountPath = ref.MountPath
+		}
+		delete(mountPaths, snap.Name)
+
+		volumes = append(volumes, configMapVolume(snap.Name, name, nil))
+		mounts = append(mounts, corev1.VolumeMount{Name: snap.Name, MountPath: mountPath, ReadOnly: true})
+	}
+
+	for _, cm := range m.ConfigMaps {
+		if cm == nil || mountPaths[cm.Name] == nil {
+			continue
+		}
+		volumes = append(volumes, configMapVolume(cm.Name, cm.Name, cm.FilesPath))
+		mounts = append(mounts, corev1.VolumeMount{Name: cm.Name, MountPath: cm.MountPath, ReadOnly: true})
+	}
+
+	if len(snapshots) == 0 {
+		return volumes, mounts, ""
+	}
+	return volumes, mounts, hex.EncodeToString(hasher.Sum(nil))[:16]
+}
+
+func configMapVolume(volume, configMap string, files map[string]string) corev1.Volume {
+	source := &corev1.ConfigMapVolumeSource{
+		LocalObjectReference: corev1.LocalObjectReference{Name: configMap},
+	}
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		source.Items = append(source.Items, corev1.KeyToPath{Key: k, Path: files[k]})
+	}
+	return corev1.Volume{Name: volume, VolumeSource: corev1.VolumeSource{ConfigMap: source}}
+}
+
+func hashData(h hash.Hash, name string, data map[string]string) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	_, _ = h.Write([]byte(name))
+	for _, k := range keys {
+		_, _ = h.Write([]byte(k))
+		_, _ = h.Write([]byte(data[k]))
+	}
+}
+
+func envFromSecrets(secrets []SecretRef) []corev1.EnvFromSource {
+	if len(secrets) == 0 {
+		return nil
+	}
+	out := make([]corev1.EnvFromSource, 0, len(secrets))
+	for _, s := range secrets {
+		out = append(out, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: s.Name}},
+		})
+	}
+	return out
+}
+
+func envVars(vars []model.EnvVar) []corev1.EnvVar {
+	out := make([]corev1.EnvVar, 0, len(vars))
+	for _, v := range vars {
+		out = append(out, corev1.EnvVar{Name: v.Name, Value: v.Value})
+	}
+	return out
+}
+
+// httpRoute Gateway API HTTPRoute，未引入 gateway-api 类型，以 map 表示；host 为域名前缀，非默认环境带环境后缀
+func httpRoute(name, host, namespace string, labels map[string]string, port int) map[string]any {
+	spec := map[string]any{
+		"parentRefs": []any{
+			map[string]any{"name": C.GatewayName, "namespace": C.GatewayNamespace},
+		},
+		"rules": []any{
+			map[string]any{
+				"backendRefs": []any{
+					map[string]any{"name": name, "port": port},
+				},
+			},
+		},
+	}
+	if C.Domain != "" {
+		spec["hostnames"] = []any{host + "." + C.Domain}
+	}
+
+	return map[string]any{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    labels,
+		},
+		"spec": spec,
+	}
+}
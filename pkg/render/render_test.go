@@ -0,0 +1,156 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden with the current output")
+
+func testApplication() *domain.Application {
+	app := &domain.Application{}
+	app.Name = "checkout"
+	app.ProjectName = "shop"
+	return app
+}
+
+func testManifest() *domain.Manifest {
+	m := &domain.Manifest{}
+	m.ID, _ = primitive.ObjectIDFromHex("65f000000000000000000001")
+	m.Name = "checkout-20260101120000"
+	m.ApplicationName = "checkout"
+	m.Branch = "main"
+	replicas := int32(2)
+	m.Replica = &replicas
+	m.Digest = "sha256:0123456789abcdef"
+	m.Envs = map[string][]model.EnvVar{
+		domain.DefaultEnv: {{Name: "LOG_LEVEL", Value: "info"}},
+		"staging":         {{Name: "LOG_LEVEL", Value: "debug"}},
+	}
+	m.Service.Ports = []model.Port{{Name: "http", Port: 80, TargetPort: 8080}}
+	return m
+}
+
+func TestRenderGolden(t *testing.T) {
+	defer func(c Config) { *C = c }(*C)
+	C.Domain = "example.com"
+
+	configID, _ := primitive.ObjectIDFromHex("65f000000000000000000002")
+
+	tests := []struct {
+		name        string
+		manifest    func() *domain.Manifest
+		env         string
+		secrets     []SecretRef
+		approximate bool
+	}{
+		{
+			// Configuration 快照渲染为 ConfigMap 并挂载，未快照的 ConfigMap 按名称引用
+			name: "internal_prod",
+			manifest: func() *domain.Manifest {
+				m := testManifest()
+				m.Internet = model.Internal
+				m.ConfigMaps = []*model.ConfigMap{
+					{Name: "app", MountPath: "/srv/config"},
+					{Name: "shared-ca", MountPath: "/etc/ssl/shared", FilesPath: map[string]string{"ca.crt": "ca.crt"}},
+				}
+				m.Configurations = map[string][]domain.ConfigurationSnapshot{
+					domain.DefaultEnv: {{
+						ConfigurationID: configID,
+						Name:            "app",
+						Revision:        3,
+						Files:           []*model.File{{Name: "app.yaml", Content: "port: 8080\n"}},
+					}},
+				}
+				return m
+			},
+			env: domain.DefaultEnv,
+		},
+		{
+			// 非默认环境：namespace 与 HTTPRoute hostname 带环境后缀，Secret 以 envFrom 注入
+			name: "external_staging",
+			manifest: func() *domain.Manifest {
+				m := testManifest()
+				m.Internet = model.External
+				return m
+			},
+			env:     "staging",
+			secrets: []SecretRef{{Name: "checkout-db", Revision: "1767268800000"}},
+		},
+		{
+			name: "approximate",
+			manifest: func() *domain.Manifest {
+				m := testManifest()
+				m.Service.Ports = nil
+				return m
+			},
+			env:         domain.DefaultEnv,
+			approximate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Render(testApplication(), tt.manifest(), tt.env, tt.secrets...)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			b.Approximate = tt.approximate
+
+			got, err := b.YAML()
+			if err != nil {
+				t.Fatalf("YAML: %v", err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden (run with -update to create): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("render output differs from %s (run with -update to accept):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestBundleFiles(t *testing.T) {
+	m := testManifest()
+	m.Internet = model.External
+
+	b, err := Render(testApplication(), m, domain.DefaultEnv)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	b.Approximate = true
+
+	files, err := b.Files()
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	for _, name := range []string{"deployment-checkout.yaml", "service-checkout.yaml", "httproute-checkout.yaml"} {
+		data, ok := files[name]
+		if !ok {
+			t.Fatalf("missing %s in %v", name, files)
+		}
+		// 近似提示只出现在预览的多文档 YAML 中，不写入 GitOps 仓库的文件
+		if bytes.HasPrefix(data, []byte("#")) {
+			t.Fatalf("%s starts with the approximate notice", name)
+		}
+	}
+	if len(files) != 3 {
+		t.Fatalf("files = %d, want 3", len(files))
+	}
+}
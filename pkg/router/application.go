@@ -13,6 +13,11 @@ func RegisterApplicationRoutes(rg *gin.RouterGroup) {
 	app.POST("", api.ApplicationRouteApi.Create)
 	app.PUT("/:id", api.ApplicationRouteApi.Update)
 	app.DELETE("/:id", api.ApplicationRouteApi.Delete)
+	app.POST("/:id/restore", api.ApplicationRouteApi.Restore)
+	app.GET("/:id/status", api.ApplicationRouteApi.Status)
+	app.GET("/:id/diff", api.ApplicationRouteApi.Diff)
+	app.GET("/:id/logs", api.ApplicationRouteApi.Logs)
+	app.GET("/:id/auto_deploys", api.ApplicationRouteApi.ListAutoDeploys)
 	app.PATCH("/:id/active_manifest", api.ApplicationRouteApi.UpdateActiveManifest)
 
 	RegisterManifestRoutes(app)
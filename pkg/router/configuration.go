@@ -13,4 +13,9 @@ func RegisterConfigurationRoutes(rg *gin.RouterGroup) {
 	cfg.POST("", api.ConfigurationRouteApi.Create)
 	cfg.PUT("/:id", api.ConfigurationRouteApi.Update)
 	cfg.DELETE("/:id", api.ConfigurationRouteApi.Delete)
+	cfg.POST("/:id/restore", api.ConfigurationRouteApi.Restore)
+	cfg.GET("/:id/revisions", api.ConfigurationRouteApi.ListRevisions)
+	cfg.GET("/:id/revisions/:revision", api.ConfigurationRouteApi.GetRevision)
+	cfg.GET("/:id/diff", api.ConfigurationRouteApi.Diff)
+	cfg.POST("/:id/revert", api.ConfigurationRouteApi.Revert)
 }
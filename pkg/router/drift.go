@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/bsonger/devflow/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDriftRoutes(rg *gin.RouterGroup) {
+	drift := rg.Group("/drift_events")
+
+	drift.GET("", api.DriftRouteApi.List)
+}
@@ -0,0 +1,13 @@
+package router
+
+import (
+	"github.com/bsonger/devflow/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterEventRoutes(rg *gin.RouterGroup) {
+	event := rg.Group("/events")
+
+	event.POST("/ticket", api.EventRouteApi.Ticket)
+	event.GET("/stream", StreamTicketMiddleware(), api.EventRouteApi.Stream)
+}
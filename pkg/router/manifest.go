@@ -10,6 +10,8 @@ func RegisterManifestRoutes(rg *gin.RouterGroup) {
 
 	manifest.GET("", api.ManifestRouteApi.List)
 	manifest.GET("/:id", api.ManifestRouteApi.Get)
+	manifest.GET("/:id/render", api.ManifestRouteApi.Render)
+	manifest.GET("/:id/steps/:task/logs", api.ManifestRouteApi.StepLogs)
 	manifest.POST("", api.ManifestRouteApi.Create)
 	manifest.PATCH("/:id", api.ManifestRouteApi.Patch)
 }
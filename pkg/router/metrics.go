@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/bsonger/devflow/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterMetricsRoutes(rg *gin.RouterGroup) {
+	metrics := rg.Group("/metrics")
+
+	metrics.GET("/dora", api.MetricsRouteApi.Dora)
+}
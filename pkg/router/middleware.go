@@ -3,11 +3,17 @@ package router
 import (
 	"context"
 	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow/pkg/auth"
+	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/grafana/pyroscope-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 )
 
@@ -18,6 +24,8 @@ import (
 func shouldIgnore(path string) bool {
 	return path == "/metrics" ||
 		path == "/health" ||
+		path == "/healthz" ||
+		path == "/readyz" ||
 		strings.HasPrefix(path, "/swagger")
 }
 
@@ -53,70 +61,65 @@ func PyroscopeMiddleware() gin.HandlerFunc {
 /********************
  * Metrics Middleware
  ********************/
-//
-//func GinMetricsMiddleware() gin.HandlerFunc {
-//	meter := otel.Meter("devflow")
-//
-//	requestsCounter, _ := meter.Int64Counter(
-//		"http.server.requests",
-//	)
-//
-//	requestLatency, _ := meter.Float64Histogram(
-//		"http.server.duration",
-//		metric.WithUnit("s"),
-//		metric.WithExplicitBucketBoundaries(
-//			0.3, 0.5, 1, 3,
-//		),
-//	)
-//
-//	requestSize, _ := meter.Int64Histogram(
-//		"http.server.request.size",
-//		metric.WithUnit("By"),
-//		metric.WithExplicitBucketBoundaries(
-//			512, 2048, 8192, 32768,
-//		),
-//	)
-//
-//	responseSize, _ := meter.Int64Histogram(
-//		"http.server.response.size",
-//		metric.WithUnit("By"),
-//		metric.WithExplicitBucketBoundaries(
-//			512, 2048, 8192, 32768,
-//		),
-//	)
-//
-//	return func(c *gin.Context) {
-//		// ⭐ 必须最早过滤
-//		if shouldIgnore(c.Request.URL.Path) {
-//			c.Next()
-//			return
-//		}
-//
-//		start := time.Now()
-//		c.Next()
-//
-//		duration := time.Since(start).Seconds()
-//		status := c.Writer.Status()
-//		ctx := c.Request.Context()
-//
-//		attrs := []attribute.KeyValue{
-//			attribute.String("method", c.Request.Method),
-//			attribute.String("path", routeLabel(c)),
-//			attribute.Int("status", status),
-//		}
-//
-//		requestsCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
-//		requestLatency.Record(ctx, duration, metric.WithAttributes(attrs...))
-//
-//		reqSize := c.Request.ContentLength
-//		if reqSize < 0 {
-//			reqSize = 0
-//		}
-//
-//		requestSize.Record(ctx, reqSize, metric.WithAttributes(attrs...))
-//		responseSize.Record(ctx, int64(c.Writer.Size()), metric.WithAttributes(attrs...))
-//	}
-//}
+
+// GinMetricsMiddleware HTTP RED 指标：请求数、错误数与耗时，按路由模板（而非原始路径）打标签
+func GinMetricsMiddleware() gin.HandlerFunc {
+	meter := otel.Meter("devflow")
+
+	requestsCounter, _ := meter.Int64Counter(
+		"http.server.requests",
+		metric.WithDescription("HTTP requests by method, route and status"),
+	)
+
+	errorsCounter, _ := meter.Int64Counter(
+		"http.server.errors",
+		metric.WithDescription("HTTP requests answered with a 5xx status"),
+	)
+
+	requestLatency, _ := meter.Float64Histogram(
+		"http.server.duration",
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(
+			0.05, 0.1, 0.3, 0.5, 1, 3, 10,
+		),
+	)
+
+	activeRequests, _ := meter.Int64UpDownCounter(
+		"http.server.active_requests",
+		metric.WithDescription("In-flight HTTP requests"),
+	)
+
+	return func(c *gin.Context) {
+		// ⭐ 必须最早过滤
+		if shouldIgnore(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		activeRequests.Add(ctx, 1)
+		defer activeRequests.Add(ctx, -1)
+
+		start := time.Now()
+		c.Next()
+
+		duration := time.Since(start).Seconds()
+		status := c.Writer.Status()
+
+		attrs := metric.WithAttributes(
+			attribute.String("method", c.Request.Method),
+			attribute.String("route", routeLabel(c)),
+			attribute.Int("status", status),
+		)
+
+		requestsCounter.Add(ctx, 1, attrs)
+		requestLatency.Record(ctx, duration, attrs)
+		if status >= http.StatusInternalServerError {
+			errorsCounter.Add(ctx, 1, attrs)
+		}
+	}
+}
+
 func GinZapLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -177,13 +180,66 @@ func GinZapLogger() gin.HandlerFunc {
 	}
 }
 
+// ticketParam 事件流票据的查询参数，访问日志中屏蔽
+const ticketParam = "ticket"
+
 func buildTarget(path, rawQuery string) string {
 	if rawQuery == "" {
 		return path
 	}
+	if strings.Contains(rawQuery, ticketParam+"=") {
+		if q, err := url.ParseQuery(rawQuery); err == nil && q.Has(ticketParam) {
+			q.Set(ticketParam, "******")
+			rawQuery = q.Encode()
+		}
+	}
 	return path + "?" + rawQuery
 }
 
+/********************
+ * Auth
+ ********************/
+
+// AuthMiddleware 携带 Authorization 头时校验 token 并将 Principal 注入 request context；
+// 未携带时放行，敏感接口（日志、Secret、事件流）通过 auth.AuthorizeProject 按资源所属项目校验，
+// 没有 Principal 或未配置 token 时返回 401 / 403
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !auth.Enabled() || header == "" {
+			c.Next()
+			return
+		}
+
+		principal, err := auth.Authenticate(header)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// StreamTicketMiddleware 事件流未携带 Authorization 头时，以 ?ticket= 查询参数（auth.IssueTicket 签发）鉴权
+func StreamTicketMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query(ticketParam)
+		if ticket == "" || auth.FromContext(c.Request.Context()) != nil {
+			c.Next()
+			return
+		}
+
+		principal, err := auth.AuthenticateTicket(ticket)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
 /********************
  * Recovery
  ********************/
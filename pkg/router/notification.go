@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/bsonger/devflow/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterNotificationRoutes(rg *gin.RouterGroup) {
+	channel := rg.Group("/notification_channels")
+
+	channel.GET("", api.NotificationRouteApi.List)
+	channel.GET("/:id", api.NotificationRouteApi.Get)
+	channel.POST("", api.NotificationRouteApi.Create)
+	channel.PUT("/:id", api.NotificationRouteApi.Update)
+	channel.DELETE("/:id", api.NotificationRouteApi.Delete)
+	channel.POST("/:id/test", api.NotificationRouteApi.Test)
+}
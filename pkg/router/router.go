@@ -1,6 +1,8 @@
 package router
 
 import (
+	"errors"
+	"github.com/bsonger/devflow-common/client/logging"
 	_ "github.com/bsonger/devflow/docs" // swagger docs 自动生成
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -8,6 +10,7 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
 	"net/http"
 	"time"
 )
@@ -28,7 +31,7 @@ func NewRouter() *gin.Engine {
 		LoggerMiddleware(),
 		GinZapRecovery(),
 		PyroscopeMiddleware(),
-		//GinMetricsMiddleware(),
+		GinMetricsMiddleware(),
 		GinZapLogger(),
 		cors.New(cors.Config{
 			AllowOrigins:     []string{"*"}, // 允许所有来源
@@ -43,23 +46,43 @@ func NewRouter() *gin.Engine {
 	// 1️⃣ Swagger UI 路由
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// 2️⃣ API 分组
-	api := r.Group("/api/v1")
+	// 2️⃣ 探针路由
+	RegisterHealthRoutes(r)
 
-	// 3️⃣ 注册 Application 路由
+	// 3️⃣ API 分组
+	api := r.Group("/api/v1", AuthMiddleware())
+
+	// 4️⃣ 注册 Application 路由
 	RegisterApplicationRoutes(api)
 	RegisterManifestRoutes(api)
 	RegisterJobRoutes(api)
+	RegisterConfigurationRoutes(api)
+	RegisterSecretRoutes(api)
+	RegisterWebhookRoutes(api)
+	RegisterWebhookSubscriptionRoutes(api)
+	RegisterNotificationRoutes(api)
+	RegisterMetricsRoutes(api)
+	RegisterSystemRoutes(api)
+	RegisterDriftRoutes(api)
+	RegisterEventRoutes(api)
 	return r
 }
 
-func StartMetricsServer(addr string) {
+// StartMetricsServer 启动 Prometheus 抓取端口，失败只记录日志，不影响 API 服务；返回的 Server 用于关闭
+func StartMetricsServer(addr string) *http.Server {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
 
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
 	go func() {
-		if err := http.ListenAndServe(addr, mux); err != nil {
-			panic(err)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Logger.Error("metrics server stopped", zap.String("addr", addr), zap.Error(err))
 		}
 	}()
+	return srv
 }
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/bsonger/devflow/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSecretRoutes(rg *gin.RouterGroup) {
+	secret := rg.Group("/secrets")
+
+	secret.GET("", api.SecretRouteApi.List)
+	secret.GET("/:id", api.SecretRouteApi.Get)
+	secret.POST("", api.SecretRouteApi.Create)
+	secret.PUT("/:id", api.SecretRouteApi.Update)
+	secret.DELETE("/:id", api.SecretRouteApi.Delete)
+	secret.GET("/:id/render", api.SecretRouteApi.Render)
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"github.com/bsonger/devflow/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHealthRoutes 探针路由，不经过 /api/v1 鉴权
+func RegisterHealthRoutes(r *gin.Engine) {
+	r.GET("/healthz", api.SystemRouteApi.Healthz)
+	r.GET("/readyz", api.SystemRouteApi.Readyz)
+}
+
+func RegisterSystemRoutes(rg *gin.RouterGroup) {
+	system := rg.Group("/system")
+
+	system.GET("/status", api.SystemRouteApi.Status)
+}
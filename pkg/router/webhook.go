@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/bsonger/devflow/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhookRoutes(rg *gin.RouterGroup) {
+	webhook := rg.Group("/webhooks")
+
+	webhook.POST("/git", api.WebhookRouteApi.Git)
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/bsonger/devflow/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhookSubscriptionRoutes(rg *gin.RouterGroup) {
+	sub := rg.Group("/webhook_subscriptions")
+
+	sub.GET("", api.WebhookSubscriptionRouteApi.List)
+	sub.GET("/:id", api.WebhookSubscriptionRouteApi.Get)
+	sub.POST("", api.WebhookSubscriptionRouteApi.Create)
+	sub.PUT("/:id", api.WebhookSubscriptionRouteApi.Update)
+	sub.DELETE("/:id", api.WebhookSubscriptionRouteApi.Delete)
+	sub.GET("/:id/deliveries", api.WebhookSubscriptionRouteApi.Deliveries)
+
+	delivery := rg.Group("/webhook_deliveries")
+
+	delivery.GET("/:id", api.WebhookSubscriptionRouteApi.GetDelivery)
+	delivery.POST("/:id/redeliver", api.WebhookSubscriptionRouteApi.Redeliver)
+}
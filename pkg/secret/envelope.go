@@ -0,0 +1,98 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+const keySize = 32
+
+// EncryptedValue 信封加密后的值：DEK 由 KeyProvider 包裹，明文由 DEK 以 AES-256-GCM 加密
+type EncryptedValue struct {
+	Provider   string `bson:"provider" json:"provider"`
+	WrappedKey []byte `bson:"wrapped_key" json:"-"`
+	Ciphertext []byte `bson:"ciphertext" json:"-"`
+}
+
+// Encrypt 使用全局 Provider 加密明文
+func Encrypt(ctx context.Context, plaintext []byte) (*EncryptedValue, error) {
+	if Provider == nil {
+		return nil, ErrProviderNotInitialized
+	}
+
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := sealGCM(dek, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := Provider.WrapKey(ctx, dek)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+
+	return &EncryptedValue{
+		Provider:   Provider.Name(),
+		WrappedKey: wrapped,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// Decrypt 使用全局 Provider 解密
+func Decrypt(ctx context.Context, v *EncryptedValue) ([]byte, error) {
+	if Provider == nil {
+		return nil, ErrProviderNotInitialized
+	}
+	if v == nil {
+		return nil, errors.New("encrypted value is nil")
+	}
+
+	dek, err := Provider.UnwrapKey(ctx, v.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return openGCM(dek, v.Ciphertext)
+}
+
+// sealGCM 输出 nonce || ciphertext
+func sealGCM(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func openGCM(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
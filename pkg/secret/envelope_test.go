@@ -0,0 +1,151 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func useProvider(t *testing.T, p KeyProvider) {
+	t.Helper()
+	prev := Provider
+	Provider = p
+	t.Cleanup(func() { Provider = prev })
+}
+
+func fileProvider(t *testing.T, key string) KeyProvider {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	useProvider(t, fileProvider(t, newKey(t)))
+
+	for _, plaintext := range [][]byte{[]byte("s3cr3t"), {}, bytes.Repeat([]byte{0xff}, 4096)} {
+		v, err := Encrypt(context.Background(), plaintext)
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		if v.Provider != ProviderFile {
+			t.Errorf("provider = %q, want %q", v.Provider, ProviderFile)
+		}
+		if len(plaintext) > 0 && bytes.Contains(v.Ciphertext, plaintext) {
+			t.Error("ciphertext contains plaintext")
+		}
+		got, err := Decrypt(context.Background(), v)
+		if err != nil {
+			t.Fatalf("decrypt: %v", err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("decrypt = %q, want %q", got, plaintext)
+		}
+	}
+}
+
+func TestEnvelopeFreshDataKey(t *testing.T) {
+	useProvider(t, fileProvider(t, newKey(t)))
+
+	a, err := Encrypt(context.Background(), []byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Encrypt(context.Background(), []byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a.WrappedKey, b.WrappedKey) || bytes.Equal(a.Ciphertext, b.Ciphertext) {
+		t.Error("encrypting twice reused the data key or nonce")
+	}
+}
+
+func TestEnvelopeTamper(t *testing.T) {
+	useProvider(t, fileProvider(t, newKey(t)))
+
+	tests := []struct {
+		name   string
+		mutate func(v *EncryptedValue)
+	}{
+		{"ciphertext bit flip", func(v *EncryptedValue) { v.Ciphertext[len(v.Ciphertext)-1] ^= 1 }},
+		{"nonce bit flip", func(v *EncryptedValue) { v.Ciphertext[0] ^= 1 }},
+		{"wrapped key bit flip", func(v *EncryptedValue) { v.WrappedKey[len(v.WrappedKey)-1] ^= 1 }},
+		{"truncated ciphertext", func(v *EncryptedValue) { v.Ciphertext = v.Ciphertext[:4] }},
+		{"truncated wrapped key", func(v *EncryptedValue) { v.WrappedKey = v.WrappedKey[:4] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Encrypt(context.Background(), []byte("s3cr3t"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.mutate(v)
+			if got, err := Decrypt(context.Background(), v); err == nil {
+				t.Errorf("decrypt succeeded with %q, want error", got)
+			}
+		})
+	}
+}
+
+func TestEnvelopeWrongKey(t *testing.T) {
+	useProvider(t, fileProvider(t, newKey(t)))
+	v, err := Encrypt(context.Background(), []byte("s3cr3t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewStaticKeyProvider(ProviderConfig, newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	useProvider(t, other)
+	if _, err := Decrypt(context.Background(), v); err == nil {
+		t.Error("decrypt with another key succeeded, want error")
+	}
+}
+
+func TestEnvelopeProviderNotInitialized(t *testing.T) {
+	useProvider(t, nil)
+	if _, err := Encrypt(context.Background(), []byte("x")); err != ErrProviderNotInitialized {
+		t.Errorf("encrypt err = %v, want %v", err, ErrProviderNotInitialized)
+	}
+	if _, err := Decrypt(context.Background(), &EncryptedValue{}); err != ErrProviderNotInitialized {
+		t.Errorf("decrypt err = %v, want %v", err, ErrProviderNotInitialized)
+	}
+}
+
+func TestNewStaticKeyProviderRejectsBadKey(t *testing.T) {
+	tests := map[string]string{
+		"not base64": "!!!",
+		"short key":  base64.StdEncoding.EncodeToString([]byte("short")),
+	}
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewStaticKeyProvider(ProviderConfig, key); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+	if _, err := NewFileKeyProvider(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing key file: want error")
+	}
+}
@@ -0,0 +1,108 @@
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider 负责包裹 / 解包数据密钥（DEK），与 KMS 的 encrypt / decrypt 语义一致
+type KeyProvider interface {
+	// Name 返回 provider 标识，随密文一起保存
+	Name() string
+	WrapKey(ctx context.Context, dek []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+const (
+	ProviderConfig = "config"
+	ProviderFile   = "file"
+	ProviderVault  = "vault"
+)
+
+// Provider 全局唯一 KeyProvider，由 InitKeyProvider 初始化
+var Provider KeyProvider
+
+var ErrProviderNotInitialized = errors.New("secret key provider not initialized")
+
+type Config struct {
+	Provider string `mapstructure:"provider" json:"provider" yaml:"provider"` // config | file | vault
+	// Key base64 编码的 32 字节主密钥（provider=config）
+	Key string `mapstructure:"key" json:"key" yaml:"key"`
+	// KeyFile 保存 base64 主密钥的文件路径（provider=file）
+	KeyFile string       `mapstructure:"key_file" json:"key_file" yaml:"key_file"`
+	Vault   *VaultConfig `mapstructure:"vault" json:"vault" yaml:"vault"`
+	// SealedSecretsCert sealed-secrets controller 公钥证书（PEM）路径
+	SealedSecretsCert string `mapstructure:"sealed_secrets_cert" json:"sealed_secrets_cert" yaml:"sealed_secrets_cert"`
+}
+
+func InitKeyProvider(config *Config) error {
+	if config == nil {
+		return nil
+	}
+
+	if config.SealedSecretsCert != "" {
+		if err := LoadSealingCert(config.SealedSecretsCert); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch config.Provider {
+	case ProviderConfig, "":
+		// 未配置主密钥时不启用加密，Secret 相关操作返回 ErrProviderNotInitialized
+		if config.Key == "" {
+			return nil
+		}
+		Provider, err = NewStaticKeyProvider(ProviderConfig, config.Key)
+	case ProviderFile:
+		Provider, err = NewFileKeyProvider(config.KeyFile)
+	case ProviderVault:
+		Provider, err = NewVaultKeyProvider(config.Vault)
+	default:
+		err = fmt.Errorf("unknown secret provider %q", config.Provider)
+	}
+	return err
+}
+
+// staticKeyProvider 使用本地主密钥（KEK）以 AES-GCM 包裹数据密钥
+type staticKeyProvider struct {
+	name string
+	kek  []byte
+}
+
+func NewStaticKeyProvider(name, encodedKey string) (KeyProvider, error) {
+	kek, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret key: %w", err)
+	}
+	if len(kek) != keySize {
+		return nil, fmt.Errorf("secret key must be %d bytes, got %d", keySize, len(kek))
+	}
+	return &staticKeyProvider{name: name, kek: kek}, nil
+}
+
+// NewFileKeyProvider 从文件读取 base64 主密钥，便于本地与测试环境使用
+func NewFileKeyProvider(path string) (KeyProvider, error) {
+	if path == "" {
+		return nil, errors.New("secret key_file is empty")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read secret key file: %w", err)
+	}
+	return NewStaticKeyProvider(ProviderFile, string(data))
+}
+
+func (p *staticKeyProvider) Name() string { return p.name }
+
+func (p *staticKeyProvider) WrapKey(_ context.Context, dek []byte) ([]byte, error) {
+	return sealGCM(p.kek, dek)
+}
+
+func (p *staticKeyProvider) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	return openGCM(p.kek, wrapped)
+}
@@ -0,0 +1,84 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// sealingKey sealed-secrets controller 的公钥，用于生成 SealedSecret
+var sealingKey *rsa.PublicKey
+
+var ErrSealingCertNotLoaded = errors.New("sealed secrets certificate not loaded")
+
+// LoadSealingCert 读取 kubeseal --fetch-cert 导出的 PEM 证书
+func LoadSealingCert(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read sealed secrets cert: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("invalid sealed secrets cert: no PEM block")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parse sealed secrets cert: %w", err)
+	}
+
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("sealed secrets cert is not an RSA key")
+	}
+	sealingKey = key
+	return nil
+}
+
+// SealValue 按 sealed-secrets 的 strict scope 规则加密单个值，label 为 "<namespace>/<name>"
+func SealValue(namespace, name string, plaintext []byte) (string, error) {
+	if sealingKey == nil {
+		return "", ErrSealingCertNotLoaded
+	}
+
+	label := []byte(namespace + "/" + name)
+
+	sessionKey := make([]byte, keySize)
+	if _, err := rand.Read(sessionKey); err != nil {
+		return "", err
+	}
+
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, sealingKey, sessionKey, label)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	// session key 只使用一次，与 kubeseal 一致使用全零 nonce
+	nonce := make([]byte, gcm.NonceSize())
+
+	out := make([]byte, 2, 2+len(rsaCiphertext)+len(plaintext)+gcm.Overhead())
+	binary.BigEndian.PutUint16(out, uint16(len(rsaCiphertext)))
+	out = append(out, rsaCiphertext...)
+	out = gcm.Seal(out, nonce, plaintext, nil)
+
+	return base64.StdEncoding.EncodeToString(out), nil
+}
@@ -0,0 +1,96 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultConfig Vault Transit（KMS 兼容）配置
+type VaultConfig struct {
+	Address string `mapstructure:"address" json:"address" yaml:"address"`
+	Token   string `mapstructure:"token"   json:"token"   yaml:"token"`
+	Mount   string `mapstructure:"mount"   json:"mount"   yaml:"mount"` // 默认 transit
+	KeyName string `mapstructure:"key_name" json:"key_name" yaml:"key_name"`
+}
+
+// vaultKeyProvider 通过 Vault Transit encrypt / decrypt 接口包裹数据密钥
+type vaultKeyProvider struct {
+	config *VaultConfig
+	client *http.Client
+}
+
+func NewVaultKeyProvider(config *VaultConfig) (KeyProvider, error) {
+	if config == nil || config.Address == "" || config.KeyName == "" {
+		return nil, errors.New("vault address and key_name are required")
+	}
+	if config.Mount == "" {
+		config.Mount = "transit"
+	}
+	return &vaultKeyProvider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (p *vaultKeyProvider) Name() string { return ProviderVault }
+
+func (p *vaultKeyProvider) WrapKey(ctx context.Context, dek []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dek)}
+	if err := p.call(ctx, "encrypt", body, &resp); err != nil {
+		return nil, err
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+func (p *vaultKeyProvider) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"ciphertext": string(wrapped)}
+	if err := p.call(ctx, "decrypt", body, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+func (p *vaultKeyProvider) call(ctx context.Context, op string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", strings.TrimRight(p.config.Address, "/"), p.config.Mount, op, p.config.KeyName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", p.config.Token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("vault %s failed: %s: %s", op, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
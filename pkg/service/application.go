@@ -8,14 +8,21 @@ import (
 	"github.com/bsonger/devflow-common/client/logging"
 	"github.com/bsonger/devflow-common/client/mongo"
 	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
 var ApplicationService = NewApplicationService()
 
-var ErrManifestNotForApplication = errors.New("manifest does not belong to application")
+var (
+	ErrManifestNotForApplication = errors.New("manifest does not belong to application")
+	ErrResourceNotDeleted        = errors.New("resource is not deleted")
+)
 
 type applicationService struct{}
 
@@ -24,11 +31,16 @@ func NewApplicationService() *applicationService {
 }
 
 // Create 创建 Application
-func (s *applicationService) Create(ctx context.Context, app *model.Application) (primitive.ObjectID, error) {
+func (s *applicationService) Create(ctx context.Context, app *domain.Application) (primitive.ObjectID, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "create_application"),
 	)
 
+	if err := s.validateConfigurations(ctx, app); err != nil {
+		log.Warn("invalid configuration reference", zap.Error(err))
+		return primitive.NilObjectID, err
+	}
+
 	if err := mongo.Repo.Create(ctx, app); err != nil {
 		log.Error("create application failed", zap.Error(err))
 		return primitive.NilObjectID, err
@@ -39,13 +51,13 @@ func (s *applicationService) Create(ctx context.Context, app *model.Application)
 }
 
 // Get 根据 ID 查询 Application
-func (s *applicationService) Get(ctx context.Context, id primitive.ObjectID) (*model.Application, error) {
+func (s *applicationService) Get(ctx context.Context, id primitive.ObjectID) (*domain.Application, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "get_application"),
 		zap.String("application_id", id.Hex()),
 	)
 
-	app := &model.Application{}
+	app := &domain.Application{}
 	if err := mongo.Repo.FindByID(ctx, app, id); err != nil {
 		log.Error("get application failed", zap.Error(err))
 		return nil, err
@@ -60,13 +72,13 @@ func (s *applicationService) Get(ctx context.Context, id primitive.ObjectID) (*m
 }
 
 // Update 更新 Application
-func (s *applicationService) Update(ctx context.Context, app *model.Application) error {
+func (s *applicationService) Update(ctx context.Context, app *domain.Application) error {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "update_application"),
 		zap.String("application_id", app.GetID().Hex()),
 	)
 
-	current := &model.Application{}
+	current := &domain.Application{}
 	if err := mongo.Repo.FindByID(ctx, current, app.GetID()); err != nil {
 		log.Error("load application failed", zap.Error(err))
 		return err
@@ -76,6 +88,11 @@ func (s *applicationService) Update(ctx context.Context, app *model.Application)
 		return mongoDriver.ErrNoDocuments
 	}
 
+	if err := s.validateConfigurations(ctx, app); err != nil {
+		log.Warn("invalid configuration reference", zap.Error(err))
+		return err
+	}
+
 	app.CreatedAt = current.CreatedAt
 	app.DeletedAt = current.DeletedAt
 	app.WithUpdateDefault()
@@ -89,13 +106,38 @@ func (s *applicationService) Update(ctx context.Context, app *model.Application)
 	return nil
 }
 
-// Delete 删除 Application
-func (s *applicationService) Delete(ctx context.Context, id primitive.ObjectID) error {
+// DeleteOptions 控制 Application 删除时的级联行为
+type DeleteOptions struct {
+	// Teardown 为 true 时同时删除 Argo CD 中的 Application
+	Teardown bool
+}
+
+// Delete 软删除 Application，并级联隐藏其下的 Manifest / Job
+func (s *applicationService) Delete(ctx context.Context, id primitive.ObjectID, opts DeleteOptions) error {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "delete_application"),
 		zap.String("application_id", id.Hex()),
+		zap.Bool("teardown", opts.Teardown),
 	)
 
+	app := &domain.Application{}
+	if err := mongo.Repo.FindByID(ctx, app, id); err != nil {
+		log.Error("get application failed", zap.Error(err))
+		return err
+	}
+	if app.DeletedAt != nil {
+		log.Warn("application already deleted")
+		return mongoDriver.ErrNoDocuments
+	}
+
+	if opts.Teardown {
+		if err := deleteArgoApplication(ctx, app.Name); err != nil {
+			log.Error("teardown argo application failed", zap.Error(err))
+			return err
+		}
+		log.Info("argo application teardown triggered")
+	}
+
 	now := time.Now()
 	update := primitive.M{
 		"$set": primitive.M{
@@ -109,10 +151,69 @@ func (s *applicationService) Delete(ctx context.Context, id primitive.ObjectID)
 		return err
 	}
 
+	// 子资源使用与 Application 相同的 deleted_at，恢复时据此识别级联删除的记录
+	children := primitive.M{
+		"application_id": id,
+		"deleted_at":     primitive.M{"$exists": false},
+	}
+	if err := mongo.Repo.UpdateMany(ctx, &model.Manifest{}, children, update); err != nil {
+		log.Error("cascade delete manifests failed", zap.Error(err))
+		return err
+	}
+	if err := mongo.Repo.UpdateMany(ctx, &model.Job{}, children, update); err != nil {
+		log.Error("cascade delete jobs failed", zap.Error(err))
+		return err
+	}
+
 	log.Info("application deleted")
 	return nil
 }
 
+// Restore 恢复软删除的 Application 及随其级联删除的 Manifest / Job。
+// 已 teardown 的 Argo CD Application 不会被重建，需要重新创建 Install Job。
+func (s *applicationService) Restore(ctx context.Context, id primitive.ObjectID) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "restore_application"),
+		zap.String("application_id", id.Hex()),
+	)
+
+	app := &domain.Application{}
+	if err := mongo.Repo.FindByID(ctx, app, id); err != nil {
+		log.Error("get application failed", zap.Error(err))
+		return err
+	}
+	if app.DeletedAt == nil {
+		log.Warn("restore skipped for active application")
+		return ErrResourceNotDeleted
+	}
+
+	update := primitive.M{
+		"$unset": primitive.M{"deleted_at": ""},
+		"$set":   primitive.M{"updated_at": time.Now()},
+	}
+
+	if err := mongo.Repo.UpdateByID(ctx, &model.Application{}, id, update); err != nil {
+		log.Error("restore application failed", zap.Error(err))
+		return err
+	}
+
+	children := primitive.M{
+		"application_id": id,
+		"deleted_at":     *app.DeletedAt,
+	}
+	if err := mongo.Repo.UpdateMany(ctx, &model.Manifest{}, children, update); err != nil {
+		log.Error("restore manifests failed", zap.Error(err))
+		return err
+	}
+	if err := mongo.Repo.UpdateMany(ctx, &model.Job{}, children, update); err != nil {
+		log.Error("restore jobs failed", zap.Error(err))
+		return err
+	}
+
+	log.Info("application restored")
+	return nil
+}
+
 // UpdateActiveManifest updates the application active manifest reference.
 func (s *applicationService) UpdateActiveManifest(ctx context.Context, appID, manifestID primitive.ObjectID) error {
 	log := logging.LoggerWithContext(ctx).With(
@@ -121,7 +222,7 @@ func (s *applicationService) UpdateActiveManifest(ctx context.Context, appID, ma
 		zap.String("manifest_id", manifestID.Hex()),
 	)
 
-	app := &model.Application{}
+	app := &domain.Application{}
 	if err := mongo.Repo.FindByID(ctx, app, appID); err != nil {
 		log.Error("get application failed", zap.Error(err))
 		return err
@@ -153,7 +254,17 @@ func (s *applicationService) UpdateActiveManifest(ctx context.Context, appID, ma
 		},
 	}
 
-	if err := mongo.Repo.UpdateByID(ctx, &model.Application{}, appID, update); err != nil {
+	err := emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		if err := mongo.Repo.UpdateByID(ctx, &model.Application{}, appID, update); err != nil {
+			return nil, err
+		}
+		return []events.DomainEvent{events.ActiveManifestChangedEvent{
+			ApplicationID: appID,
+			ManifestID:    &manifestID,
+			ManifestName:  manifest.Name,
+		}}, nil
+	})
+	if err != nil {
 		log.Error("update active manifest failed", zap.Error(err))
 		return err
 	}
@@ -162,14 +273,86 @@ func (s *applicationService) UpdateActiveManifest(ctx context.Context, appID, ma
 	return nil
 }
 
+// ActiveManifest 返回 Application 在 env 当前部署的 Manifest：默认环境为 active manifest，
+// 其他环境为最近一次成功的部署 Job 的 Manifest，最近一次成功的是 Uninstall 或从未部署时返回 nil
+func (s *applicationService) ActiveManifest(ctx context.Context, app *domain.Application, env string) (*primitive.ObjectID, string, error) {
+	if env == "" || env == domain.DefaultEnv {
+		return app.ActiveManifestID, app.ActiveManifestName, nil
+	}
+
+	job := &model.Job{}
+	filter := primitive.M{
+		"application_id": app.GetID(),
+		"env":            env,
+		"status":         model.JobSucceeded,
+		"deleted_at":     primitive.M{"$exists": false},
+	}
+	opts := options.FindOne().SetSort(primitive.D{{Key: "created_at", Value: -1}})
+	err := store.Collection(job).FindOne(ctx, filter, opts).Decode(job)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if job.Type == JobUninstall || job.ManifestID.IsZero() {
+		return nil, "", nil
+	}
+	return &job.ManifestID, job.ManifestName, nil
+}
+
+// ClearActiveManifest 清空 Application 的 active manifest 与状态（Uninstall 完成后调用）
+func (s *applicationService) ClearActiveManifest(ctx context.Context, appID primitive.ObjectID) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "clear_application_active_manifest"),
+		zap.String("application_id", appID.Hex()),
+	)
+
+	update := primitive.M{
+		"$unset": primitive.M{"active_manifest_id": ""},
+		"$set": primitive.M{
+			"active_manifest_name": "",
+			"status":               "",
+			"updated_at":           time.Now(),
+		},
+	}
+
+	err := emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		if err := mongo.Repo.UpdateByID(ctx, &model.Application{}, appID, update); err != nil {
+			return nil, err
+		}
+		return []events.DomainEvent{events.ActiveManifestChangedEvent{ApplicationID: appID}}, nil
+	})
+	if err != nil {
+		log.Error("clear active manifest failed", zap.Error(err))
+		return err
+	}
+
+	log.Info("active manifest cleared")
+	return nil
+}
+
+// validateConfigurations 校验 Application 引用的 Configuration / Secret 均存在且未删除，以及自动部署规则合法
+func (s *applicationService) validateConfigurations(ctx context.Context, app *domain.Application) error {
+	for i := range app.AutoDeploy {
+		if err := app.AutoDeploy[i].Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := ConfigurationService.Snapshot(ctx, app.ConfigurationIDs); err != nil {
+		return err
+	}
+	return SecretService.Validate(ctx, app.ProjectName, app.SecretIDs)
+}
+
 // List 查询 Application 列表
-func (s *applicationService) List(ctx context.Context, filter primitive.M) ([]model.Application, error) {
+func (s *applicationService) List(ctx context.Context, filter primitive.M) ([]domain.Application, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "list_applications"),
 		zap.Any("filter", filter),
 	)
 
-	var apps []model.Application
+	var apps []domain.Application
 	if err := mongo.Repo.List(ctx, &model.Application{}, filter, &apps); err != nil {
 		log.Error("list applications failed", zap.Error(err))
 		return nil, err
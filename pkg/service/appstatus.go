@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	appv1 "github.com/argoproj/argo-cd/v3/pkg/apis/application/v1alpha1"
+	"github.com/bsonger/devflow-common/client/argo"
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/tekton"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// applicationStatusTTL 实时状态缓存时间，避免看板轮询压垮 Argo CD / Kubernetes API
+	applicationStatusTTL = 5 * time.Second
+	// recentOperationLimit 返回的最近部署记录数
+	recentOperationLimit = 5
+)
+
+var ErrApplicationNotDeployed = errors.New("application is not deployed to argo cd")
+
+var ApplicationStatusService = NewApplicationStatusService()
+
+// ApplicationLiveStatus Application 在集群中的实际运行状态
+type ApplicationLiveStatus struct {
+	Application string            `json:"application"`
+	Namespace   string            `json:"namespace"`
+	SyncStatus  string            `json:"sync_status"`
+	Health      string            `json:"health"`
+	Revision    string            `json:"revision,omitempty"`
+	Images      []string          `json:"images,omitempty"`
+	Resources   []ResourceNode    `json:"resources"`
+	Operations  []OperationResult `json:"operations,omitempty"`
+	Conditions  []string          `json:"conditions,omitempty"`
+	FetchedAt   time.Time         `json:"fetched_at"`
+}
+
+// ResourceNode 资源树节点，Deployment 的 Children 为其 Pod
+type ResourceNode struct {
+	Kind       string         `json:"kind"`
+	Name       string         `json:"name"`
+	Namespace  string         `json:"namespace,omitempty"`
+	SyncStatus string         `json:"sync_status,omitempty"`
+	Health     string         `json:"health,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	Ready      *bool          `json:"ready,omitempty"`
+	Restarts   int32          `json:"restarts,omitempty"`
+	Images     []string       `json:"images,omitempty"`
+	Children   []ResourceNode `json:"children,omitempty"`
+}
+
+// OperationResult Argo CD 的同步操作结果，第一条为当前 / 最近一次操作
+type OperationResult struct {
+	Revision   string     `json:"revision,omitempty"`
+	Phase      string     `json:"phase,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type statusCacheEntry struct {
+	status  *ApplicationLiveStatus
+	expires time.Time
+}
+
+type applicationStatusService struct {
+	mu    sync.Mutex
+	cache map[primitive.ObjectID]statusCacheEntry
+	group singleflight.Group
+}
+
+func NewApplicationStatusService() *applicationStatusService {
+	return &applicationStatusService{cache: map[primitive.ObjectID]statusCacheEntry{}}
+}
+
+// Get 返回 Application 的实时状态，applicationStatusTTL 内复用缓存，并发请求合并为一次查询
+func (s *applicationStatusService) Get(ctx context.Context, id primitive.ObjectID) (*ApplicationLiveStatus, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[id]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.status, nil
+	}
+
+	// 合并后的查询可能被多个请求共享，不随发起请求的取消而中断
+	v, err, _ := s.group.Do(id.Hex(), func() (any, error) {
+		status, err := s.fetch(context.WithoutCancel(ctx), id)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		s.cache[id] = statusCacheEntry{status: status, expires: time.Now().Add(applicationStatusTTL)}
+		s.mu.Unlock()
+		return status, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*ApplicationLiveStatus), nil
+}
+
+func (s *applicationStatusService) fetch(ctx context.Context, id primitive.ObjectID) (*ApplicationLiveStatus, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "get_application_status"),
+		zap.String("application_id", id.Hex()),
+	)
+
+	app, err := ApplicationService.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	argoApp, err := argo.ArgoCdClient.ArgoprojV1alpha1().Applications(argoNamespace).Get(ctx, app.Name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil, ErrApplicationNotDeployed
+	}
+	if err != nil {
+		log.Error("get argo application failed", zap.Error(err))
+		return nil, err
+	}
+
+	status := &ApplicationLiveStatus{
+		Application: app.Name,
+		Namespace:   argoApp.Spec.Destination.Namespace,
+		SyncStatus:  string(argoApp.Status.Sync.Status),
+		Health:      string(argoApp.Status.Health.Status),
+		Revision:    argoApp.Status.Sync.Revision,
+		Images:      argoApp.Status.Summary.Images,
+		Operations:  recentOperations(argoApp),
+		FetchedAt:   time.Now(),
+	}
+	for _, c := range argoApp.Status.Conditions {
+		status.Conditions = append(status.Conditions, c.Type+": "+c.Message)
+	}
+
+	status.Resources = make([]ResourceNode, 0, len(argoApp.Status.Resources))
+	for _, r := range argoApp.Status.Resources {
+		node := ResourceNode{
+			Kind:       r.Kind,
+			Name:       r.Name,
+			Namespace:  r.Namespace,
+			SyncStatus: string(r.Status),
+		}
+		if r.Health != nil {
+			node.Health = string(r.Health.Status)
+			node.Message = r.Health.Message
+		}
+		if r.Kind == "Deployment" {
+			pods, err := s.deploymentPods(ctx, r.Namespace, r.Name)
+			if err != nil {
+				log.Warn("list deployment pods failed", zap.String("deployment", r.Name), zap.Error(err))
+			}
+			node.Children = pods
+		}
+		status.Resources = append(status.Resources, node)
+	}
+
+	log.Debug("application status fetched",
+		zap.String("sync_status", status.SyncStatus),
+		zap.String("health", status.Health),
+	)
+	return status, nil
+}
+
+// deploymentPods 按 Deployment 的 selector 列出 Pod
+func (s *applicationStatusService) deploymentPods(ctx context.Context, namespace, name string) ([]ResourceNode, error) {
+	deploy, err := tekton.KubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := tekton.KubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]ResourceNode, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		nodes = append(nodes, podNode(&pod))
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+func podNode(pod *corev1.Pod) ResourceNode {
+	ready := false
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			ready = c.Status == corev1.ConditionTrue
+		}
+	}
+
+	node := ResourceNode{
+		Kind:      "Pod",
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Health:    string(pod.Status.Phase),
+		Message:   pod.Status.Message,
+		Ready:     &ready,
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		node.Restarts += cs.RestartCount
+		node.Images = append(node.Images, cs.Image)
+	}
+	return node
+}
+
+// recentOperations 当前操作在前，随后按时间倒序的部署历史
+func recentOperations(app *appv1.Application) []OperationResult {
+	ops := make([]OperationResult, 0, recentOperationLimit+1)
+
+	if op := app.Status.OperationState; op != nil {
+		result := OperationResult{
+			Phase:     string(op.Phase),
+			Message:   op.Message,
+			StartedAt: &op.StartedAt.Time,
+		}
+		if op.SyncResult != nil {
+			result.Revision = op.SyncResult.Revision
+		}
+		if op.FinishedAt != nil {
+			result.FinishedAt = &op.FinishedAt.Time
+		}
+		ops = append(ops, result)
+	}
+
+	history := app.Status.History
+	for i := len(history) - 1; i >= 0 && len(ops) <= recentOperationLimit; i-- {
+		h := history[i]
+		result := OperationResult{
+			Revision:   h.Revision,
+			Phase:      "Deployed",
+			FinishedAt: &h.DeployedAt.Time,
+		}
+		if h.DeployStartedAt != nil {
+			result.StartedAt = &h.DeployStartedAt.Time
+		}
+		ops = append(ops, result)
+	}
+	return ops
+}
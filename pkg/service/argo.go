@@ -2,20 +2,180 @@ package service
 
 import (
 	"context"
+	"errors"
 	"github.com/argoproj/gitops-engine/pkg/health"
+	"sync/atomic"
+	"time"
 
 	appv1 "github.com/argoproj/argo-cd/v3/pkg/apis/application/v1alpha1"
+	argoinformers "github.com/argoproj/argo-cd/v3/pkg/client/informers/externalversions"
+	"github.com/bsonger/devflow-common/client/argo"
 	"github.com/bsonger/devflow-common/client/logging"
 	"github.com/bsonger/devflow-common/client/mongo"
 	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/events"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
+const (
+	argoNamespace = "argocd"
+
+	// uninstallSweepInterval 检查进行中 Uninstall Job 的周期，补偿 informer 未观察到的删除（如服务重启期间）
+	uninstallSweepInterval = 30 * time.Second
+	// argoInformerResync informer 全量 resync 周期
+	argoInformerResync = 10 * time.Minute
+)
+
+// deleteArgoApplication 删除 Argo CD Application，不存在时视为成功
+func deleteArgoApplication(ctx context.Context, name string) error {
+	applications := argo.ArgoCdClient.ArgoprojV1alpha1().Applications(argoNamespace)
+
+	err := applications.Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// uninstallArgoApplication 按 cascade 策略设置 finalizer 后删除 Argo CD Application，
+// 返回 false 表示 Application 本就不存在
+func uninstallArgoApplication(ctx context.Context, name, cascade string) (bool, error) {
+	applications := argo.ArgoCdClient.ArgoprojV1alpha1().Applications(argoNamespace)
+
+	current, err := applications.Get(ctx, name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	current.UnSetCascadedDeletion()
+	switch cascade {
+	case CascadeForeground:
+		current.SetCascadedDeletion(appv1.ForegroundPropagationPolicyFinalizer)
+	case CascadeBackground:
+		current.SetCascadedDeletion(appv1.BackgroundPropagationPolicyFinalizer)
+	}
+
+	if _, err := applications.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+		return true, err
+	}
+
+	if err := deleteArgoApplication(ctx, name); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// argoInformerSynced informer 首次同步完成，readiness 检查使用
+var argoInformerSynced atomic.Bool
+
+// ArgoInformerSynced Argo CD Application informer 是否已完成首次同步
+func ArgoInformerSynced() bool {
+	return argoInformerSynced.Load()
+}
+
+// StartArgoCdInformer 监听 argocd 命名空间下的 Application，同步 / 健康状态变化时推送事件，
+// 删除时确认进行中的 Uninstall Job；ctx 取消时停止，首次同步完成前阻塞
 func StartArgoCdInformer(ctx context.Context) error {
+	factory := argoinformers.NewSharedInformerFactoryWithOptions(
+		argo.ArgoCdClient,
+		argoInformerResync,
+		argoinformers.WithNamespace(argoNamespace),
+	)
+	informer := factory.Argoproj().V1alpha1().Applications().Informer()
+	exists := func(name string) (bool, error) {
+		_, ok, err := informer.GetIndexer().GetByKey(argoNamespace + "/" + name)
+		return ok, err
+	}
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldApp, ok1 := oldObj.(*appv1.Application)
+			newApp, ok2 := newObj.(*appv1.Application)
+			if !ok1 || !ok2 {
+				return
+			}
+			BusinessMetrics.RecordArgoSync(ctx, oldApp, newApp)
+			if oldApp.Status.Health.Status == newApp.Status.Health.Status &&
+				oldApp.Status.Sync.Status == newApp.Status.Sync.Status {
+				return
+			}
+			publishArgoHealth(ctx, newApp)
+		},
+		DeleteFunc: func(interface{}) {
+			JobService.ReconcileUninstalls(ctx, exists)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	factory.Start(ctx.Done())
+	// ctx 取消后等待 informer 及其事件处理 goroutine 退出
+	goWorker(func() {
+		<-ctx.Done()
+		factory.Shutdown()
+	})
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return errors.New("argo cd application informer sync failed")
+	}
+	argoInformerSynced.Store(true)
+	logging.LoggerWithContext(ctx).Info("argo cd application informer started")
+
+	// 周期扫描确认 Uninstall：覆盖重启前已触发、删除事件已错过的 Job，以及超时
+	goWorker(func() {
+		ticker := time.NewTicker(uninstallSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			JobService.ReconcileUninstalls(ctx, exists)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
 	return nil
 }
 
+// publishArgoHealth 通过 job-id 标签关联 devflow Application 后推送健康状态事件
+func publishArgoHealth(ctx context.Context, app *appv1.Application) {
+	var applicationID, project string
+	if jobID, err := primitive.ObjectIDFromHex(app.Labels[model.JobIDLabel]); err == nil {
+		job := &model.Job{}
+		if err := mongo.Repo.FindByID(ctx, job, jobID); err == nil {
+			applicationID, project = job.ApplicationId.Hex(), job.ProjectName
+		}
+	}
+	if applicationID == "" {
+		logging.LoggerWithContext(ctx).Debug("skip argo event without devflow job", zap.String("application", app.Name))
+		return
+	}
+
+	events.Publish(events.Event{
+		Type:          events.ApplicationHealthChanged,
+		Resource:      events.ResourceApplication,
+		ResourceID:    applicationID,
+		ApplicationID: applicationID,
+		Project:       project,
+		Data: map[string]any{
+			"name":        app.Name,
+			"sync_status": app.Status.Sync.Status,
+			"health":      app.Status.Health.Status,
+			"message":     app.Status.Health.Message,
+			"revision":    app.Status.Sync.Revision,
+		},
+	})
+}
+
 func handleArgoEvent(ctx context.Context, obj interface{}) {
 	app, ok := obj.(*appv1.Application)
 	if !ok {
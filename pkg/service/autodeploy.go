@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+var AutoDeployService = NewAutoDeployService()
+
+// autoDeployClaimTimeout Pending 审计记录的占位时限，须大于 outboxHandlerTimeout：
+// 超过时限仍为 Pending 说明评估中途退出，记录可被重新领取
+const autoDeployClaimTimeout = 2 * time.Minute
+
+// errAutoDeployInProgress 其他实例正在评估同一 Manifest / 环境，outbox 稍后重试
+var errAutoDeployInProgress = errors.New("auto deploy evaluation in progress")
+
+type autoDeployService struct{}
+
+func NewAutoDeployService() *autoDeployService {
+	return &autoDeployService{}
+}
+
+// HandleEvent outbox 订阅者：Manifest 进入 Succeeded 时评估自动部署；Manifest 已删除时不再评估
+func (s *autoDeployService) HandleEvent(ctx context.Context, msg OutboxMessage) error {
+	e, ok := msg.Event.(*events.ManifestStatusChangedEvent)
+	if !ok || e.Status != model.ManifestSucceeded {
+		return nil
+	}
+	m, err := ManifestService.Get(ctx, e.ManifestID)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.Evaluate(ctx, m)
+}
+
+// Evaluate Manifest 进入 Succeeded 后按 Application 的自动部署规则创建 Job，每条命中规则写一条审计记录。
+// 审计记录先以 Pending 写入，(manifest_id, env) 唯一索引保证同一 Manifest / 环境只评估一次；
+// 占位未完成（其他实例评估中）或写入失败时返回 error，由 outbox 按退避重试
+func (s *autoDeployService) Evaluate(ctx context.Context, m *domain.Manifest) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "evaluate_auto_deploy"),
+		zap.String("manifest_id", m.GetID().Hex()),
+		zap.String("manifest_name", m.Name),
+	)
+
+	app, err := ApplicationService.Get(ctx, m.ApplicationId)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		log.Error("get application failed", zap.Error(err))
+		return err
+	}
+
+	var errs []error
+	for i := range app.AutoDeploy {
+		rule := &app.AutoDeploy[i]
+		if !rule.Match(m) {
+			continue
+		}
+
+		record, err := s.claim(ctx, app, m, rule)
+		if err != nil {
+			if !errors.Is(err, errAutoDeployInProgress) {
+				log.Error("claim auto deploy record failed", zap.String("env", rule.Env), zap.Error(err))
+			}
+			errs = append(errs, fmt.Errorf("env %s: %w", rule.Env, err))
+			continue
+		}
+		if record == nil {
+			log.Info("auto deploy already evaluated", zap.String("env", rule.Env))
+			continue
+		}
+
+		if err := s.deploy(ctx, app, m, rule, record); err != nil {
+			log.Error("auto deploy failed", zap.String("env", rule.Env), zap.Error(err))
+			errs = append(errs, fmt.Errorf("env %s: %w", rule.Env, err))
+			continue
+		}
+
+		update := bson.M{
+			"result":     record.Result,
+			"reason":     record.Reason,
+			"updated_at": time.Now(),
+		}
+		if record.JobID != nil {
+			update["job_id"] = record.JobID
+		}
+		if err := mongo.Repo.UpdateByID(ctx, record, record.GetID(), bson.M{"$set": update}); err != nil {
+			// 记录保持 Pending，超过占位时限后重新领取时按已创建的 Job 补写结果
+			log.Error("update auto deploy record failed", zap.String("env", rule.Env), zap.Error(err))
+			errs = append(errs, fmt.Errorf("env %s: %w", rule.Env, err))
+			continue
+		}
+
+		log.Info("auto deploy evaluated",
+			zap.String("env", rule.Env),
+			zap.String("rule", rule.Pattern()),
+			zap.String("result", record.Result),
+			zap.String("reason", record.Reason),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+// claim 写入 Pending 审计记录占位；记录已存在时：已有结果返回 nil，
+// Pending 且超过 autoDeployClaimTimeout 时重新领取，否则返回 errAutoDeployInProgress
+func (s *autoDeployService) claim(ctx context.Context, app *domain.Application, m *domain.Manifest, rule *domain.AutoDeployRule) (*domain.AutoDeployRecord, error) {
+	record := &domain.AutoDeployRecord{
+		ApplicationID: app.GetID(),
+		ManifestID:    m.GetID(),
+		ManifestName:  m.Name,
+		Env:           rule.Env,
+		Rule:          rule.Pattern(),
+		Result:        domain.AutoDeployPending,
+	}
+	record.WithCreateDefault()
+	err := mongo.Repo.Create(ctx, record)
+	if err == nil {
+		return record, nil
+	}
+	if !mongoDriver.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	now := time.Now()
+	filter := bson.M{
+		"manifest_id": m.GetID(),
+		"env":         rule.Env,
+		"result":      domain.AutoDeployPending,
+		"updated_at":  bson.M{"$lt": now.Add(-autoDeployClaimTimeout)},
+	}
+	update := bson.M{"$set": bson.M{"rule": rule.Pattern(), "updated_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	reclaimed := &domain.AutoDeployRecord{}
+	err = store.Collection(reclaimed).FindOneAndUpdate(ctx, filter, update, opts).Decode(reclaimed)
+	if err == nil {
+		logging.LoggerWithContext(ctx).Warn("reclaim stale auto deploy record",
+			zap.String("record_id", reclaimed.GetID().Hex()),
+			zap.String("env", rule.Env),
+		)
+		return reclaimed, nil
+	}
+	if !errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, err
+	}
+
+	current := &domain.AutoDeployRecord{}
+	err = store.Collection(current).FindOne(ctx, bson.M{"manifest_id": m.GetID(), "env": rule.Env}).Decode(current)
+	if err != nil {
+		return nil, err
+	}
+	if current.Result == domain.AutoDeployPending {
+		return nil, errAutoDeployInProgress
+	}
+	return nil, nil
+}
+
+// deploy 为命中规则创建 Job 并把结果写入 record；已是该环境 active 的 Manifest 不重复部署。
+// 重新领取的记录先查找上一次占位后已创建的 Job，避免重复部署
+func (s *autoDeployService) deploy(ctx context.Context, app *domain.Application, m *domain.Manifest, rule *domain.AutoDeployRule, record *domain.AutoDeployRecord) error {
+	existing := &model.Job{}
+	err := store.Collection(existing).FindOne(ctx, bson.M{
+		"manifest_id": m.GetID(),
+		"env":         rule.Env,
+		"created_at":  bson.M{"$gte": record.CreatedAt},
+	}).Decode(existing)
+	if err == nil {
+		id := existing.ID
+		record.Result, record.JobID = domain.AutoDeployCreated, &id
+		return nil
+	}
+	if !errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return err
+	}
+
+	activeID, _, err := ApplicationService.ActiveManifest(ctx, app, rule.Env)
+	if err != nil {
+		return err
+	}
+	if activeID != nil && *activeID == m.GetID() {
+		record.Result, record.Reason = domain.AutoDeploySkipped, "manifest already active"
+		return nil
+	}
+
+	job := &model.Job{
+		ApplicationId: app.GetID(),
+		ManifestID:    m.GetID(),
+		Env:           rule.Env,
+		Type:          model.JobUpgrade,
+	}
+	if activeID == nil {
+		job.Type = model.JobInstall
+	}
+
+	id, err := JobService.Create(ctx, job, CreateJobOptions{})
+	switch {
+	case errors.Is(err, ErrVersionDowngrade):
+		record.Result, record.Reason = domain.AutoDeploySkipped, err.Error()
+	case err != nil && id.IsZero():
+		record.Result, record.Reason = domain.AutoDeployFailed, err.Error()
+	default:
+		// Job 已落库但后续同步失败时，失败原因记录在 Job 状态中
+		record.Result, record.JobID = domain.AutoDeployCreated, &id
+		if err != nil {
+			record.Reason = err.Error()
+		}
+	}
+	return nil
+}
+
+// ListRecords 查询 Application 的自动部署审计记录
+func (s *autoDeployService) ListRecords(ctx context.Context, filter primitive.M) ([]domain.AutoDeployRecord, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "list_auto_deploy_records"),
+		zap.Any("filter", filter),
+	)
+
+	var records []domain.AutoDeployRecord
+	if err := mongo.Repo.List(ctx, &domain.AutoDeployRecord{}, filter, &records); err != nil {
+		log.Error("list auto deploy records failed", zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("auto deploy records listed", zap.Int("count", len(records)))
+	return records, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/gitprovider"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var CommitStatusService = NewCommitStatusService()
+
+type commitStatusService struct{}
+
+func NewCommitStatusService() *commitStatusService {
+	return &commitStatusService{}
+}
+
+// HandleEvent outbox 订阅者：Manifest / Job 状态变更时回写 commit status，按记录的当前状态上报；
+// 回写失败返回 error，由 outbox 按退避重试
+func (s *commitStatusService) HandleEvent(ctx context.Context, msg OutboxMessage) error {
+	switch e := msg.Event.(type) {
+	case *events.ManifestStatusChangedEvent:
+		m, err := ManifestService.Get(ctx, e.ManifestID)
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.ReportManifest(ctx, m)
+	case *events.JobStatusChangedEvent:
+		job := &model.Job{}
+		if err := mongo.Repo.FindByID(ctx, job, e.JobID); err != nil {
+			return err
+		}
+		return s.ReportJob(ctx, job)
+	}
+	return nil
+}
+
+// ReportManifest 将 Manifest 构建状态回写到 CommitHash 对应的 commit
+func (s *commitStatusService) ReportManifest(ctx context.Context, m *domain.Manifest) error {
+	state, ok := manifestState(m.Status)
+	if !ok {
+		return nil
+	}
+
+	return s.report(ctx, &gitprovider.CommitStatus{
+		RepoURL:     m.GitRepo,
+		Commit:      m.CommitHash,
+		State:       state,
+		Context:     gitprovider.StatusContext("build"),
+		Description: fmt.Sprintf("Manifest %s %s", m.Name, m.Status),
+		TargetURL:   gitprovider.TargetURL("/api/v1/manifests/" + m.GetID().Hex()),
+	})
+}
+
+// ReportJob 将 Job 部署结果回写到其 Manifest 的 commit
+func (s *commitStatusService) ReportJob(ctx context.Context, job *model.Job) error {
+	state, ok := jobState(job.Status)
+	if !ok || job.ManifestID.IsZero() {
+		return nil
+	}
+
+	m, err := ManifestService.Get(ctx, job.ManifestID)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		logging.LoggerWithContext(ctx).Warn("load manifest for commit status failed",
+			zap.String("job_id", job.ID.Hex()),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return s.report(ctx, &gitprovider.CommitStatus{
+		RepoURL:     m.GitRepo,
+		Commit:      m.CommitHash,
+		State:       state,
+		Context:     gitprovider.StatusContext("deploy/" + job.Env),
+		Description: fmt.Sprintf("%s %s to %s %s", job.Type, m.Name, job.Env, job.Status),
+		TargetURL:   gitprovider.TargetURL("/api/v1/jobs/" + job.ID.Hex()),
+	})
+}
+
+func (s *commitStatusService) report(ctx context.Context, status *gitprovider.CommitStatus) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "report_commit_status"),
+		zap.String("repo", status.RepoURL),
+		zap.String("commit", status.Commit),
+		zap.String("context", status.Context),
+		zap.String("state", string(status.State)),
+	)
+
+	if status.Commit == "" || status.RepoURL == "" {
+		log.Debug("commit status skipped: no commit")
+		return nil
+	}
+
+	if err := gitprovider.Notifier.SetCommitStatus(ctx, status); err != nil {
+		log.Warn("report commit status failed", zap.Error(err))
+		return err
+	}
+	log.Debug("commit status reported")
+	return nil
+}
+
+func manifestState(status model.ManifestStatus) (gitprovider.State, bool) {
+	switch status {
+	case model.ManifestPending, model.ManifestRunning:
+		return gitprovider.StatePending, true
+	case model.ManifestSucceeded:
+		return gitprovider.StateSuccess, true
+	case model.ManifestFailed:
+		return gitprovider.StateFailure, true
+	default:
+		return "", false
+	}
+}
+
+// jobState 仅回写终态，RolledBack 视为部署失败
+func jobState(status model.JobStatus) (gitprovider.State, bool) {
+	switch status {
+	case model.JobSucceeded:
+		return gitprovider.StateSuccess, true
+	case model.JobFailed, model.JobRolledBack:
+		return gitprovider.StateFailure, true
+	case model.JobSyncFailed:
+		return gitprovider.StateError, true
+	default:
+		return "", false
+	}
+}
@@ -2,11 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/bsonger/devflow-common/client/logging"
 	"github.com/bsonger/devflow-common/client/mongo"
 	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/store"
+	"github.com/pmezard/go-difflib/difflib"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongoDriver "go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
@@ -14,18 +22,31 @@ import (
 
 var ConfigurationService = NewConfigurationService()
 
+var (
+	ErrConfigurationNotFound = errors.New("configuration not found")
+	ErrConfigurationConflict = errors.New("configuration modified concurrently, reload and retry")
+	ErrRevisionNotFound      = errors.New("configuration revision not found")
+)
+
 type configurationService struct{}
 
 func NewConfigurationService() *configurationService {
 	return &configurationService{}
 }
 
-func (s *configurationService) Create(ctx context.Context, cfg *model.Configuration) (primitive.ObjectID, error) {
+func (s *configurationService) Create(ctx context.Context, cfg *domain.Configuration) (primitive.ObjectID, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "create_configuration"),
 	)
 
-	if err := mongo.Repo.Create(ctx, cfg); err != nil {
+	cfg.Revision = 1
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := mongo.Repo.Create(ctx, cfg); err != nil {
+			return err
+		}
+		return mongo.Repo.Create(ctx, domain.NewConfigurationRevision(cfg))
+	})
+	if err != nil {
 		log.Error("create configuration failed", zap.Error(err))
 		return primitive.NilObjectID, err
 	}
@@ -34,13 +55,13 @@ func (s *configurationService) Create(ctx context.Context, cfg *model.Configurat
 	return cfg.GetID(), nil
 }
 
-func (s *configurationService) Get(ctx context.Context, id primitive.ObjectID) (*model.Configuration, error) {
+func (s *configurationService) Get(ctx context.Context, id primitive.ObjectID) (*domain.Configuration, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "get_configuration"),
 		zap.String("configuration_id", id.Hex()),
 	)
 
-	cfg := &model.Configuration{}
+	cfg := &domain.Configuration{}
 	if err := mongo.Repo.FindByID(ctx, cfg, id); err != nil {
 		log.Error("get configuration failed", zap.Error(err))
 		return nil, err
@@ -54,32 +75,74 @@ func (s *configurationService) Get(ctx context.Context, id primitive.ObjectID) (
 	return cfg, nil
 }
 
-func (s *configurationService) Update(ctx context.Context, cfg *model.Configuration) error {
+// Update 更新 Configuration，文档更新与新修订记录在同一事务内写入。
+// (configuration_id, revision) 唯一索引保证同一修订号只会写入一次，并发更新时后者返回 ErrConfigurationConflict
+func (s *configurationService) Update(ctx context.Context, cfg *domain.Configuration) error {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "update_configuration"),
 		zap.String("configuration_id", cfg.GetID().Hex()),
 	)
 
-	current := &model.Configuration{}
-	if err := mongo.Repo.FindByID(ctx, current, cfg.GetID()); err != nil {
-		log.Error("load configuration failed", zap.Error(err))
-		return err
-	}
-	if current.DeletedAt != nil {
-		log.Warn("update skipped for deleted configuration")
-		return mongoDriver.ErrNoDocuments
-	}
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		current := &domain.Configuration{}
+		if err := mongo.Repo.FindByID(ctx, current, cfg.GetID()); err != nil {
+			return err
+		}
+		if current.DeletedAt != nil {
+			return mongoDriver.ErrNoDocuments
+		}
+
+		// 以修订号做乐观锁，避免并发更新覆盖；引入修订前创建的配置没有 revision 字段
+		filter := bson.M{"_id": cfg.GetID(), "revision": current.Revision}
+		if current.Revision == 0 {
+			filter["revision"] = bson.M{"$exists": false}
 
-	cfg.CreatedAt = current.CreatedAt
-	cfg.DeletedAt = current.DeletedAt
-	cfg.WithUpdateDefault()
+			// 没有历史的配置先把当前内容记为第 1 个修订，与本次更新同事务写入；
+			// 不支持事务时上次更新可能只写入了该修订，已存在则沿用
+			current.Revision = 1
+			err := mongo.Repo.FindOne(ctx, &domain.ConfigurationRevision{}, bson.M{"configuration_id": current.GetID(), "revision": 1})
+			if errors.Is(err, mongoDriver.ErrNoDocuments) {
+				err = mongo.Repo.Create(ctx, domain.NewConfigurationRevision(current))
+			}
+			if err != nil {
+				return err
+			}
+		}
 
-	if err := mongo.Repo.Update(ctx, cfg); err != nil {
+		cfg.CreatedAt = current.CreatedAt
+		cfg.DeletedAt = current.DeletedAt
+		cfg.Revision = current.Revision + 1
+		cfg.WithUpdateDefault()
+
+		res, err := store.Collection(cfg).UpdateOne(ctx, filter, bson.M{"$set": cfg})
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return ErrConfigurationConflict
+		}
+
+		return mongo.Repo.Create(ctx, domain.NewConfigurationRevision(cfg))
+	})
+	if mongoDriver.IsDuplicateKeyError(err) {
+		err = ErrConfigurationConflict
+	}
+	switch {
+	case errors.Is(err, ErrConfigurationConflict):
+		log.Warn("configuration modified concurrently")
+		return err
+	case errors.Is(err, mongoDriver.ErrNoDocuments):
+		log.Warn("update skipped for missing or deleted configuration")
+		return err
+	case err != nil:
 		log.Error("update configuration failed", zap.Error(err))
 		return err
 	}
 
-	log.Debug("configuration updated", zap.String("configuration_name", cfg.Name))
+	log.Debug("configuration updated",
+		zap.String("configuration_name", cfg.Name),
+		zap.Int("revision", cfg.Revision),
+	)
 	return nil
 }
 
@@ -106,13 +169,43 @@ func (s *configurationService) Delete(ctx context.Context, id primitive.ObjectID
 	return nil
 }
 
-func (s *configurationService) List(ctx context.Context, filter primitive.M) ([]model.Configuration, error) {
+func (s *configurationService) Restore(ctx context.Context, id primitive.ObjectID) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "restore_configuration"),
+		zap.String("configuration_id", id.Hex()),
+	)
+
+	cfg := &domain.Configuration{}
+	if err := mongo.Repo.FindByID(ctx, cfg, id); err != nil {
+		log.Error("get configuration failed", zap.Error(err))
+		return err
+	}
+	if cfg.DeletedAt == nil {
+		log.Warn("restore skipped for active configuration")
+		return ErrResourceNotDeleted
+	}
+
+	update := primitive.M{
+		"$unset": primitive.M{"deleted_at": ""},
+		"$set":   primitive.M{"updated_at": time.Now()},
+	}
+
+	if err := mongo.Repo.UpdateByID(ctx, &model.Configuration{}, id, update); err != nil {
+		log.Error("restore configuration failed", zap.Error(err))
+		return err
+	}
+
+	log.Info("configuration restored")
+	return nil
+}
+
+func (s *configurationService) List(ctx context.Context, filter primitive.M) ([]domain.Configuration, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "list_configurations"),
 		zap.Any("filter", filter),
 	)
 
-	var cfgs []model.Configuration
+	var cfgs []domain.Configuration
 	if err := mongo.Repo.List(ctx, &model.Configuration{}, filter, &cfgs); err != nil {
 		log.Error("list configurations failed", zap.Error(err))
 		return nil, err
@@ -121,3 +214,165 @@ func (s *configurationService) List(ctx context.Context, filter primitive.M) ([]
 	log.Debug("configurations listed", zap.Int("count", len(cfgs)))
 	return cfgs, nil
 }
+
+// Snapshot 读取按环境引用的 Configuration 并复制其内容，供 Manifest 固化
+func (s *configurationService) Snapshot(ctx context.Context, refs map[string][]primitive.ObjectID) (map[string][]domain.ConfigurationSnapshot, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	snapshots := make(map[string][]domain.ConfigurationSnapshot, len(refs))
+	for env, ids := range refs {
+		for _, id := range ids {
+			cfg, err := s.Get(ctx, id)
+			if err != nil {
+				if errors.Is(err, mongoDriver.ErrNoDocuments) {
+					return nil, fmt.Errorf("%w: %s (env %s)", ErrConfigurationNotFound, id.Hex(), env)
+				}
+				return nil, err
+			}
+
+			snapshots[env] = append(snapshots[env], domain.ConfigurationSnapshot{
+				ConfigurationID: cfg.GetID(),
+				Name:            cfg.Name,
+				Revision:        cfg.Revision,
+				Files:           cfg.Files,
+			})
+		}
+	}
+	return snapshots, nil
+}
+
+// ListRevisions 查询 Configuration 的修订历史（新 → 旧）
+func (s *configurationService) ListRevisions(ctx context.Context, id primitive.ObjectID) ([]domain.ConfigurationRevision, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "list_configuration_revisions"),
+		zap.String("configuration_id", id.Hex()),
+	)
+
+	var revisions []domain.ConfigurationRevision
+	if err := mongo.Repo.List(ctx, &domain.ConfigurationRevision{}, bson.M{"configuration_id": id}, &revisions); err != nil {
+		log.Error("list configuration revisions failed", zap.Error(err))
+		return nil, err
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision > revisions[j].Revision
+	})
+
+	log.Debug("configuration revisions listed", zap.Int("count", len(revisions)))
+	return revisions, nil
+}
+
+// GetRevision 查询 Configuration 的指定修订
+func (s *configurationService) GetRevision(ctx context.Context, id primitive.ObjectID, revision int) (*domain.ConfigurationRevision, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "get_configuration_revision"),
+		zap.String("configuration_id", id.Hex()),
+		zap.Int("revision", revision),
+	)
+
+	rev := &domain.ConfigurationRevision{}
+	err := mongo.Repo.FindOne(ctx, rev, bson.M{"configuration_id": id, "revision": revision})
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		log.Warn("configuration revision not found")
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		log.Error("get configuration revision failed", zap.Error(err))
+		return nil, err
+	}
+	return rev, nil
+}
+
+// Diff 生成两个修订之间的 unified diff，按文件名排序输出
+func (s *configurationService) Diff(ctx context.Context, id primitive.ObjectID, from, to int) (string, error) {
+	fromRev, err := s.GetRevision(ctx, id, from)
+	if err != nil {
+		return "", err
+	}
+	toRev, err := s.GetRevision(ctx, id, to)
+	if err != nil {
+		return "", err
+	}
+
+	return diffFiles(
+		fmt.Sprintf("r%d", from), fromRev.Files,
+		fmt.Sprintf("r%d", to), toRev.Files,
+	)
+}
+
+// Revert 以指定修订的内容生成新的修订，历史记录保持不变
+func (s *configurationService) Revert(ctx context.Context, id primitive.ObjectID, revision int) (*domain.Configuration, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "revert_configuration"),
+		zap.String("configuration_id", id.Hex()),
+		zap.Int("target_revision", revision),
+	)
+
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := s.GetRevision(ctx, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	current.Name = rev.Name
+	current.Files = rev.Files
+	if err := s.Update(ctx, current); err != nil {
+		return nil, err
+	}
+
+	log.Info("configuration reverted", zap.Int("revision", current.Revision))
+	return current, nil
+}
+
+func diffFiles(fromLabel string, fromFiles []*model.File, toLabel string, toFiles []*model.File) (string, error) {
+	fromContent := filesByName(fromFiles)
+	toContent := filesByName(toFiles)
+
+	names := make([]string, 0, len(fromContent)+len(toContent))
+	for name := range fromContent {
+		names = append(names, name)
+	}
+	for name := range toContent {
+		if _, ok := fromContent[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	for _, name := range names {
+		a, b := fromContent[name], toContent[name]
+		if a == b {
+			continue
+		}
+
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(a),
+			B:        difflib.SplitLines(b),
+			FromFile: fmt.Sprintf("a/%s (%s)", name, fromLabel),
+			ToFile:   fmt.Sprintf("b/%s (%s)", name, toLabel),
+			Context:  3,
+		})
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(text)
+	}
+	return out.String(), nil
+}
+
+func filesByName(files []*model.File) map[string]string {
+	out := make(map[string]string, len(files))
+	for _, f := range files {
+		if f != nil {
+			out[f.Name] = f.Content
+		}
+	}
+	return out
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	argocommon "github.com/argoproj/argo-cd/v3/common"
+	appv1 "github.com/argoproj/argo-cd/v3/pkg/apis/application/v1alpha1"
+	argodiff "github.com/argoproj/argo-cd/v3/util/argo/diff"
+	"github.com/argoproj/argo-cd/v3/util/argo/normalizers"
+	"github.com/bsonger/devflow-common/client/argo"
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/gitprovider"
+	"github.com/bsonger/devflow/pkg/render"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var DiffService = NewDiffService()
+
+type diffService struct{}
+
+func NewDiffService() *diffService {
+	return &diffService{}
+}
+
+// ManifestRef 参与对比的 Manifest 摘要
+type ManifestRef struct {
+	ID         primitive.ObjectID `json:"id"`
+	Name       string             `json:"name"`
+	Version    string             `json:"version,omitempty"`
+	CommitHash string             `json:"commit_hash,omitempty"`
+	Digest     string             `json:"digest,omitempty"`
+}
+
+// FieldChange 结构化字段差异，From / To 为 nil 表示该侧不存在
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// CommitRange active 与候选 Manifest 之间的提交范围
+type CommitRange struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	CompareURL string `json:"compare_url,omitempty"`
+}
+
+// LiveDiff 集群中实际资源与候选渲染结果的差异
+type LiveDiff struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	SyncStatus string `json:"sync_status,omitempty"`
+	Health     string `json:"health,omitempty"`
+	Diff       string `json:"diff,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ManifestDiff active Manifest 与候选 Manifest 的差异；Active 为空表示尚未部署
+type ManifestDiff struct {
+	Env       string        `json:"env"`
+	Active    *ManifestRef  `json:"active,omitempty"`
+	Candidate ManifestRef   `json:"candidate"`
+	Fields    []FieldChange `json:"fields"`
+	Commits   *CommitRange  `json:"commits,omitempty"`
+	Rendered  string        `json:"rendered"`
+	Live      []LiveDiff    `json:"live,omitempty"`
+}
+
+// Compare 对比 Application 当前 active Manifest 与候选 Manifest；live 为 true 时附带集群实际状态的差异
+func (s *diffService) Compare(ctx context.Context, appID, candidateID primitive.ObjectID, env string, live bool) (*ManifestDiff, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "diff_manifest"),
+		zap.String("application_id", appID.Hex()),
+		zap.String("candidate_id", candidateID.Hex()),
+		zap.String("env", env),
+	)
+
+	app, err := ApplicationService.Get(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := ManifestService.Get(ctx, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate.ApplicationId != appID {
+		return nil, ErrManifestNotForApplication
+	}
+
+	if env == "" {
+		env = defaultEnv
+	}
+	if err := domain.ValidateEnv(env); err != nil {
+		return nil, err
+	}
+	activeID, _, err := ApplicationService.ActiveManifest(ctx, app, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// 环境未部署时以空 Manifest 对比，所有字段都视为新增
+	active := &domain.Manifest{}
+	diff := &ManifestDiff{Env: env, Candidate: manifestRef(candidate)}
+	if activeID != nil {
+		active, err = ManifestService.Get(ctx, *activeID)
+		if err != nil {
+			log.Error("get active manifest failed", zap.Error(err))
+			return nil, err
+		}
+		ref := manifestRef(active)
+		diff.Active = &ref
+	}
+
+	diff.Fields = diffFields(active, candidate, env)
+	if active.CommitHash != "" && candidate.CommitHash != "" && active.CommitHash != candidate.CommitHash {
+		diff.Commits = &CommitRange{
+			From:       active.CommitHash,
+			To:         candidate.CommitHash,
+			CompareURL: gitprovider.CompareURL(candidate.GitRepo, active.CommitHash, candidate.CommitHash),
+		}
+	}
+
+	secrets, err := SecretService.Refs(ctx, app, env)
+	if err != nil {
+		return nil, err
+	}
+	candidateBundle, err := render.Render(app, candidate, env, secrets...)
+	if err != nil {
+		return nil, err
+	}
+	candidateFiles, err := bundleFiles(candidateBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	var activeFiles []*model.File
+	if diff.Active != nil {
+		activeBundle, err := render.Render(app, active, env, secrets...)
+		if err != nil {
+			return nil, err
+		}
+		if activeFiles, err = bundleFiles(activeBundle); err != nil {
+			return nil, err
+		}
+	}
+
+	diff.Rendered, err = diffFiles(activeLabel(diff.Active), activeFiles, candidate.Name, candidateFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	if live {
+		diff.Live = s.liveDiff(ctx, app, env, candidateBundle)
+	}
+
+	log.Debug("manifest diff computed", zap.Int("fields", len(diff.Fields)))
+	return diff, nil
+}
+
+// liveDiff 按 Argo CD 的对比逻辑（StateDiff：ignoreDifferences / 已知类型归一化、基于 last-applied-configuration 的三方合并）
+// 计算候选渲染结果应用到集群后的预测状态，与归一化后的实际状态做 unified diff，并附带 Argo CD 记录的同步 / 健康状态
+func (s *diffService) liveDiff(ctx context.Context, app *domain.Application, env string, candidate *render.Bundle) []LiveDiff {
+	name := domain.ArgoAppName(app.Name, env)
+	log := logging.LoggerWithContext(ctx).With(zap.String("application", name))
+
+	statuses := map[string]LiveDiff{}
+	var ignores []appv1.ResourceIgnoreDifferences
+	argoApp, err := argo.ArgoCdClient.ArgoprojV1alpha1().Applications(argoNamespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		ignores = argoApp.Spec.IgnoreDifferences
+		for _, r := range argoApp.Status.Resources {
+			d := LiveDiff{SyncStatus: string(r.Status)}
+			if r.Health != nil {
+				d.Health = string(r.Health.Status)
+			}
+			statuses[r.Kind+"/"+r.Name] = d
+		}
+	} else if !k8sErrors.IsNotFound(err) {
+		log.Warn("get argo application failed", zap.Error(err))
+	}
+
+	out := make([]LiveDiff, 0, len(candidate.Resources))
+	diffConfig, err := argodiff.NewDiffConfigBuilder().
+		WithDiffSettings(ignores, nil, false, normalizers.IgnoreNormalizerOpts{}).
+		WithTracking(argocommon.LabelKeyAppInstance, string(appv1.TrackingMethodAnnotation)).
+		WithNoCache().
+		Build()
+	if err != nil {
+		log.Error("build argo diff config failed", zap.Error(err))
+		for _, r := range candidate.Resources {
+			d := statuses[r.Kind+"/"+r.Name]
+			d.Kind, d.Name, d.Error = r.Kind, r.Name, err.Error()
+			out = append(out, d)
+		}
+		return out
+	}
+
+	for _, r := range candidate.Resources {
+		d := statuses[r.Kind+"/"+r.Name]
+		d.Kind, d.Name = r.Kind, r.Name
+		if err := s.diffResource(ctx, r, diffConfig, &d); err != nil {
+			d.Error = err.Error()
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// diffResource 计算单个资源的 live diff；资源不存在时 live 一侧为空
+func (s *diffService) diffResource(ctx context.Context, r render.Resource, diffConfig argodiff.DiffConfig, d *LiveDiff) error {
+	target, err := toUnstructured(r.Object)
+	if err != nil {
+		return err
+	}
+
+	live, err := getLiveObject(ctx, target)
+	if k8sErrors.IsNotFound(err) {
+		live, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := argodiff.StateDiff(live, target, diffConfig)
+	if err != nil {
+		return err
+	}
+	if !res.Modified {
+		return nil
+	}
+
+	current, err := stateYAML(res.NormalizedLive)
+	if err != nil {
+		return err
+	}
+	predicted, err := stateYAML(res.PredictedLive)
+	if err != nil {
+		return err
+	}
+
+	name := strings.ToLower(r.Kind) + "-" + r.Name + ".yaml"
+	d.Diff, err = diffFiles(
+		"live", []*model.File{{Name: name, Content: current}},
+		"candidate", []*model.File{{Name: name, Content: predicted}},
+	)
+	return err
+}
+
+func diffFields(from, to *domain.Manifest, env string) []FieldChange {
+	fields := []struct {
+		name     string
+		from, to any
+	}{
+		{"digest", from.Digest, to.Digest},
+		{"commit_hash", from.CommitHash, to.CommitHash},
+		{"version", from.Version, to.Version},
+		{"replica", derefInt32(from.Replica), derefInt32(to.Replica)},
+		{"envs." + env, from.Envs[env], to.Envs[env]},
+		{"config_maps", configMapValues(from.ConfigMaps), configMapValues(to.ConfigMaps)},
+		{"configurations." + env, from.GetConfigurations(env), to.GetConfigurations(env)},
+		{"service.ports", from.Service.Ports, to.Service.Ports},
+		{"internet", from.Internet, to.Internet},
+	}
+
+	changes := make([]FieldChange, 0)
+	for _, f := range fields {
+		if isEmptyValue(f.from) && isEmptyValue(f.to) {
+			continue
+		}
+		if reflect.DeepEqual(f.from, f.to) {
+			continue
+		}
+		change := FieldChange{Field: f.name, From: f.from, To: f.to}
+		if isEmptyValue(f.from) {
+			change.From = nil
+		}
+		if isEmptyValue(f.to) {
+			change.To = nil
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func isEmptyValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
+
+func derefInt32(v *int32) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// configMapValues 去掉指针并按名称排序，保证比较与输出稳定
+func configMapValues(cms []*model.ConfigMap) []model.ConfigMap {
+	out := make([]model.ConfigMap, 0, len(cms))
+	for _, cm := range cms {
+		if cm != nil {
+			out = append(out, *cm)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func manifestRef(m *domain.Manifest) ManifestRef {
+	return ManifestRef{
+		ID:         m.GetID(),
+		Name:       m.Name,
+		Version:    m.Version,
+		CommitHash: m.CommitHash,
+		Digest:     m.Digest,
+	}
+}
+
+func activeLabel(ref *ManifestRef) string {
+	if ref == nil {
+		return "none"
+	}
+	return ref.Name
+}
+
+// bundleFiles 渲染结果转为文件列表，复用 Configuration 的 unified diff
+func bundleFiles(b *render.Bundle) ([]*model.File, error) {
+	files, err := b.Files()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.File, 0, len(files))
+	for name, data := range files {
+		out = append(out, &model.File{Name: name, Content: string(data)})
+	}
+	return out, nil
+}
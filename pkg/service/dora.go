@@ -0,0 +1,441 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const (
+	// doraExportWindow Prometheus 导出的统计窗口
+	doraExportWindow = 30 * 24 * time.Hour
+	// doraExportInterval 导出数据的刷新周期
+	doraExportInterval = 5 * time.Minute
+)
+
+// DoraBucket 时间桶粒度
+type DoraBucket string
+
+const (
+	DoraDay   DoraBucket = "day"
+	DoraWeek  DoraBucket = "week"
+	DoraMonth DoraBucket = "month"
+)
+
+var ErrInvalidDoraQuery = errors.New("invalid dora query")
+
+// DoraQuery 统计范围，Project / Env 为空表示不限制
+type DoraQuery struct {
+	Project string
+	Env     string
+	From    time.Time
+	To      time.Time
+	Bucket  DoraBucket
+}
+
+// DoraMetrics 四项 DORA 指标。
+// 部署指 Install / Upgrade Job；Failed、SyncFailed、RolledBack 视为失败部署；
+// 前置时间为部署成功时间与 Manifest 创建时间之差；恢复时间为失败部署到同一 Application / 环境下一次成功 Job（含 Rollback）的间隔
+type DoraMetrics struct {
+	Deployments       int `json:"deployments"`
+	FailedDeployments int `json:"failed_deployments"`
+	// DeploymentFrequency 平均每天成功部署次数
+	DeploymentFrequency float64 `json:"deployment_frequency"`
+	// LeadTimeSeconds 前置时间中位数
+	LeadTimeSeconds   float64 `json:"lead_time_seconds"`
+	ChangeFailureRate float64 `json:"change_failure_rate"`
+	// TimeToRestoreSeconds 平均恢复时间，Restores 为已恢复的失败次数
+	TimeToRestoreSeconds float64 `json:"time_to_restore_seconds"`
+	Restores             int     `json:"restores"`
+}
+
+type DoraBucketMetrics struct {
+	Start time.Time `json:"start"`
+	DoraMetrics
+}
+
+// ApplicationDora 单个 Application 在单个环境的指标
+type ApplicationDora struct {
+	ApplicationID   primitive.ObjectID  `json:"application_id"`
+	ApplicationName string              `json:"application_name"`
+	ProjectName     string              `json:"project_name"`
+	Env             string              `json:"env"`
+	Summary         DoraMetrics         `json:"summary"`
+	Buckets         []DoraBucketMetrics `json:"buckets"`
+}
+
+type DoraReport struct {
+	Project      string              `json:"project,omitempty"`
+	Env          string              `json:"env,omitempty"`
+	From         time.Time           `json:"from"`
+	To           time.Time           `json:"to"`
+	Bucket       DoraBucket          `json:"bucket"`
+	Summary      DoraMetrics         `json:"summary"`
+	Buckets      []DoraBucketMetrics `json:"buckets"`
+	Applications []ApplicationDora   `json:"applications"`
+}
+
+var DoraService = NewDoraService()
+
+type doraService struct {
+	// exported 最近一次导出窗口的统计结果，供 Prometheus gauge 读取
+	exported atomic.Pointer[DoraReport]
+}
+
+func NewDoraService() *doraService {
+	s := &doraService{}
+	s.registerGauges()
+	return s
+}
+
+// Compute 统计 [From, To) 内结束的 Job
+func (s *doraService) Compute(ctx context.Context, q DoraQuery) (*DoraReport, error) {
+	if !q.From.Before(q.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidDoraQuery)
+	}
+	switch q.Bucket {
+	case "":
+		q.Bucket = DoraWeek
+	case DoraDay, DoraWeek, DoraMonth:
+	default:
+		return nil, fmt.Errorf("%w: bucket must be day, week or month", ErrInvalidDoraQuery)
+	}
+
+	// 恢复可能发生在 To 之后，因此不限制上界
+	filter := primitive.M{
+		"deleted_at": primitive.M{"$exists": false},
+		"updated_at": primitive.M{"$gte": q.From},
+		"status": primitive.M{"$in": []model.JobStatus{
+			model.JobSucceeded, model.JobFailed, model.JobSyncFailed, model.JobRolledBack,
+		}},
+		"type": primitive.M{"$in": []string{model.JobInstall, model.JobUpgrade, model.JobRollback}},
+	}
+	if q.Project != "" {
+		filter["project_name"] = q.Project
+	}
+	if q.Env != "" {
+		filter["env"] = q.Env
+	}
+
+	var jobs []model.Job
+	if err := mongo.Repo.List(ctx, &model.Job{}, filter, &jobs); err != nil {
+		return nil, err
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].UpdatedAt.Before(jobs[j].UpdatedAt) })
+
+	manifestCreated, err := s.manifestCreatedAt(ctx, jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DoraReport{Project: q.Project, Env: q.Env, From: q.From, To: q.To, Bucket: q.Bucket}
+	total := newDoraAccumulator(q)
+
+	type groupKey struct {
+		app primitive.ObjectID
+		env string
+	}
+	groups := map[groupKey]*doraAccumulator{}
+	var order []groupKey
+	// pendingFailure 每个 Application / 环境尚未恢复的最早失败时间
+	pendingFailure := map[groupKey]time.Time{}
+
+	for i := range jobs {
+		job := &jobs[i]
+		key := groupKey{app: job.ApplicationId, env: job.Env}
+		finished := job.UpdatedAt
+
+		if start, ok := pendingFailure[key]; ok && job.Status == model.JobSucceeded {
+			delete(pendingFailure, key)
+			if start.Before(q.To) {
+				restore := finished.Sub(start).Seconds()
+				total.restore(start, restore)
+				groups[key].restore(start, restore)
+			}
+		}
+		if !finished.Before(q.To) || job.Type == model.JobRollback {
+			continue
+		}
+
+		acc, ok := groups[key]
+		if !ok {
+			acc = newDoraAccumulator(q)
+			acc.app = ApplicationDora{
+				ApplicationID:   job.ApplicationId,
+				ApplicationName: job.ApplicationName,
+				ProjectName:     job.ProjectName,
+				Env:             job.Env,
+			}
+			groups[key] = acc
+			order = append(order, key)
+		}
+
+		if job.Status == model.JobSucceeded {
+			var leadTime float64 = -1
+			if created, ok := manifestCreated[job.ManifestID]; ok {
+				leadTime = finished.Sub(created).Seconds()
+			}
+			total.deploy(finished, leadTime)
+			acc.deploy(finished, leadTime)
+			continue
+		}
+
+		total.fail(finished)
+		acc.fail(finished)
+		if _, ok := pendingFailure[key]; !ok {
+			pendingFailure[key] = finished
+		}
+	}
+
+	report.Summary, report.Buckets = total.finish()
+	report.Applications = make([]ApplicationDora, 0, len(order))
+	for _, key := range order {
+		acc := groups[key]
+		acc.app.Summary, acc.app.Buckets = acc.finish()
+		report.Applications = append(report.Applications, acc.app)
+	}
+	return report, nil
+}
+
+func (s *doraService) manifestCreatedAt(ctx context.Context, jobs []model.Job) (map[primitive.ObjectID]time.Time, error) {
+	ids := make([]primitive.ObjectID, 0, len(jobs))
+	seen := map[primitive.ObjectID]bool{}
+	for i := range jobs {
+		id := jobs[i].ManifestID
+		if id.IsZero() || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	created := make(map[primitive.ObjectID]time.Time, len(ids))
+	if len(ids) == 0 {
+		return created, nil
+	}
+
+	var manifests []domain.Manifest
+	if err := mongo.Repo.List(ctx, &domain.Manifest{}, primitive.M{"_id": primitive.M{"$in": ids}}, &manifests); err != nil {
+		return nil, err
+	}
+	for i := range manifests {
+		created[manifests[i].GetID()] = manifests[i].CreatedAt
+	}
+	return created, nil
+}
+
+// doraAccumulator 累计一组 Job 的原始数据，finish 时计算指标
+type doraAccumulator struct {
+	q       DoraQuery
+	app     ApplicationDora
+	all     doraSample
+	buckets map[time.Time]*doraSample
+}
+
+type doraSample struct {
+	deployments int
+	failed      int
+	leadTimes   []float64
+	restores    []float64
+}
+
+func newDoraAccumulator(q DoraQuery) *doraAccumulator {
+	return &doraAccumulator{q: q, buckets: map[time.Time]*doraSample{}}
+}
+
+func (a *doraAccumulator) bucket(t time.Time) *doraSample {
+	start := bucketStart(t, a.q.Bucket)
+	b, ok := a.buckets[start]
+	if !ok {
+		b = &doraSample{}
+		a.buckets[start] = b
+	}
+	return b
+}
+
+// deploy leadTime < 0 表示 Manifest 已被清理，不计入前置时间
+func (a *doraAccumulator) deploy(at time.Time, leadTime float64) {
+	for _, s := range []*doraSample{&a.all, a.bucket(at)} {
+		s.deployments++
+		if leadTime >= 0 {
+			s.leadTimes = append(s.leadTimes, leadTime)
+		}
+	}
+}
+
+func (a *doraAccumulator) fail(at time.Time) {
+	a.all.failed++
+	a.bucket(at).failed++
+}
+
+// restore 恢复时间计入失败发生的时间桶
+func (a *doraAccumulator) restore(failedAt time.Time, seconds float64) {
+	a.all.restores = append(a.all.restores, seconds)
+	b := a.bucket(failedAt)
+	b.restores = append(b.restores, seconds)
+}
+
+// finish 计算汇总与按时间排序的各桶指标，没有数据的桶也会输出
+func (a *doraAccumulator) finish() (DoraMetrics, []DoraBucketMetrics) {
+	summary := a.all.metrics(a.q.To.Sub(a.q.From))
+
+	var buckets []DoraBucketMetrics
+	for start := bucketStart(a.q.From, a.q.Bucket); start.Before(a.q.To); start = nextBucket(start, a.q.Bucket) {
+		from, to := start, nextBucket(start, a.q.Bucket)
+		if from.Before(a.q.From) {
+			from = a.q.From
+		}
+		if to.After(a.q.To) {
+			to = a.q.To
+		}
+
+		sample := a.buckets[start]
+		if sample == nil {
+			sample = &doraSample{}
+		}
+		buckets = append(buckets, DoraBucketMetrics{Start: start, DoraMetrics: sample.metrics(to.Sub(from))})
+	}
+	return summary, buckets
+}
+
+func (s *doraSample) metrics(span time.Duration) DoraMetrics {
+	m := DoraMetrics{
+		Deployments:       s.deployments,
+		FailedDeployments: s.failed,
+		Restores:          len(s.restores),
+		LeadTimeSeconds:   median(s.leadTimes),
+	}
+	if days := span.Hours() / 24; days > 0 {
+		m.DeploymentFrequency = float64(s.deployments) / days
+	}
+	if finished := s.deployments + s.failed; finished > 0 {
+		m.ChangeFailureRate = float64(s.failed) / float64(finished)
+	}
+	if len(s.restores) > 0 {
+		var sum float64
+		for _, v := range s.restores {
+			sum += v
+		}
+		m.TimeToRestoreSeconds = sum / float64(len(s.restores))
+	}
+	return m
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// bucketStart 时间桶起点（UTC），周从周一开始
+func bucketStart(t time.Time, b DoraBucket) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch b {
+	case DoraDay:
+		return day
+	case DoraMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	}
+}
+
+func nextBucket(start time.Time, b DoraBucket) time.Time {
+	switch b {
+	case DoraDay:
+		return start.AddDate(0, 0, 1)
+	case DoraMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 7)
+	}
+}
+
+// registerGauges 以最近 30 天为窗口，按 project / application / env 导出四项指标
+func (s *doraService) registerGauges() {
+	meter := otel.Meter(ServiceName)
+
+	frequency, err1 := meter.Float64ObservableGauge("devflow.dora.deployment_frequency",
+		metric.WithDescription("Successful deployments per day over the last 30 days"))
+	leadTime, err2 := meter.Float64ObservableGauge("devflow.dora.lead_time",
+		metric.WithDescription("Median lead time from manifest creation to successful deployment over the last 30 days"),
+		metric.WithUnit("s"))
+	failureRate, err3 := meter.Float64ObservableGauge("devflow.dora.change_failure_rate",
+		metric.WithDescription("Ratio of failed deployments over the last 30 days"))
+	restore, err4 := meter.Float64ObservableGauge("devflow.dora.time_to_restore",
+		metric.WithDescription("Mean time to restore after a failed deployment over the last 30 days"),
+		metric.WithUnit("s"))
+	if err := errors.Join(err1, err2, err3, err4); err != nil {
+		return
+	}
+
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		report := s.exported.Load()
+		if report == nil {
+			return nil
+		}
+		for i := range report.Applications {
+			app := &report.Applications[i]
+			attrs := metric.WithAttributes(
+				attribute.String("project", app.ProjectName),
+				attribute.String("application", app.ApplicationName),
+				attribute.String("env", app.Env),
+			)
+			o.ObserveFloat64(frequency, app.Summary.DeploymentFrequency, attrs)
+			o.ObserveFloat64(leadTime, app.Summary.LeadTimeSeconds, attrs)
+			o.ObserveFloat64(failureRate, app.Summary.ChangeFailureRate, attrs)
+			o.ObserveFloat64(restore, app.Summary.TimeToRestoreSeconds, attrs)
+		}
+		return nil
+	}, frequency, leadTime, failureRate, restore)
+}
+
+// StartDoraExporter 周期性刷新导出到 Prometheus 的 DORA 指标，ctx 取消后退出
+func StartDoraExporter(ctx context.Context) {
+	log := logging.Logger.With(zap.String("component", "dora_exporter"))
+	log.Info("dora exporter started", zap.Duration("interval", doraExportInterval))
+
+	goWorker(func() {
+		ticker := time.NewTicker(doraExportInterval)
+		defer ticker.Stop()
+
+		for {
+			now := time.Now()
+			report, err := DoraService.Compute(ctx, DoraQuery{From: now.Add(-doraExportWindow), To: now, Bucket: DoraMonth})
+			if err != nil {
+				log.Warn("compute dora metrics failed", zap.Error(err))
+			} else {
+				DoraService.exported.Store(report)
+			}
+
+			select {
+			case <-ctx.Done():
+				log.Info("dora exporter stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	appv1 "github.com/argoproj/argo-cd/v3/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/health"
+	"github.com/bsonger/devflow-common/client/argo"
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/client/tekton"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/render"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const defaultDriftInterval = 5 * time.Minute
+
+var DriftService = NewDriftService()
+
+type driftService struct {
+	// drifted 最近一次扫描结果，application 名称 → 是否漂移，供 Prometheus gauge 读取
+	drifted sync.Map
+}
+
+func NewDriftService() *driftService {
+	s := &driftService{}
+
+	gauge, err := otel.Meter(ServiceName).Int64ObservableGauge(
+		"devflow.application.drift",
+		metric.WithDescription("1 when the live state of the application drifts from its active manifest"),
+	)
+	if err == nil {
+		_, _ = otel.Meter(ServiceName).RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			s.drifted.Range(func(k, v any) bool {
+				var value int64
+				if v.(bool) {
+					value = 1
+				}
+				o.ObserveInt64(gauge, value, metric.WithAttributes(attribute.String("application", k.(string))))
+				return true
+			})
+			return nil
+		}, gauge)
+	}
+	return s
+}
+
+// StartDriftScanner 周期性扫描已部署 Application 的漂移，autoResync 为 true 时新发现的漂移会创建重新同步的 Job
+func StartDriftScanner(ctx context.Context, interval time.Duration, autoResync bool) {
+	log := logging.Logger.With(zap.String("component", "drift_scanner"))
+
+	if interval <= 0 {
+		interval = defaultDriftInterval
+	}
+
+	log.Info("drift scanner started",
+		zap.Duration("interval", interval),
+		zap.Bool("auto_resync", autoResync),
+	)
+
+	goWorker(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info("drift scanner stopped")
+				return
+			case <-ticker.C:
+				DriftService.Scan(ctx, autoResync)
+			}
+		}
+	})
+}
+
+// Scan 对所有已部署的 Application 检测一次漂移
+func (s *driftService) Scan(ctx context.Context, autoResync bool) {
+	log := logging.LoggerWithContext(ctx).With(zap.String("operation", "scan_drift"))
+
+	apps, err := ApplicationService.List(ctx, primitive.M{
+		"deleted_at":         primitive.M{"$exists": false},
+		"active_manifest_id": primitive.M{"$exists": true},
+	})
+	if err != nil {
+		log.Error("list applications failed", zap.Error(err))
+		return
+	}
+
+	for i := range apps {
+		if err := s.check(ctx, &apps[i], autoResync); err != nil {
+			log.Warn("check drift failed", zap.String("application", apps[i].Name), zap.Error(err))
+		}
+	}
+}
+
+func (s *driftService) check(ctx context.Context, app *domain.Application, autoResync bool) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "check_drift"),
+		zap.String("application", app.Name),
+	)
+
+	argoApp, err := argo.ArgoCdClient.ArgoprojV1alpha1().Applications(argoNamespace).Get(ctx, app.Name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		s.drifted.Delete(app.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// 有 Job 正在同步时跳过，避免把部署过程误判为漂移
+	if op := argoApp.Status.OperationState; op != nil && !op.Phase.Completed() {
+		return nil
+	}
+
+	active, err := ManifestService.Get(ctx, *app.ActiveManifestID)
+	if err != nil {
+		return err
+	}
+
+	event := &domain.DriftEvent{
+		ApplicationID:   app.GetID(),
+		ApplicationName: app.Name,
+		ManifestID:      active.GetID(),
+		ManifestName:    active.Name,
+		SyncStatus:      string(argoApp.Status.Sync.Status),
+		Health:          string(argoApp.Status.Health.Status),
+		ExpectedImage:   render.Image(active),
+	}
+
+	if argoApp.Status.Sync.Status == appv1.SyncStatusCodeOutOfSync {
+		event.Reasons = append(event.Reasons, "argo cd reports OutOfSync")
+	}
+	switch argoApp.Status.Health.Status {
+	case health.HealthStatusHealthy, health.HealthStatusProgressing, "":
+	default:
+		event.Reasons = append(event.Reasons, fmt.Sprintf("argo cd reports health %s", argoApp.Status.Health.Status))
+	}
+
+	mismatched, err := s.mismatchedImages(ctx, app, active, event.ExpectedImage)
+	if err != nil {
+		log.Warn("inspect live images failed", zap.Error(err))
+	}
+	if len(mismatched) > 0 {
+		event.LiveImages = mismatched
+		event.Reasons = append(event.Reasons, "live images differ from active manifest")
+	}
+
+	drifted := len(event.Reasons) > 0
+	s.drifted.Store(app.Name, drifted)
+	return s.record(ctx, event, drifted, autoResync)
+}
+
+// mismatchedImages 返回与 active Manifest 不一致的 Pod 镜像；Manifest 有 digest 时按 imageID 的 digest 比较
+func (s *driftService) mismatchedImages(ctx context.Context, app *domain.Application, active *domain.Manifest, expected string) ([]string, error) {
+	deploy, err := tekton.KubeClient.AppsV1().Deployments(app.ProjectName).Get(ctx, app.Name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := tekton.KubeClient.CoreV1().Pods(app.ProjectName).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatched []string
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != app.Name {
+				continue
+			}
+			ok := cs.Image == expected
+			if active.Digest != "" {
+				ok = strings.HasSuffix(cs.ImageID, active.Digest)
+			}
+			if !ok {
+				mismatched = append(mismatched, fmt.Sprintf("%s: %s", pod.Name, cs.ImageID))
+			}
+		}
+	}
+	return mismatched, nil
+}
+
+// record 漂移开始时新建事件，持续漂移时刷新 last_seen_at，恢复时关闭事件
+func (s *driftService) record(ctx context.Context, event *domain.DriftEvent, drifted, autoResync bool) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "record_drift"),
+		zap.String("application", event.ApplicationName),
+	)
+
+	now := time.Now()
+	open := &domain.DriftEvent{}
+	err := mongo.Repo.FindOne(ctx, open, primitive.M{
+		"application_id": event.ApplicationID,
+		"resolved_at":    primitive.M{"$exists": false},
+	})
+	if err != nil && !errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return err
+	}
+	hasOpen := err == nil
+
+	switch {
+	case drifted && hasOpen:
+		return mongo.Repo.UpdateByID(ctx, &domain.DriftEvent{}, open.GetID(), primitive.M{"$set": primitive.M{
+			"sync_status":  event.SyncStatus,
+			"health":       event.Health,
+			"live_images":  event.LiveImages,
+			"reasons":      event.Reasons,
+			"open":         true,
+			"last_seen_at": now,
+			"updated_at":   now,
+		}})
+
+	case drifted:
+		event.Open = true
+		event.LastSeenAt = now
+		event.WithCreateDefault()
+		if err := mongo.Repo.Create(ctx, event); err != nil {
+			if mongoDriver.IsDuplicateKeyError(err) {
+				// 其他实例已记录本次漂移并负责 resync
+				log.Debug("drift already recorded by another instance")
+				return nil
+			}
+			return err
+		}
+		log.Warn("drift detected", zap.Strings("reasons", event.Reasons))
+
+		if autoResync {
+			s.resync(ctx, event)
+		}
+		return nil
+
+	case hasOpen:
+		log.Info("drift resolved", zap.String("drift_event_id", open.GetID().Hex()))
+		return mongo.Repo.UpdateByID(ctx, &domain.DriftEvent{}, open.GetID(), primitive.M{
+			"$set": primitive.M{
+				"resolved_at": now,
+				"updated_at":  now,
+			},
+			"$unset": primitive.M{"open": ""},
+		})
+	}
+	return nil
+}
+
+// resync 以 active Manifest 重新部署到默认环境
+func (s *driftService) resync(ctx context.Context, event *domain.DriftEvent) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "drift_resync"),
+		zap.String("application", event.ApplicationName),
+	)
+
+	job := &model.Job{
+		ApplicationId: event.ApplicationID,
+		ManifestID:    event.ManifestID,
+		Type:          model.JobUpgrade,
+	}
+
+	id, err := JobService.Create(ctx, job, CreateJobOptions{})
+	if id.IsZero() {
+		log.Error("create resync job failed", zap.Error(err))
+		return
+	}
+	if err != nil {
+		// Job 已落库但同步失败，失败原因记录在 Job 状态中，仍关联到漂移事件
+		log.Error("sync resync job failed", zap.String("job_id", id.Hex()), zap.Error(err))
+	}
+
+	if err := mongo.Repo.UpdateByID(ctx, &domain.DriftEvent{}, event.GetID(), primitive.M{"$set": primitive.M{"resync_job_id": id}}); err != nil {
+		log.Error("record resync job failed", zap.Error(err))
+	}
+	log.Info("resync job created", zap.String("job_id", id.Hex()))
+}
+
+// ListEvents 查询漂移事件
+func (s *driftService) ListEvents(ctx context.Context, filter primitive.M) ([]domain.DriftEvent, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "list_drift_events"),
+		zap.Any("filter", filter),
+	)
+
+	var events []domain.DriftEvent
+	if err := mongo.Repo.List(ctx, &domain.DriftEvent{}, filter, &events); err != nil {
+		log.Error("list drift events failed", zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("drift events listed", zap.Int("count", len(events)))
+	return events, nil
+}
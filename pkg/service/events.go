@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	// streamTailInterval 扫描 outbox 新事件的周期，本实例写入的事件会立即唤醒
+	streamTailInterval = time.Second
+	// streamTailLookback 回看窗口：事务提交晚于 created_at 的事件（含其他实例写入的）仍能被扫描到
+	streamTailLookback = 30 * time.Second
+)
+
+var streamWake = make(chan struct{}, 1)
+
+// StartEventStreamer 每个实例独立 tail outbox_events 并发布到本地事件总线，供 /events/stream 推送；
+// 不作为分发器订阅者，否则只有领取到事件的实例能推送给自己的连接
+func StartEventStreamer(ctx context.Context) {
+	log := logging.Logger.With(zap.String("component", "event_streamer"))
+	log.Info("event streamer started")
+
+	goWorker(func() {
+		ticker := time.NewTicker(streamTailInterval)
+		defer ticker.Stop()
+
+		started := time.Now()
+		seen := map[primitive.ObjectID]time.Time{}
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info("event streamer stopped")
+				return
+			case <-ticker.C:
+			case <-streamWake:
+			}
+			if err := tailOutbox(ctx, started, seen); err != nil {
+				log.Warn("tail outbox events failed", zap.Error(err))
+			}
+		}
+	})
+}
+
+// tailOutbox 发布回看窗口内尚未发布过的 outbox 事件；started 之前的事件不再推送
+func tailOutbox(ctx context.Context, started time.Time, seen map[primitive.ObjectID]time.Time) error {
+	since := time.Now().Add(-streamTailLookback)
+	for id, createdAt := range seen {
+		if createdAt.Before(since) {
+			delete(seen, id)
+		}
+	}
+	if since.Before(started) {
+		since = started
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := store.Collection(&domain.OutboxEvent{}).Find(ctx, bson.M{"created_at": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		rec := &domain.OutboxEvent{}
+		if err := cur.Decode(rec); err != nil {
+			return err
+		}
+		if _, ok := seen[rec.ID]; ok {
+			continue
+		}
+		seen[rec.ID] = rec.CreatedAt
+		streamEvent(ctx, rec)
+	}
+	return cur.Err()
+}
+
+// streamEvent 将 outbox 事件转发到进程内事件总线
+func streamEvent(ctx context.Context, rec *domain.OutboxEvent) {
+	ev, err := events.NewDomainEvent(rec.Type)
+	if err == nil {
+		err = bson.Unmarshal(rec.Payload, ev)
+	}
+	if err != nil {
+		logging.LoggerWithContext(ctx).Warn("decode outbox event failed",
+			zap.String("event_id", rec.ID.Hex()),
+			zap.Error(err),
+		)
+		return
+	}
+
+	e := events.FromDomainEvent(rec.ID.Hex(), rec.CreatedAt, ev)
+	e.Project = applicationProject(ctx, rec.ApplicationID)
+	events.Publish(e)
+}
+
+// applicationProject 查询 Application 所属项目，用于事件流按项目过滤；查询失败时返回空，受项目限制的订阅者不会收到该事件
+func applicationProject(ctx context.Context, id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	app := &model.Application{}
+	if err := mongo.Repo.FindByID(ctx, app, id); err != nil {
+		return ""
+	}
+	return app.ProjectName
+}
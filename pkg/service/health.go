@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/argo"
+	"github.com/bsonger/devflow-common/client/tekton"
+	"github.com/bsonger/devflow/pkg/store"
+)
+
+// dependencyCheckTimeout 单个依赖检查超时
+const dependencyCheckTimeout = 3 * time.Second
+
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+var errArgoInformerNotSynced = errors.New("argo cd application informer not synced")
+
+// DependencyStatus 单个依赖的最近一次检查结果；LastError 在恢复后仍保留，便于排查间歇性故障
+type DependencyStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Required 参与 readiness 判断
+	Required    bool       `json:"required"`
+	LatencyMs   int64      `json:"latency_ms"`
+	CheckedAt   time.Time  `json:"checked_at"`
+	Error       string     `json:"error,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type SystemStatus struct {
+	Ready bool `json:"ready"`
+	// Draining 实例正在退出，readiness 直接失败
+	Draining     bool               `json:"draining,omitempty"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+type dependencyCheck struct {
+	name     string
+	required bool
+	check    func(ctx context.Context) error
+}
+
+var HealthService = NewHealthService()
+
+type healthService struct {
+	checks []dependencyCheck
+
+	mu         sync.Mutex
+	lastErrors map[string]dependencyError
+
+	draining atomic.Bool
+}
+
+type dependencyError struct {
+	message string
+	at      time.Time
+}
+
+func NewHealthService() *healthService {
+	return &healthService{
+		checks: []dependencyCheck{
+			{name: "mongo", required: true, check: store.Ping},
+			{name: "kubernetes", required: true, check: checkKubernetes},
+			{name: "argo_informer", required: true, check: checkArgoInformer},
+			{name: "tekton", check: checkTekton},
+			{name: "argo", check: checkArgo},
+		},
+		lastErrors: map[string]dependencyError{},
+	}
+}
+
+// Ready 只检查 readiness 依赖：Mongo、Kubernetes API 与 Argo CD informer 同步状态；退出中直接返回未就绪
+func (s *healthService) Ready(ctx context.Context) SystemStatus {
+	if s.draining.Load() {
+		return SystemStatus{Draining: true, Dependencies: []DependencyStatus{}}
+	}
+	return s.run(ctx, true)
+}
+
+// SetDraining 标记实例正在退出，之后 Ready 始终返回未就绪，负载均衡据此摘除实例
+func (s *healthService) SetDraining() {
+	s.draining.Store(true)
+}
+
+// Status 检查全部依赖
+func (s *healthService) Status(ctx context.Context) SystemStatus {
+	return s.run(ctx, false)
+}
+
+// run 并发执行检查，结果按注册顺序返回
+func (s *healthService) run(ctx context.Context, requiredOnly bool) SystemStatus {
+	var checks []dependencyCheck
+	for _, c := range s.checks {
+		if !requiredOnly || c.required {
+			checks = append(checks, c)
+		}
+	}
+
+	results := make([]DependencyStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.check(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	status := SystemStatus{Ready: true, Dependencies: results}
+	for _, r := range results {
+		if r.Required && r.Status != DependencyUp {
+			status.Ready = false
+		}
+	}
+	return status
+}
+
+func (s *healthService) check(ctx context.Context, c dependencyCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := safeCheck(ctx, c.check)
+	result := DependencyStatus{
+		Name:      c.name,
+		Status:    DependencyUp,
+		Required:  c.required,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		result.Status = DependencyDown
+		result.Error = err.Error()
+		s.lastErrors[c.name] = dependencyError{message: err.Error(), at: start}
+	}
+	if last, ok := s.lastErrors[c.name]; ok {
+		result.LastError, result.LastErrorAt = last.message, &last.at
+	}
+	return result
+}
+
+// safeCheck 客户端未初始化等情况下的 panic 视为检查失败
+func safeCheck(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("check panicked: client not initialized")
+		}
+	}()
+	return fn(ctx)
+}
+
+func checkKubernetes(ctx context.Context) error {
+	return tekton.KubeClient.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+func checkTekton(ctx context.Context) error {
+	return tekton.TektonClient.Discovery().RESTClient().Get().AbsPath("/apis/tekton.dev/v1").Do(ctx).Error()
+}
+
+func checkArgo(ctx context.Context) error {
+	return argo.ArgoCdClient.Discovery().RESTClient().Get().AbsPath("/apis/argoproj.io/v1alpha1").Do(ctx).Error()
+}
+
+func checkArgoInformer(context.Context) error {
+	if !ArgoInformerSynced() {
+		return errArgoInformerNotSynced
+	}
+	return nil
+}
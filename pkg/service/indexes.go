@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/store"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionIndexes 各集合依赖的索引：唯一索引用于并发去重，其余覆盖后台任务的领取 / 扫描查询
+var collectionIndexes = []struct {
+	model   model.MongoModel
+	indexes []mongoDriver.IndexModel
+}{
+	{
+		model: &domain.OutboxEvent{},
+		indexes: []mongoDriver.IndexModel{
+			// 分发器领取：status + next_attempt_at 过滤，按 created_at 排序
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}, {Key: "created_at", Value: 1}}},
+			// 各实例 tail 新事件
+			{Keys: bson.D{{Key: "created_at", Value: 1}}},
+		},
+	},
+	{
+		model: &domain.WebhookDelivery{},
+		indexes: []mongoDriver.IndexModel{
+			// HandleEvent 按 (subscription_id, event_id) upsert，重复投递的 outbox 事件不会生成两条记录
+			{
+				Keys:    bson.D{{Key: "subscription_id", Value: 1}, {Key: "event_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			// 投递 worker 领取
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		},
+	},
+	{
+		model: &domain.AutoDeployRecord{},
+		indexes: []mongoDriver.IndexModel{{
+			Keys:    bson.D{{Key: "manifest_id", Value: 1}, {Key: "env", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+	},
+	{
+		model: &model.Job{},
+		// 非默认环境当前部署的 Manifest：按环境取最近一次成功的 Job
+		indexes: []mongoDriver.IndexModel{{
+			Keys: bson.D{{Key: "application_id", Value: 1}, {Key: "env", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+		}},
+	},
+	{
+		model: &domain.DriftEvent{},
+		indexes: []mongoDriver.IndexModel{{
+			Keys: bson.D{{Key: "application_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"open": true}),
+		}},
+	},
+	{
+		model: &domain.ConfigurationRevision{},
+		indexes: []mongoDriver.IndexModel{{
+			Keys:    bson.D{{Key: "configuration_id", Value: 1}, {Key: "revision", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+	},
+}
+
+// EnsureIndexes 启动时创建 collectionIndexes 中的索引，应在后台任务启动前调用
+func EnsureIndexes(ctx context.Context) error {
+	for _, c := range collectionIndexes {
+		if err := store.EnsureIndexes(ctx, c.model, c.indexes...); err != nil {
+			return fmt.Errorf("ensure indexes on %s: %w", c.model.CollectionName(), err)
+		}
+	}
+	return nil
+}
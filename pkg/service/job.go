@@ -3,26 +3,66 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	appv1 "github.com/argoproj/argo-cd/v3/pkg/apis/application/v1alpha1"
 	"github.com/bsonger/devflow-common/client/argo"
 	"github.com/bsonger/devflow-common/client/logging"
 	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/client/tekton"
 	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/gitops"
+	"github.com/bsonger/devflow/pkg/render"
+	"github.com/bsonger/devflow/pkg/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var JobService = &jobService{}
 
+const (
+	// JobUninstall 删除 Argo CD Application 的 Job 类型
+	JobUninstall string = "Uninstall"
+
+	CascadeForeground = "foreground"
+	CascadeBackground = "background"
+	CascadeNone       = "none"
+
+	uninstallTimeout = 10 * time.Minute
+
+	defaultEnv = domain.DefaultEnv
+)
+
+var (
+	ErrInvalidCascade      = errors.New("invalid cascade, expect foreground | background | none")
+	ErrApplicationRequired = errors.New("application_id is required when manifest_id is empty")
+	ErrDryRunUnsupported   = errors.New("dry run is not supported for Uninstall jobs")
+	ErrVersionDowngrade    = errors.New("manifest version is older than the active version, set allow_downgrade=true or use a Rollback job")
+)
+
+// CreateJobOptions Job 创建时的执行参数（不落库）
+type CreateJobOptions struct {
+	// Cascade 仅对 Uninstall 生效：foreground | background | none，默认 foreground
+	Cascade string
+	// AllowDowngrade 允许 Install / Upgrade 部署低于当前 active 的版本
+	AllowDowngrade bool
+}
+
 type jobService struct{}
 
 //	func NewJobService() *jobService {
 //		return &jobService{}
 //	}
-func (s *jobService) Create(ctx context.Context, job *model.Job) (primitive.ObjectID, error) {
+func (s *jobService) Create(ctx context.Context, job *model.Job, opts CreateJobOptions) (primitive.ObjectID, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("job.type", job.Type),
 		zap.String("manifest.id", job.ManifestID.Hex()),
@@ -30,41 +70,29 @@ func (s *jobService) Create(ctx context.Context, job *model.Job) (primitive.Obje
 
 	log.Info("job create started")
 
-	// ---------- 1️⃣ 获取 Manifest ----------
-	manifest, err := ManifestService.Get(ctx, job.ManifestID)
+	app, manifest, err := s.prepare(ctx, job, &opts)
 	if err != nil {
-		log.Error("get manifest failed", zap.Error(err))
 		return primitive.NilObjectID, err
 	}
 
-	job.ManifestName = manifest.Name
-	job.ApplicationId = manifest.ApplicationId
-
-	// ---------- 2️⃣ 默认值 ----------
-	if job.Type == "" {
-		job.Type = model.JobUpgrade
-	}
-
-	// ---------- 3️⃣ 获取 Application ----------
-	app, err := ApplicationService.Get(ctx, manifest.ApplicationId)
-	if err != nil {
-		log.Error("get application failed",
-			zap.String("application.id", manifest.ApplicationId.Hex()),
-			zap.Error(err),
-		)
-		return primitive.NilObjectID, err
-	}
-
-	job.ApplicationName = app.Name
-	job.ProjectName = app.ProjectName
-	job.Env = "prod"
-
 	// ---------- 4️⃣ 初始化 Job ----------
 	job.Status = model.JobPending
 	job.WithCreateDefault()
 
-	// ---------- 5️⃣ 落库 ----------
-	if err := mongo.Repo.Create(ctx, job); err != nil {
+	// ---------- 5️⃣ 落库并写入 JobCreated 事件 ----------
+	err = emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		if err := mongo.Repo.Create(ctx, job); err != nil {
+			return nil, err
+		}
+		return []events.DomainEvent{events.JobCreatedEvent{
+			JobID:         job.ID,
+			ApplicationID: job.ApplicationId,
+			ManifestID:    job.ManifestID,
+			Type:          job.Type,
+			Env:           job.Env,
+		}}, nil
+	})
+	if err != nil {
 		log.Error("create job record failed", zap.Error(err))
 		return primitive.NilObjectID, err
 	}
@@ -87,7 +115,38 @@ func (s *jobService) Create(ctx context.Context, job *model.Job) (primitive.Obje
 	)
 
 	// ---------- 7️⃣ 调用 Argo ----------
-	if err := s.syncArgo(ctx, job); err != nil {
+	if job.Type == JobUninstall {
+		if err := s.uninstall(ctx, job, opts.Cascade); err != nil {
+			s.handleSyncArgoError(ctx, job, err)
+			return job.ID, err
+		}
+		log.Info("job uninstall triggered", zap.String("cascade", opts.Cascade))
+		return job.ID, nil
+	}
+
+	// ---------- 8️⃣ 渲染 Secret 并同步 Argo ----------
+	// Secret 不进入 git，两种模式都直接写入集群
+	namespace := domain.EnvNamespace(app.ProjectName, job.Env)
+	if err := ensureNamespace(ctx, namespace); err != nil {
+		s.handleSyncArgoError(ctx, job, err)
+		return job.ID, err
+	}
+	if err := SecretService.Apply(ctx, app.ProjectName, namespace, app.SecretIDs[job.Env]); err != nil {
+		s.handleSyncArgoError(ctx, job, err)
+		return job.ID, err
+	}
+
+	application := argoApplication(job)
+	if gitops.Enabled() {
+		source, err := s.commitGitOps(ctx, job, app, manifest)
+		if err != nil {
+			s.handleSyncArgoError(ctx, job, err)
+			return job.ID, err
+		}
+		application.Spec.Source = source
+	}
+
+	if err := s.syncArgo(ctx, job, application); err != nil {
 		s.handleSyncArgoError(ctx, job, err)
 		return job.ID, err
 	}
@@ -97,6 +156,181 @@ func (s *jobService) Create(ctx context.Context, job *model.Job) (primitive.Obje
 	return job.ID, nil
 }
 
+// prepare 校验 Job 并补全 Manifest / Application 信息与 opts 默认值，不落库
+func (s *jobService) prepare(ctx context.Context, job *model.Job, opts *CreateJobOptions) (*domain.Application, *domain.Manifest, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("job.type", job.Type),
+		zap.String("manifest.id", job.ManifestID.Hex()),
+	)
+
+	if opts.Cascade == "" {
+		opts.Cascade = CascadeForeground
+	}
+	if opts.Cascade != CascadeForeground && opts.Cascade != CascadeBackground && opts.Cascade != CascadeNone {
+		return nil, nil, ErrInvalidCascade
+	}
+
+	// ---------- 1️⃣ 获取 Manifest ----------
+	// Uninstall / Rollback 可以只指定 application_id：
+	// Uninstall 以当前 active manifest 记录，Rollback 按版本优先级选择目标
+	var manifest *domain.Manifest
+	if job.ManifestID.IsZero() && (job.Type == JobUninstall || job.Type == model.JobRollback) {
+		if job.ApplicationId.IsZero() {
+			return nil, nil, ErrApplicationRequired
+		}
+	} else {
+		var err error
+		manifest, err = ManifestService.Get(ctx, job.ManifestID)
+		if err != nil {
+			log.Error("get manifest failed", zap.Error(err))
+			return nil, nil, err
+		}
+
+		job.ManifestName = manifest.Name
+		job.ApplicationId = manifest.ApplicationId
+	}
+
+	// ---------- 2️⃣ 默认值 ----------
+	if job.Type == "" {
+		job.Type = model.JobUpgrade
+	}
+	if job.Env == "" {
+		job.Env = defaultEnv
+	}
+	if err := domain.ValidateEnv(job.Env); err != nil {
+		return nil, nil, err
+	}
+
+	// ---------- 3️⃣ 获取 Application ----------
+	app, err := ApplicationService.Get(ctx, job.ApplicationId)
+	if err != nil {
+		log.Error("get application failed",
+			zap.String("application.id", job.ApplicationId.Hex()),
+			zap.Error(err),
+		)
+		return nil, nil, err
+	}
+
+	// active manifest 按环境区分：默认环境取 Application 记录，其他环境取最近一次成功的部署
+	activeID, activeName, err := ApplicationService.ActiveManifest(ctx, app, job.Env)
+	if err != nil {
+		log.Error("get active manifest failed", zap.String("env", job.Env), zap.Error(err))
+		return nil, nil, err
+	}
+
+	if job.Type == model.JobRollback && manifest == nil {
+		if activeID == nil {
+			return nil, nil, ErrNoRollbackTarget
+		}
+		manifest, err = ManifestService.FindRollbackTarget(ctx, app, *activeID)
+		if err != nil {
+			log.Warn("find rollback target failed", zap.Error(err))
+			return nil, nil, err
+		}
+		job.ManifestID = manifest.GetID()
+		job.ManifestName = manifest.Name
+	}
+
+	// 非 Rollback 部署旧版本需要显式 allow_downgrade
+	if (job.Type == model.JobInstall || job.Type == model.JobUpgrade) && !opts.AllowDowngrade {
+		if err := s.checkDowngrade(ctx, activeID, manifest); err != nil {
+			log.Warn("deploy blocked", zap.Error(err))
+			return nil, nil, err
+		}
+	}
+
+	if job.ManifestID.IsZero() && activeID != nil {
+		job.ManifestID = *activeID
+		job.ManifestName = activeName
+	}
+
+	job.ApplicationName = app.Name
+	job.ProjectName = app.ProjectName
+
+	return app, manifest, nil
+
+}
+
+// DryRun 按 Create 的规则校验 Job，返回将要部署的资源而不落库、不同步 Argo
+func (s *jobService) DryRun(ctx context.Context, job *model.Job, opts CreateJobOptions) (*render.Bundle, error) {
+	app, manifest, err := s.prepare(ctx, job, &opts)
+	if err != nil {
+		return nil, err
+	}
+	if job.Type == JobUninstall {
+		return nil, ErrDryRunUnsupported
+	}
+	return s.renderBundle(ctx, job, app, manifest)
+}
+
+// renderBundle 渲染 Job 将要部署的 Kubernetes 资源与 Argo CD Application（不含 Secret 本身，只含 envFrom 引用）。
+// 未启用 GitOps 时 Argo CD 通过 CMP 插件自行渲染，本地渲染结果只是近似，bundle 标记为 Approximate
+func (s *jobService) renderBundle(ctx context.Context, job *model.Job, app *domain.Application, manifest *domain.Manifest) (*render.Bundle, error) {
+	secrets, err := SecretService.Refs(ctx, app, job.Env)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := render.Render(app, manifest, job.Env, secrets...)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Approximate = !gitops.Enabled()
+
+	application := argoApplication(job)
+	if gitops.Enabled() {
+		// 预览时提交尚未产生，source 指向分支
+		application.Spec.Source = &appv1.ApplicationSource{
+			RepoURL:        gitops.Repo.Address,
+			Path:           gitops.Dir(app.ProjectName, app.Name, job.Env),
+			TargetRevision: gitops.C.Branch,
+		}
+	}
+	bundle.Add(application.Kind, application.Name, application)
+	return bundle, nil
+}
+
+// argoApplication 按环境生成 Argo CD Application：非默认环境使用 <name>-<env> 名称与 <project>-<env> namespace
+func argoApplication(job *model.Job) *appv1.Application {
+	application := job.GenerateApplication()
+	application.Name = domain.ArgoAppName(job.ApplicationName, job.Env)
+	application.Spec.Destination.Namespace = domain.EnvNamespace(job.ProjectName, job.Env)
+	return application
+}
+
+// ensureNamespace 创建非默认环境的 namespace；默认环境沿用已有的项目 namespace
+func ensureNamespace(ctx context.Context, namespace string) error {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+	_, err := tekton.KubeClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// checkDowngrade 目标与环境当前的 active manifest 都有版本号时，禁止部署更低的版本
+func (s *jobService) checkDowngrade(ctx context.Context, activeID *primitive.ObjectID, target *domain.Manifest) error {
+	if target == nil || target.Version == "" || activeID == nil || *activeID == target.GetID() {
+		return nil
+	}
+
+	active, err := ManifestService.Get(ctx, *activeID)
+	if err != nil {
+		return err
+	}
+	if active.Version == "" {
+		return nil
+	}
+
+	cmp, err := domain.CompareVersions(target.Version, active.Version)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return fmt.Errorf("%w: %s < %s", ErrVersionDowngrade, target.Version, active.Version)
+	}
+	return nil
+}
+
 func (s *jobService) handleSyncArgoError(ctx context.Context, job *model.Job, err error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("job.id", job.ID.Hex()),
@@ -111,13 +345,13 @@ func (s *jobService) handleSyncArgoError(ctx context.Context, job *model.Job, er
 	}
 }
 
-func (s *jobService) Get(ctx context.Context, id primitive.ObjectID) (*model.Job, error) {
+func (s *jobService) Get(ctx context.Context, id primitive.ObjectID) (*domain.Job, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("job.id", id.Hex()),
 		zap.String("operation", "get_job"),
 	)
 
-	job := &model.Job{}
+	job := &domain.Job{}
 	err := mongo.Repo.FindByID(ctx, job, id)
 	if err != nil {
 		log.Error("get job failed", zap.Error(err))
@@ -152,7 +386,16 @@ func (s *jobService) Update(ctx context.Context, job *model.Job) error {
 	job.DeletedAt = current.DeletedAt
 	job.WithUpdateDefault()
 
-	if err := mongo.Repo.Update(ctx, job); err != nil {
+	err := emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		if err := mongo.Repo.Update(ctx, job); err != nil {
+			return nil, err
+		}
+		if job.Status == current.Status {
+			return nil, nil
+		}
+		return []events.DomainEvent{jobStatusChanged(job)}, nil
+	})
+	if err != nil {
 		log.Error("update job failed", zap.Error(err))
 		return err
 	}
@@ -184,13 +427,13 @@ func (s *jobService) Delete(ctx context.Context, id primitive.ObjectID) error {
 	return nil
 }
 
-func (s *jobService) List(ctx context.Context, filter primitive.M) ([]*model.Job, error) {
+func (s *jobService) List(ctx context.Context, filter primitive.M) ([]*domain.Job, error) {
 	log := logging.LoggerWithContext(ctx).With(
 		zap.String("operation", "list_jobs"),
 		zap.Any("filter", filter),
 	)
 
-	var jobs []*model.Job
+	var jobs []*domain.Job
 	if err := mongo.Repo.List(ctx, &model.Job{}, filter, &jobs); err != nil {
 		log.Error("list jobs failed", zap.Error(err))
 		return nil, err
@@ -200,21 +443,90 @@ func (s *jobService) List(ctx context.Context, filter primitive.M) ([]*model.Job
 	return jobs, nil
 }
 
+// updateStatus 更新 Job 状态，并在同一事务内写入 JobStatusChanged 事件
 func (s *jobService) updateStatus(ctx context.Context, jobID primitive.ObjectID, status model.JobStatus) error {
+	return s.setStatus(ctx, primitive.M{"_id": jobID}, status)
+}
+
+// setStatus 更新命中 filter 的 Job 状态，并在同一事务内写入 JobStatusChanged 事件；未命中时返回 ErrNoDocuments
+func (s *jobService) setStatus(ctx context.Context, filter primitive.M, status model.JobStatus) error {
 	update := primitive.M{
 		"$set": primitive.M{
 			"status":     status,
 			"updated_at": time.Now(),
 		},
 	}
-	return mongo.Repo.UpdateByID(ctx, &model.Job{}, jobID, update)
+
+	return emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		job := &model.Job{}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := store.Collection(job).FindOneAndUpdate(ctx, filter, update, opts).Decode(job)
+		if err != nil {
+			return nil, err
+		}
+		return []events.DomainEvent{jobStatusChanged(job)}, nil
+	})
+}
+
+func jobStatusChanged(job *model.Job) events.DomainEvent {
+	return events.JobStatusChangedEvent{
+		JobID:         job.ID,
+		ApplicationID: job.ApplicationId,
+		ManifestID:    job.ManifestID,
+		Type:          job.Type,
+		Env:           job.Env,
+		Status:        job.Status,
+	}
+}
+
+// commitGitOps 渲染 Manifest 并提交到 manifests 仓库，返回固定到该提交的 Argo CD source
+func (s *jobService) commitGitOps(ctx context.Context, job *model.Job, app *domain.Application, manifest *domain.Manifest) (*appv1.ApplicationSource, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("job.id", job.ID.Hex()),
+		zap.String("operation", "commit_gitops"),
+	)
+
+	secrets, err := SecretService.Refs(ctx, app, job.Env)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := render.Render(app, manifest, job.Env, secrets...)
+	if err != nil {
+		return nil, err
+	}
+	files, err := bundle.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := gitops.Dir(app.ProjectName, app.Name, job.Env)
+	message := fmt.Sprintf("deploy %s %s to %s\n\njob: %s\nmanifest: %s",
+		app.Name, manifest.Name, job.Env, job.ID.Hex(), manifest.GetID().Hex())
+
+	sha, err := gitops.DefaultWriter.Write(ctx, dir, files, message)
+	if err != nil {
+		log.Error("write gitops repo failed", zap.String("dir", dir), zap.Error(err))
+		return nil, err
+	}
+
+	update := primitive.M{"$set": primitive.M{"gitops_commit": sha, "updated_at": time.Now()}}
+	if err := mongo.Repo.UpdateByID(ctx, &model.Job{}, job.ID, update); err != nil {
+		log.Error("record gitops commit failed", zap.Error(err))
+		return nil, err
+	}
+
+	log.Info("gitops commit pushed", zap.String("dir", dir), zap.String("commit", sha))
+	return &appv1.ApplicationSource{
+		RepoURL:        gitops.Repo.Address,
+		Path:           dir,
+		TargetRevision: sha,
+	}, nil
 }
 
-func (s *jobService) syncArgo(ctx context.Context, job *model.Job) error {
+func (s *jobService) syncArgo(ctx context.Context, job *model.Job, application *appv1.Application) error {
 
 	log := logging.LoggerWithContext(ctx)
 	var err error
-	application := job.GenerateApplication()
 	// 3.2 获取当前 trace context
 	sc := trace.SpanContextFromContext(ctx)
 	application.Annotations = map[string]string{
@@ -249,3 +561,89 @@ func (s *jobService) syncArgo(ctx context.Context, job *model.Job) error {
 	)
 	return nil
 }
+
+// uninstall 删除 Argo CD Application；删除完成由 ReconcileUninstalls 在 informer 删除事件或周期扫描时确认，
+// 不占用后台 goroutine，服务重启后可继续
+func (s *jobService) uninstall(ctx context.Context, job *model.Job, cascade string) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("job.id", job.ID.Hex()),
+		zap.String("application", job.ApplicationName),
+		zap.String("cascade", cascade),
+	)
+
+	exists, err := uninstallArgoApplication(ctx, domain.ArgoAppName(job.ApplicationName, job.Env), cascade)
+	if err != nil {
+		log.Error("argo uninstall failed", zap.Error(err))
+		return err
+	}
+	if !exists {
+		log.Warn("argo application not found, treat as uninstalled")
+		s.finishUninstall(ctx, job, nil)
+	}
+	return nil
+}
+
+// ReconcileUninstalls 确认进行中（Syncing）的 Uninstall Job：Argo CD Application 已删除 → Succeeded，
+// 创建后超过 uninstallTimeout 仍未删除 → Failed；exists 判断 Application 是否仍存在
+func (s *jobService) ReconcileUninstalls(ctx context.Context, exists func(name string) (bool, error)) {
+	log := logging.LoggerWithContext(ctx).With(zap.String("operation", "reconcile_uninstalls"))
+
+	var jobs []model.Job
+	err := mongo.Repo.List(ctx, &model.Job{}, primitive.M{
+		"type":       JobUninstall,
+		"status":     model.JobSyncing,
+		"deleted_at": primitive.M{"$exists": false},
+	}, &jobs)
+	if err != nil {
+		log.Error("list uninstall jobs failed", zap.Error(err))
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		found, err := exists(domain.ArgoAppName(job.ApplicationName, job.Env))
+		switch {
+		case err != nil:
+			log.Warn("check argo application failed", zap.String("job.id", job.ID.Hex()), zap.Error(err))
+		case !found:
+			s.finishUninstall(ctx, job, nil)
+		case time.Since(job.CreatedAt) > uninstallTimeout:
+			s.finishUninstall(ctx, job, fmt.Errorf("argo application not deleted within %s", uninstallTimeout))
+		}
+	}
+}
+
+// finishUninstall 记录 Uninstall 结果；只更新仍为 Syncing 的 Job，多实例或重复触发时只生效一次
+func (s *jobService) finishUninstall(ctx context.Context, job *model.Job, cause error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("job.id", job.ID.Hex()),
+		zap.String("application", job.ApplicationName),
+	)
+
+	// 非默认环境的 active manifest 由成功的 Uninstall Job 本身表示
+	if cause == nil && job.Env == defaultEnv {
+		if err := ApplicationService.ClearActiveManifest(ctx, job.ApplicationId); err != nil {
+			cause = fmt.Errorf("clear application active manifest: %w", err)
+		}
+	}
+
+	status := model.JobSucceeded
+	if cause != nil {
+		log.Error("uninstall failed", zap.Error(cause))
+		status = model.JobFailed
+	}
+
+	err := s.setStatus(ctx, primitive.M{"_id": job.ID, "status": model.JobSyncing}, status)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		log.Debug("uninstall job already finished")
+		return
+	}
+	if err != nil {
+		log.Error("update job status failed", zap.String("job.status", string(status)), zap.Error(err))
+		return
+	}
+
+	if cause == nil {
+		log.Info("application uninstalled")
+	}
+}
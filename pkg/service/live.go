@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bsonger/devflow-common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	dynamicOnce   sync.Once
+	dynamicClient dynamic.Interface
+	dynamicErr    error
+)
+
+// serverSideFields 输出 live / predicted 状态前去掉的服务端字段，两侧同时存在，只影响可读性
+var serverSideFields = [][]string{
+	{"status"},
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "uid"},
+	{"metadata", "generation"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"},
+	{"metadata", "annotations", "deployment.kubernetes.io/revision"},
+}
+
+func getDynamicClient() (dynamic.Interface, error) {
+	dynamicOnce.Do(func() {
+		dynamicClient, dynamicErr = dynamic.NewForConfig(model.KubeConfig)
+	})
+	return dynamicClient, dynamicErr
+}
+
+// toUnstructured 渲染对象转为 unstructured
+func toUnstructured(obj any) (*unstructured.Unstructured, error) {
+	content, ok := obj.(map[string]any)
+	if !ok {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+// getLiveObject 按渲染对象的 apiVersion / kind / name 读取集群中的实际资源，保留 last-applied-configuration 供三方对比
+func getLiveObject(ctx context.Context, desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(desired.GetAPIVersion())
+	if err != nil {
+		return nil, fmt.Errorf("parse apiVersion of %s/%s: %w", desired.GetKind(), desired.GetName(), err)
+	}
+	// 渲染的资源类型（Deployment / Service / ConfigMap / HTTPRoute）复数形式均为小写加 s
+	gvr := gv.WithResource(strings.ToLower(desired.GetKind()) + "s")
+
+	client, err := getDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Resource(gvr).Namespace(desired.GetNamespace()).Get(ctx, desired.GetName(), metav1.GetOptions{})
+}
+
+// stateYAML Argo CD diff 结果中的 JSON 状态转为去掉服务端字段的 YAML，null（资源不存在）返回空
+func stateYAML(state []byte) (string, error) {
+	var obj map[string]any
+	if err := json.Unmarshal(state, &obj); err != nil {
+		return "", err
+	}
+	if obj == nil {
+		return "", nil
+	}
+	for _, field := range serverSideFields {
+		unstructured.RemoveNestedField(obj, field...)
+	}
+	out, err := yaml.Marshal(obj)
+	return string(out), err
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/tekton"
+	"github.com/bsonger/devflow/pkg/auth"
+	"github.com/bsonger/devflow/pkg/render"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	ErrStepNotFound      = errors.New("manifest step not found")
+	ErrStepNotStarted    = errors.New("manifest step has not started yet")
+	ErrContainerNotFound = errors.New("container not found in pod")
+	ErrNoRunningPods     = errors.New("no pods found for application")
+)
+
+var LogService = &logService{}
+
+// LogOptions 日志读取参数
+type LogOptions struct {
+	// Container 容器名，Tekton step 可省略 `step-` 前缀；为空时按顺序输出全部 step 容器
+	Container string
+	// Follow 持续跟随直到容器结束
+	Follow bool
+	// TailLines 只返回最后 N 行
+	TailLines *int64
+	// SinceSeconds 只返回最近 N 秒
+	SinceSeconds *int64
+	// Timestamps 每行附带时间戳
+	Timestamps bool
+}
+
+func (o LogOptions) podLogOptions(container string) *corev1.PodLogOptions {
+	return &corev1.PodLogOptions{
+		Container:    container,
+		Follow:       o.Follow,
+		TailLines:    o.TailLines,
+		SinceSeconds: o.SinceSeconds,
+		Timestamps:   o.Timestamps,
+	}
+}
+
+type logService struct{}
+
+// StepLogs 读取 Manifest 某个构建步骤对应 TaskRun Pod 的容器日志，调用方负责关闭返回的 Reader；
+// 调用方须有 Manifest 所属 Application 项目的访问权限
+func (s *logService) StepLogs(ctx context.Context, id primitive.ObjectID, task string, opts LogOptions) (io.ReadCloser, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "step_logs"),
+		zap.String("manifest_id", id.Hex()),
+		zap.String("task", task),
+	)
+
+	m, err := ManifestService.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := ApplicationService.Get(ctx, m.ApplicationId)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.AuthorizeProject(ctx, app.ProjectName); err != nil {
+		log.Warn("step logs denied", zap.String("project", app.ProjectName), zap.Error(err))
+		return nil, err
+	}
+
+	step := m.GetStep(task)
+	if step == nil {
+		return nil, ErrStepNotFound
+	}
+	if step.TaskRun == "" {
+		return nil, ErrStepNotStarted
+	}
+
+	// 1️⃣ TaskRun → Pod
+	tr, err := tekton.TektonClient.TektonV1().TaskRuns(namespace).Get(ctx, step.TaskRun, metav1.GetOptions{})
+	if err != nil {
+		log.Error("get taskrun failed", zap.String("task_run", step.TaskRun), zap.Error(err))
+		return nil, err
+	}
+	if tr.Status.PodName == "" {
+		return nil, ErrStepNotStarted
+	}
+
+	pod, err := tekton.KubeClient.CoreV1().Pods(namespace).Get(ctx, tr.Status.PodName, metav1.GetOptions{})
+	if err != nil {
+		log.Error("get pod failed", zap.String("pod", tr.Status.PodName), zap.Error(err))
+		return nil, err
+	}
+
+	// 2️⃣ 选择容器
+	containers, err := stepContainers(pod, opts.Container)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("streaming step logs", zap.String("pod", pod.Name), zap.Strings("containers", containers))
+
+	// 3️⃣ 单容器直接透传，多容器按顺序拼接并输出分隔行
+	if len(containers) == 1 {
+		return tekton.KubeClient.CoreV1().Pods(namespace).GetLogs(pod.Name, opts.podLogOptions(containers[0])).Stream(ctx)
+	}
+	return sequentialLogs(ctx, pod, containers, opts), nil
+}
+
+// stepContainers 解析要读取的容器：指定时支持省略 `step-` 前缀，未指定时返回全部 step 容器
+func stepContainers(pod *corev1.Pod, container string) ([]string, error) {
+	if container != "" {
+		for _, name := range []string{container, "step-" + container} {
+			for _, c := range pod.Spec.Containers {
+				if c.Name == name {
+					return []string{name}, nil
+				}
+			}
+		}
+		return nil, ErrContainerNotFound
+	}
+
+	var names []string
+	for _, c := range pod.Spec.Containers {
+		if strings.HasPrefix(c.Name, "step-") {
+			names = append(names, c.Name)
+		}
+	}
+	if len(names) == 0 {
+		for _, c := range pod.Spec.Containers {
+			names = append(names, c.Name)
+		}
+	}
+	return names, nil
+}
+
+// sequentialLogs 依次读取各容器日志写入同一个流，follow 时前一个容器结束后才读取下一个
+func sequentialLogs(ctx context.Context, pod *corev1.Pod, containers []string, opts LogOptions) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		for _, c := range containers {
+			if _, err := fmt.Fprintf(pw, "==> %s <==\n", c); err != nil {
+				return
+			}
+			stream, err := tekton.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts.podLogOptions(c)).Stream(ctx)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			_, err = io.Copy(pw, stream)
+			stream.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	return pr
+}
+
+// ApplicationLogs 读取 Application 运行中 Deployment 各 Pod 的容器日志并合并为一个流，每行以 `[pod]` 前缀区分；
+// 调用方须有 Application 所属项目的访问权限
+func (s *logService) ApplicationLogs(ctx context.Context, id primitive.ObjectID, env string, opts LogOptions) (io.ReadCloser, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "application_logs"),
+		zap.String("application_id", id.Hex()),
+		zap.String("env", env),
+	)
+
+	app, err := ApplicationService.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1️⃣ 鉴权
+	if err := auth.AuthorizeProject(ctx, app.ProjectName); err != nil {
+		log.Warn("application logs denied", zap.String("project", app.ProjectName), zap.Error(err))
+		return nil, err
+	}
+
+	// 2️⃣ Deployment → Pods
+	deploy, err := tekton.KubeClient.AppsV1().Deployments(app.ProjectName).Get(ctx, app.Name, metav1.GetOptions{})
+	if err != nil {
+		log.Error("get deployment failed", zap.Error(err))
+		return nil, err
+	}
+	if env != "" && deploy.Labels[render.EnvLabel] != env {
+		return nil, ErrApplicationNotDeployed
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := tekton.KubeClient.CoreV1().Pods(app.ProjectName).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		log.Error("list pods failed", zap.Error(err))
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, ErrNoRunningPods
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+
+	// 3️⃣ 选择容器，默认与 Application 同名的主容器
+	container := opts.Container
+	if container == "" {
+		container = app.Name
+	}
+	for _, c := range pods.Items[0].Spec.Containers {
+		if c.Name == container {
+			log.Debug("streaming application logs", zap.Int("pods", len(pods.Items)), zap.String("container", container))
+			return mergedLogs(ctx, pods.Items, container, opts), nil
+		}
+	}
+	return nil, ErrContainerNotFound
+}
+
+// mergedLogs 并发读取各 Pod 的日志，按行加 `[pod]` 前缀写入同一个流；单个 Pod 失败时输出错误行而不中断其他 Pod
+func mergedLogs(ctx context.Context, pods []corev1.Pod, container string, opts LogOptions) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	writeLine := func(line string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := io.WriteString(pw, line)
+		return err
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		prefix := "[" + pod.Name + "] "
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			stream, err := tekton.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts.podLogOptions(container)).Stream(ctx)
+			if err != nil {
+				_ = writeLine(prefix + "error: " + err.Error() + "\n")
+				return
+			}
+			defer stream.Close()
+
+			reader := bufio.NewReader(stream)
+			for {
+				line, err := reader.ReadString('\n')
+				if line != "" {
+					if !strings.HasSuffix(line, "\n") {
+						line += "\n"
+					}
+					if werr := writeLine(prefix + line); werr != nil {
+						return
+					}
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		pw.Close()
+	}()
+
+	return pr
+}
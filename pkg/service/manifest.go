@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+
 	"github.com/bsonger/devflow-common/client/tekton"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -14,10 +20,28 @@ import (
 	"github.com/bsonger/devflow-common/client/logging"
 	"github.com/bsonger/devflow-common/client/mongo"
 	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/render"
+	"github.com/bsonger/devflow/pkg/store"
 )
 
 var ManifestService = &manifestService{}
 
+var (
+	ErrInvalidVersionTag = errors.New("invalid version tag")
+	ErrVersionExists     = errors.New("manifest version already exists")
+	ErrNoRollbackTarget  = errors.New("no older succeeded version to roll back to")
+	// ErrManifestStatusConflict 读取后状态被并发修改
+	ErrManifestStatusConflict = errors.New("manifest status modified concurrently, reload and retry")
+)
+
+// 终态的 Manifest / 步骤不再变更
+var (
+	terminalManifestStatuses = []model.ManifestStatus{model.ManifestFailed, model.ManifestSucceeded}
+	terminalStepStatuses     = []model.StepStatus{model.StepFailed, model.StepSucceeded}
+)
+
 const (
 	namespace = "tekton-pipelines"
 )
@@ -25,7 +49,7 @@ const (
 type manifestService struct {
 }
 
-func (s *manifestService) CreateManifest(ctx context.Context, m *model.Manifest) (primitive.ObjectID, error) {
+func (s *manifestService) CreateManifest(ctx context.Context, m *domain.Manifest) (primitive.ObjectID, error) {
 	logger := logging.LoggerFromContext(ctx)
 
 	// ---- Entry log（一次请求的主线）----
@@ -57,12 +81,32 @@ func (s *manifestService) CreateManifest(ctx context.Context, m *model.Manifest)
 	m.Internet = app.Internet
 	m.ID = primitive.NewObjectID()
 
-	m.Name = model.GenerateManifestVersion(app.Name)
+	m.Configurations, err = ConfigurationService.Snapshot(ctx, app.ConfigurationIDs)
+	if err != nil {
+		logger.Error("snapshot configurations failed", zap.Error(err))
+		return primitive.NilObjectID, err
+	}
+
+	if m.Tag != "" {
+		// 以 semver tag 构建：名称与镜像 tag 由 tag 派生
+		m.Version, err = domain.ParseVersion(m.Tag)
+		if err != nil {
+			logger.Warn("invalid version tag", zap.String("tag", m.Tag), zap.Error(err))
+			return primitive.NilObjectID, fmt.Errorf("%w: %v", ErrInvalidVersionTag, err)
+		}
+		if err := s.ensureVersionAvailable(ctx, app.GetID(), m.Version); err != nil {
+			logger.Warn("manifest version already exists", zap.String("version", m.Version))
+			return primitive.NilObjectID, err
+		}
+		m.Name = domain.VersionedManifestName(app.Name, m.Tag)
+	} else {
+		m.Name = model.GenerateManifestVersion(app.Name)
+		if m.Branch == "" {
+			m.Branch = "main"
+		}
+	}
 	m.Status = model.ManifestPending
 	m.WithCreateDefault()
-	if m.Branch == "" {
-		m.Branch = "main"
-	}
 
 	logger.Debug("manifest initialized",
 		zap.String("manifest", m.Name),
@@ -84,6 +128,14 @@ func (s *manifestService) CreateManifest(ctx context.Context, m *model.Manifest)
 
 	// 3.1 PipelineRun
 	pr := m.GeneratePipelineRun("devflow-ci", pvc.Name)
+	if m.Tag != "" {
+		setPipelineParam(pr, "git-revision", m.Tag)
+		setPipelineParam(pr, "image-tag", m.Tag)
+	}
+	// 已知 commit 时（如 webhook 触发）按 commit 构建，避免分支在构建前又被推进
+	if m.CommitHash != "" {
+		setPipelineParam(pr, "git-revision", m.CommitHash)
+	}
 
 	sc := trace.SpanContextFromContext(pctx)
 	pr.Annotations = map[string]string{
@@ -126,8 +178,22 @@ func (s *manifestService) CreateManifest(ctx context.Context, m *model.Manifest)
 
 	logger.Debug("steps initialized", zap.Int("step_count", len(m.Steps)))
 
-	// 6️⃣ 保存 Manifest
-	if err := mongo.Repo.Create(ctx, m); err != nil {
+	// 6️⃣ 保存 Manifest 并写入 ManifestCreated 事件
+	err = emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		if err := mongo.Repo.Create(ctx, m); err != nil {
+			return nil, err
+		}
+		return []events.DomainEvent{events.ManifestCreatedEvent{
+			ManifestID:    m.GetID(),
+			ApplicationID: m.ApplicationId,
+			Name:          m.Name,
+			Branch:        m.Branch,
+			Tag:           m.Tag,
+			CommitHash:    m.CommitHash,
+			PipelineID:    m.PipelineID,
+		}}, nil
+	})
+	if err != nil {
 		logger.Error("save manifest failed", zap.Error(err))
 		return primitive.NilObjectID, err
 	}
@@ -141,14 +207,14 @@ func (s *manifestService) CreateManifest(ctx context.Context, m *model.Manifest)
 }
 
 // GetManifest 根据 ID 查询 Manifest
-func (s *manifestService) GetManifest(ctx context.Context, id primitive.ObjectID) (*model.Manifest, error) {
+func (s *manifestService) GetManifest(ctx context.Context, id primitive.ObjectID) (*domain.Manifest, error) {
 	logger := logging.LoggerWithContext(ctx)
 
 	logger.Debug("get manifest start",
 		zap.String("manifest_id", id.Hex()),
 	)
 
-	m := &model.Manifest{}
+	m := &domain.Manifest{}
 	if err := mongo.Repo.FindByID(ctx, m, id); err != nil {
 		logger.Error("get manifest failed",
 			zap.String("manifest_id", id.Hex()),
@@ -166,8 +232,8 @@ func (s *manifestService) GetManifest(ctx context.Context, id primitive.ObjectID
 	return m, nil
 }
 
-// Update UpdateManifest 更新 Manifest
-func (s *manifestService) Update(ctx context.Context, m *model.Manifest) error {
+// Update UpdateManifest 更新 Manifest；状态与步骤状态的变更在同一事务内写入领域事件，终态不再变更
+func (s *manifestService) Update(ctx context.Context, m *domain.Manifest) error {
 
 	logger := logging.LoggerWithContext(ctx)
 
@@ -177,20 +243,23 @@ func (s *manifestService) Update(ctx context.Context, m *model.Manifest) error {
 		zap.String("status", string(m.Status)),
 	)
 
-	current := &model.Manifest{}
-	if err := mongo.Repo.FindByID(ctx, current, m.GetID()); err != nil {
-		logger.Error("load manifest failed",
-			zap.String("manifest_id", m.GetID().Hex()),
-			zap.Error(err),
-		)
-		return err
-	}
-
-	m.CreatedAt = current.CreatedAt
-	m.DeletedAt = current.DeletedAt
-	m.WithUpdateDefault()
-
-	if err := mongo.Repo.Update(ctx, m); err != nil {
+	err := emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		current := &domain.Manifest{}
+		if err := mongo.Repo.FindByID(ctx, current, m.GetID()); err != nil {
+			return nil, err
+		}
+
+		m.CreatedAt = current.CreatedAt
+		m.DeletedAt = current.DeletedAt
+		keepTerminal(current, m)
+		m.WithUpdateDefault()
+
+		if err := mongo.Repo.Update(ctx, m); err != nil {
+			return nil, err
+		}
+		return manifestStatusEvents(current, m), nil
+	})
+	if err != nil {
 		logger.Error("update manifest failed",
 			zap.String("manifest_id", m.GetID().Hex()),
 			zap.String("manifest_name", m.Name),
@@ -207,13 +276,57 @@ func (s *manifestService) Update(ctx context.Context, m *model.Manifest) error {
 
 	return nil
 }
-func (s *manifestService) List(ctx context.Context, filter primitive.M) ([]model.Manifest, error) {
+
+// keepTerminal 已处于终态的 Manifest 状态与步骤状态保持不变，与 UpdateManifestStatus / UpdateStepStatus 一致
+func keepTerminal(current, m *domain.Manifest) {
+	if slices.Contains(terminalManifestStatuses, current.Status) {
+		m.Status = current.Status
+	}
+	for i := range m.Steps {
+		prev := current.GetStep(m.Steps[i].TaskName)
+		if prev != nil && slices.Contains(terminalStepStatuses, prev.Status) {
+			m.Steps[i] = *prev
+		}
+	}
+}
+
+// manifestStatusEvents 对比更新前后的 Manifest，为变化的步骤与 Manifest 状态生成领域事件
+func manifestStatusEvents(before, after *domain.Manifest) []events.DomainEvent {
+	var evs []events.DomainEvent
+	for _, step := range after.Steps {
+		prev := before.GetStep(step.TaskName)
+		if prev != nil && prev.Status == step.Status {
+			continue
+		}
+		evs = append(evs, events.StepStatusChangedEvent{
+			ManifestID:    after.GetID(),
+			ApplicationID: after.ApplicationId,
+			Name:          after.Name,
+			TaskName:      step.TaskName,
+			Status:        step.Status,
+			Message:       step.Message,
+			StartTime:     step.StartTime,
+			EndTime:       step.EndTime,
+		})
+	}
+	if before.Status != after.Status {
+		evs = append(evs, events.ManifestStatusChangedEvent{
+			ManifestID:    after.GetID(),
+			ApplicationID: after.ApplicationId,
+			Name:          after.Name,
+			Status:        after.Status,
+		})
+	}
+	return evs
+}
+
+func (s *manifestService) List(ctx context.Context, filter primitive.M) ([]domain.Manifest, error) {
 
 	logger := logging.LoggerWithContext(ctx)
 
 	logger.Debug("list manifests start")
 
-	var manifests []model.Manifest
+	var manifests []domain.Manifest
 	if err := mongo.Repo.List(ctx, &model.Manifest{}, filter, &manifests); err != nil {
 		logger.Error("list manifests failed",
 			zap.Error(err),
@@ -228,12 +341,19 @@ func (s *manifestService) List(ctx context.Context, filter primitive.M) ([]model
 	return manifests, nil
 }
 
-func (s *manifestService) Get(ctx context.Context, id primitive.ObjectID) (*model.Manifest, error) {
-	app := &model.Manifest{}
-	err := mongo.Repo.FindByID(ctx, app, id)
-	return app, err
+// Get 根据 ID 查询 Manifest，软删除（含随 Application 级联删除）的记录视为不存在
+func (s *manifestService) Get(ctx context.Context, id primitive.ObjectID) (*domain.Manifest, error) {
+	m := &domain.Manifest{}
+	if err := mongo.Repo.FindByID(ctx, m, id); err != nil {
+		return nil, err
+	}
+	if m.DeletedAt != nil {
+		return nil, mongoDriver.ErrNoDocuments
+	}
+	return m, nil
 }
 
+// UpdateStepStatus 更新步骤状态，并在同一事务内写入 StepStatusChanged 事件
 func (s *manifestService) UpdateStepStatus(ctx context.Context, pipelineID, taskName string, status model.StepStatus, message string, start, end *time.Time) error {
 
 	update := bson.M{
@@ -255,35 +375,198 @@ func (s *manifestService) UpdateStepStatus(ctx context.Context, pipelineID, task
 			"$elemMatch": bson.M{
 				"task_name": taskName,
 				"status": bson.M{
-					"$nin": []model.StepStatus{model.StepFailed, model.StepSucceeded},
+					"$nin": terminalStepStatuses,
 				},
 			},
 		},
 	}
 
-	return mongo.Repo.UpdateOne(ctx, &model.Manifest{}, filter, bson.M{"$set": update})
+	return emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		m, err := s.findAndUpdate(ctx, filter, bson.M{"$set": update})
+		if m == nil || err != nil {
+			return nil, err
+		}
+		step := m.GetStep(taskName)
+		if step == nil {
+			return nil, nil
+		}
+		return []events.DomainEvent{events.StepStatusChangedEvent{
+			ManifestID:    m.GetID(),
+			ApplicationID: m.ApplicationId,
+			Name:          m.Name,
+			TaskName:      step.TaskName,
+			Status:        step.Status,
+			Message:       step.Message,
+			StartTime:     step.StartTime,
+			EndTime:       step.EndTime,
+		}}, nil
+	})
 }
 
+// UpdateManifestStatus 更新 Manifest 状态，并在同一事务内写入 ManifestStatusChanged 事件
 func (s *manifestService) UpdateManifestStatus(ctx context.Context, pipelineID string, status model.ManifestStatus) error {
 
 	filter := bson.M{
 		"pipeline_id": pipelineID,
 		"status": bson.M{
-			"$nin": []model.ManifestStatus{model.ManifestFailed, model.ManifestSucceeded},
+			"$nin": terminalManifestStatuses,
 		},
 	}
 
-	return mongo.Repo.UpdateOne(
-		ctx,
-		&model.Manifest{},
-		filter,
-		bson.M{
+	// 终态不再变更，重复的终态回调不会再次产生事件（回写 commit status 与自动部署由 outbox 订阅者处理）
+	return emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		m, err := s.findAndUpdate(ctx, filter, bson.M{
 			"$set": bson.M{
 				"status":     status,
 				"updated_at": time.Now(),
 			},
-		},
+		})
+		if m == nil || err != nil {
+			return nil, err
+		}
+		return []events.DomainEvent{events.ManifestStatusChangedEvent{
+			ManifestID:    m.GetID(),
+			ApplicationID: m.ApplicationId,
+			Name:          m.Name,
+			Status:        m.Status,
+		}}, nil
+	})
+}
+
+// findAndUpdate 更新并返回更新后的 Manifest，未命中时返回 nil
+func (s *manifestService) findAndUpdate(ctx context.Context, filter, update bson.M) (*domain.Manifest, error) {
+	m := &domain.Manifest{}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := store.Collection(&model.Manifest{}).FindOneAndUpdate(ctx, filter, update, opts).Decode(m)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Render 渲染 Manifest 部署到 env 时的 Kubernetes 资源与 Argo CD Application，不访问集群
+func (s *manifestService) Render(ctx context.Context, id primitive.ObjectID, env string) (*render.Bundle, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "render_manifest"),
+		zap.String("manifest_id", id.Hex()),
+		zap.String("env", env),
 	)
+
+	m, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := ApplicationService.Get(ctx, m.ApplicationId)
+	if err != nil {
+		log.Error("get application failed", zap.Error(err))
+		return nil, err
+	}
+
+	if env == "" {
+		env = defaultEnv
+	}
+	if err := domain.ValidateEnv(env); err != nil {
+		return nil, err
+	}
+	job := &model.Job{
+		ApplicationId:   app.GetID(),
+		ApplicationName: app.Name,
+		ProjectName:     app.ProjectName,
+		ManifestID:      m.GetID(),
+		ManifestName:    m.Name,
+		Env:             env,
+	}
+
+	bundle, err := JobService.renderBundle(ctx, job, app, m)
+	if err != nil {
+		log.Error("render manifest failed", zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("manifest rendered", zap.Int("resources", len(bundle.Resources)))
+	return bundle, nil
+}
+
+// setPipelineParam 覆盖 PipelineRun 中已有的 string 参数
+func setPipelineParam(pr *v1.PipelineRun, name, value string) {
+	for i := range pr.Spec.Params {
+		if pr.Spec.Params[i].Name == name {
+			pr.Spec.Params[i].Value.StringVal = value
+		}
+	}
+}
+
+// ensureVersionAvailable 同一 Application 下未失败的 Manifest 不允许重复版本
+func (s *manifestService) ensureVersionAvailable(ctx context.Context, appID primitive.ObjectID, version string) error {
+	manifests, err := s.List(ctx, bson.M{
+		"application_id": appID,
+		"version":        version,
+		"deleted_at":     bson.M{"$exists": false},
+		"status":         bson.M{"$ne": model.ManifestFailed},
+	})
+	if err != nil {
+		return err
+	}
+	if len(manifests) > 0 {
+		return fmt.Errorf("%w: %s", ErrVersionExists, version)
+	}
+	return nil
+}
+
+// FindRollbackTarget 按 semver 优先级选择低于 activeID 版本的最高已成功版本
+func (s *manifestService) FindRollbackTarget(ctx context.Context, app *domain.Application, activeID primitive.ObjectID) (*domain.Manifest, error) {
+	logger := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "find_rollback_target"),
+		zap.String("application_id", app.GetID().Hex()),
+	)
+
+	active, err := s.Get(ctx, activeID)
+	if err != nil {
+		return nil, err
+	}
+	if active.Version == "" {
+		logger.Warn("active manifest has no version")
+		return nil, ErrNoRollbackTarget
+	}
+
+	candidates, err := s.List(ctx, bson.M{
+		"application_id": app.GetID(),
+		"status":         model.ManifestSucceeded,
+		"version":        bson.M{"$exists": true, "$ne": ""},
+		"deleted_at":     bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var target *domain.Manifest
+	for i := range candidates {
+		c := &candidates[i]
+		if cmp, err := domain.CompareVersions(c.Version, active.Version); err != nil || cmp >= 0 {
+			continue
+		}
+		if target == nil {
+			target = c
+			continue
+		}
+		if cmp, err := domain.CompareVersions(c.Version, target.Version); err == nil && cmp > 0 {
+			target = c
+		}
+	}
+	if target == nil {
+		return nil, ErrNoRollbackTarget
+	}
+
+	logger.Info("rollback target selected",
+		zap.String("active_version", active.Version),
+		zap.String("target_version", target.Version),
+		zap.String("target_manifest", target.Name),
+	)
+	return target, nil
 }
 
 func BuildStepsFromPipeline(pipeline *v1.Pipeline) []model.ManifestStep {
@@ -325,9 +608,9 @@ func (s *manifestService) BindTaskRun(ctx context.Context, pipelineID, taskName,
 	)
 }
 
-func (s *manifestService) GetManifestByPipelineID(ctx context.Context, pipelineID string) (*model.Manifest, error) {
+func (s *manifestService) GetManifestByPipelineID(ctx context.Context, pipelineID string) (*domain.Manifest, error) {
 
-	var m model.Manifest
+	var m domain.Manifest
 	err := mongo.Repo.FindOne(
 		ctx,
 		&m,
@@ -339,7 +622,8 @@ func (s *manifestService) GetManifestByPipelineID(ctx context.Context, pipelineI
 	return &m, nil
 }
 
-func (s *manifestService) Patch(ctx context.Context, id primitive.ObjectID, manifest *model.PatchManifestRequest) error {
+// Patch 部分更新 Manifest；状态变更在同一事务内写入 ManifestStatusChanged 事件，终态不再变更
+func (s *manifestService) Patch(ctx context.Context, id primitive.ObjectID, patch *domain.ManifestPatch) error {
 
 	logger := logging.LoggerWithContext(ctx)
 
@@ -347,34 +631,49 @@ func (s *manifestService) Patch(ctx context.Context, id primitive.ObjectID, mani
 		zap.String("manifest_id", id.Hex()),
 	)
 
-	// 1️⃣ 构造 $set
-	set := bson.M{}
-
-	if manifest.Digest != "" {
-		set["digest"] = manifest.Digest
-	}
-
-	if manifest.CommitHash != "" {
-		set["commit_hash"] = manifest.CommitHash
-	}
-
-	// 2️⃣ 无有效字段直接返回（非常关键）
-	if len(set) == 0 {
+	// 无有效字段直接返回（非常关键）
+	if patch.IsEmpty() {
 		logger.Warn("patch manifest skipped: no valid fields",
 			zap.String("manifest_id", id.Hex()),
 		)
 		return nil
 	}
 
-	set["updated_at"] = time.Now()
-
-	// 3️⃣ 执行 Patch
-	err := mongo.Repo.UpdateOne(
-		ctx,
-		&model.Manifest{},
-		bson.M{"_id": id},
-		bson.M{"$set": set},
-	)
+	var set bson.M
+	err := emit(ctx, func(ctx context.Context) ([]events.DomainEvent, error) {
+		current := &domain.Manifest{}
+		if err := mongo.Repo.FindByID(ctx, current, id); err != nil {
+			return nil, err
+		}
+
+		set = bson.M{}
+		if patch.Digest != "" {
+			set["digest"] = patch.Digest
+		}
+		if patch.CommitHash != "" {
+			set["commit_hash"] = patch.CommitHash
+		}
+
+		filter := bson.M{"_id": id}
+		if patch.Status != "" && patch.Status != current.Status && !slices.Contains(terminalManifestStatuses, current.Status) {
+			set["status"] = patch.Status
+			// 按读取时的状态匹配，避免覆盖并发写入的终态
+			filter["status"] = current.Status
+		}
+		if len(set) == 0 {
+			return nil, nil
+		}
+		set["updated_at"] = time.Now()
+
+		m, err := s.findAndUpdate(ctx, filter, bson.M{"$set": set})
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			return nil, ErrManifestStatusConflict
+		}
+		return manifestStatusEvents(current, m), nil
+	})
 
 	if err != nil {
 		logger.Error("patch manifest failed",
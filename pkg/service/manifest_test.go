@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+)
+
+func manifestWith(status model.ManifestStatus, steps ...model.ManifestStep) *domain.Manifest {
+	m := &domain.Manifest{}
+	m.Status = status
+	m.Steps = steps
+	return m
+}
+
+func TestManifestStatusEvents(t *testing.T) {
+	before := manifestWith(model.ManifestRunning,
+		model.ManifestStep{TaskName: "build", Status: model.StepRunning},
+		model.ManifestStep{TaskName: "push", Status: model.StepPending},
+	)
+	after := manifestWith(model.ManifestSucceeded,
+		model.ManifestStep{TaskName: "build", Status: model.StepSucceeded},
+		model.ManifestStep{TaskName: "push", Status: model.StepPending},
+	)
+
+	evs := manifestStatusEvents(before, after)
+	if len(evs) != 2 {
+		t.Fatalf("events = %v, want step + manifest", evs)
+	}
+	step, ok := evs[0].(events.StepStatusChangedEvent)
+	if !ok || step.TaskName != "build" || step.Status != model.StepSucceeded {
+		t.Fatalf("first event = %#v, want build Succeeded", evs[0])
+	}
+	status, ok := evs[1].(events.ManifestStatusChangedEvent)
+	if !ok || status.Status != model.ManifestSucceeded {
+		t.Fatalf("second event = %#v, want manifest Succeeded", evs[1])
+	}
+
+	if evs := manifestStatusEvents(after, after); len(evs) != 0 {
+		t.Fatalf("unchanged manifest produced events: %v", evs)
+	}
+}
+
+func TestKeepTerminal(t *testing.T) {
+	current := manifestWith(model.ManifestFailed,
+		model.ManifestStep{TaskName: "build", Status: model.StepFailed, Message: "exit 1"},
+		model.ManifestStep{TaskName: "push", Status: model.StepPending},
+	)
+	m := manifestWith(model.ManifestRunning,
+		model.ManifestStep{TaskName: "build", Status: model.StepRunning},
+		model.ManifestStep{TaskName: "push", Status: model.StepRunning},
+	)
+
+	keepTerminal(current, m)
+
+	if m.Status != model.ManifestFailed {
+		t.Fatalf("status = %s, want Failed kept", m.Status)
+	}
+	if s := m.GetStep("build"); s.Status != model.StepFailed || s.Message != "exit 1" {
+		t.Fatalf("build = %#v, want terminal step kept", s)
+	}
+	if s := m.GetStep("push"); s.Status != model.StepRunning {
+		t.Fatalf("push = %#v, want non-terminal step updated", s)
+	}
+	if evs := manifestStatusEvents(current, m); len(evs) != 1 {
+		t.Fatalf("events = %v, want only push step", evs)
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	appv1 "github.com/argoproj/argo-cd/v3/pkg/apis/application/v1alpha1"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// inFlightQueryTimeout 统计进行中 Job 的单次查询超时，避免拖慢 /metrics
+const inFlightQueryTimeout = 5 * time.Second
+
+// inFlightJobStatuses 尚未结束的 Job 状态
+var inFlightJobStatuses = []model.JobStatus{
+	model.JobPending, model.JobRunning, model.JobSyncing, model.JobRollingBack,
+}
+
+// metricEvents 业务指标订阅的领域事件
+var metricEvents = []events.Type{
+	events.ManifestStepChanged,
+	events.ManifestStatusChanged,
+	events.JobStatusChanged,
+}
+
+// durationBuckets 构建 / 同步耗时分桶（秒）
+var durationBuckets = []float64{5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+var BusinessMetrics = NewBusinessMetrics()
+
+type businessMetrics struct {
+	stepDuration  metric.Float64Histogram
+	buildDuration metric.Float64Histogram
+	jobOutcomes   metric.Int64Counter
+	argoSync      metric.Float64Histogram
+}
+
+func NewBusinessMetrics() *businessMetrics {
+	meter := otel.Meter(ServiceName)
+	m := &businessMetrics{}
+
+	// 创建失败时 OTel 返回 no-op instrument，不影响业务
+	m.stepDuration, _ = meter.Float64Histogram("devflow.manifest.step.duration",
+		metric.WithDescription("Duration of finished manifest build steps"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	m.buildDuration, _ = meter.Float64Histogram("devflow.manifest.build.duration",
+		metric.WithDescription("Duration from manifest creation to a final build status"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	m.jobOutcomes, _ = meter.Int64Counter("devflow.job.outcomes",
+		metric.WithDescription("Finished jobs by type, env and status"))
+	m.argoSync, _ = meter.Float64Histogram("devflow.argo.sync.duration",
+		metric.WithDescription("Duration of completed Argo CD sync operations"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+
+	inFlight, err := meter.Int64ObservableGauge("devflow.job.in_flight",
+		metric.WithDescription("Jobs that have not reached a final status, by type and env"))
+	if err == nil {
+		_, _ = meter.RegisterCallback(observeInFlightJobs(inFlight), inFlight)
+	}
+	return m
+}
+
+// HandleEvent outbox 订阅者：按领域事件记录构建与部署指标，每个事件只由一个实例处理
+func (m *businessMetrics) HandleEvent(ctx context.Context, msg OutboxMessage) error {
+	switch e := msg.Event.(type) {
+	case *events.StepStatusChangedEvent:
+		if !isFinalStep(e.Status) || e.StartTime == nil || e.EndTime == nil {
+			return nil
+		}
+		m.stepDuration.Record(ctx, e.EndTime.Sub(*e.StartTime).Seconds(), metric.WithAttributes(
+			attribute.String("task", e.TaskName),
+			attribute.String("status", string(e.Status)),
+		))
+	case *events.ManifestStatusChangedEvent:
+		if e.Status != model.ManifestSucceeded && e.Status != model.ManifestFailed {
+			return nil
+		}
+		manifest, err := ManifestService.Get(ctx, e.ManifestID)
+		if err != nil {
+			return nil
+		}
+		m.buildDuration.Record(ctx, msg.OccurredAt.Sub(manifest.CreatedAt).Seconds(), metric.WithAttributes(
+			attribute.String("status", string(e.Status)),
+		))
+	case *events.JobStatusChangedEvent:
+		if !isFinalJob(e.Status) {
+			return nil
+		}
+		m.jobOutcomes.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("type", e.Type),
+			attribute.String("env", e.Env),
+			attribute.String("status", string(e.Status)),
+		))
+	}
+	return nil
+}
+
+// RecordArgoSync Argo CD 同步操作从未完成变为完成时记录耗时；各实例的 informer 都会记录同一次同步，查询时只取单个实例
+func (m *businessMetrics) RecordArgoSync(ctx context.Context, oldApp, newApp *appv1.Application) {
+	op := newApp.Status.OperationState
+	if op == nil || !op.Phase.Completed() || op.FinishedAt == nil {
+		return
+	}
+	if prev := oldApp.Status.OperationState; prev != nil && prev.Phase.Completed() && prev.StartedAt.Equal(&op.StartedAt) {
+		return
+	}
+	m.argoSync.Record(ctx, op.FinishedAt.Sub(op.StartedAt.Time).Seconds(), metric.WithAttributes(
+		attribute.String("application", newApp.Name),
+		attribute.String("phase", string(op.Phase)),
+	))
+}
+
+// observeInFlightJobs 抓取时按 type / env 聚合未结束的 Job；统计的是全局数量，每个副本上报相同的值，
+// 查询时应取 max（如 max without (instance, pod) (devflow_job_in_flight)），不能跨实例 sum
+func observeInFlightJobs(gauge metric.Int64ObservableGauge) metric.Callback {
+	return func(ctx context.Context, o metric.Observer) error {
+		ctx, cancel := context.WithTimeout(ctx, inFlightQueryTimeout)
+		defer cancel()
+
+		cursor, err := store.Collection(&model.Job{}).Aggregate(ctx, bson.A{
+			bson.M{"$match": bson.M{
+				"deleted_at": bson.M{"$exists": false},
+				"status":     bson.M{"$in": inFlightJobStatuses},
+			}},
+			bson.M{"$group": bson.M{
+				"_id":   bson.M{"type": "$type", "env": "$env"},
+				"count": bson.M{"$sum": 1},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+
+		var rows []struct {
+			ID struct {
+				Type string `bson:"type"`
+				Env  string `bson:"env"`
+			} `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cursor.All(ctx, &rows); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			o.ObserveInt64(gauge, r.Count, metric.WithAttributes(
+				attribute.String("type", r.ID.Type),
+				attribute.String("env", r.ID.Env),
+			))
+		}
+		return nil
+	}
+}
+
+func isFinalStep(s model.StepStatus) bool {
+	return s == model.StepSucceeded || s == model.StepFailed
+}
+
+func isFinalJob(s model.JobStatus) bool {
+	switch s {
+	case model.JobSucceeded, model.JobFailed, model.JobSyncFailed, model.JobRolledBack:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/notify"
+	"github.com/bsonger/devflow/pkg/secret"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// notificationEvents 触发通知的领域事件
+var notificationEvents = []events.Type{
+	events.ManifestCreated,
+	events.ManifestStatusChanged,
+	events.JobCreated,
+	events.JobStatusChanged,
+}
+
+var NotificationService = NewNotificationService()
+
+type notificationService struct{}
+
+func NewNotificationService() *notificationService {
+	return &notificationService{}
+}
+
+// Create 校验并保存渠道，rawURL 为 slack / webhook 地址明文
+func (s *notificationService) Create(ctx context.Context, ch *domain.NotificationChannel, rawURL string) (primitive.ObjectID, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "create_notification_channel"),
+		zap.String("name", ch.Name),
+		zap.String("type", string(ch.Type)),
+	)
+
+	if err := ch.Validate(rawURL, false); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := s.encryptURL(ctx, ch, rawURL); err != nil {
+		log.Error("encrypt channel url failed", zap.Error(err))
+		return primitive.NilObjectID, err
+	}
+
+	ch.WithCreateDefault()
+	if err := mongo.Repo.Create(ctx, ch); err != nil {
+		log.Error("create notification channel failed", zap.Error(err))
+		return primitive.NilObjectID, err
+	}
+
+	log.Info("notification channel created", zap.String("channel_id", ch.GetID().Hex()))
+	return ch.GetID(), nil
+}
+
+func (s *notificationService) Get(ctx context.Context, id primitive.ObjectID) (*domain.NotificationChannel, error) {
+	ch := &domain.NotificationChannel{}
+	if err := mongo.Repo.FindByID(ctx, ch, id); err != nil {
+		return nil, err
+	}
+	if ch.DeletedAt != nil {
+		return nil, mongoDriver.ErrNoDocuments
+	}
+	return ch, nil
+}
+
+// Update 替换渠道配置；类型不变且 rawURL 为空或为 domain.MaskedValue 时保留原地址
+func (s *notificationService) Update(ctx context.Context, ch *domain.NotificationChannel, rawURL string) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "update_notification_channel"),
+		zap.String("channel_id", ch.GetID().Hex()),
+	)
+
+	current, err := s.Get(ctx, ch.GetID())
+	if err != nil {
+		return err
+	}
+
+	keepURL := (rawURL == "" || rawURL == domain.MaskedValue) && current.Type == ch.Type && current.URL != nil
+	if err := ch.Validate(rawURL, keepURL); err != nil {
+		return err
+	}
+	if keepURL {
+		ch.URL = current.URL
+	} else if err := s.encryptURL(ctx, ch, rawURL); err != nil {
+		log.Error("encrypt channel url failed", zap.Error(err))
+		return err
+	}
+	ch.WithUpdateDefault()
+
+	update := primitive.M{
+		"$set": primitive.M{
+			"name":         ch.Name,
+			"type":         ch.Type,
+			"project_name": ch.ProjectName,
+			"url":          ch.URL,
+			"recipients":   ch.Recipients,
+			"rules":        ch.Rules,
+			"template":     ch.Template,
+			"enabled":      ch.Enabled,
+			"updated_at":   ch.UpdatedAt,
+		},
+	}
+	if err := mongo.Repo.UpdateByID(ctx, &domain.NotificationChannel{}, ch.GetID(), update); err != nil {
+		log.Error("update notification channel failed", zap.Error(err))
+		return err
+	}
+
+	log.Info("notification channel updated")
+	return nil
+}
+
+func (s *notificationService) encryptURL(ctx context.Context, ch *domain.NotificationChannel, rawURL string) error {
+	ch.URL = nil
+	if ch.Type == notify.Email {
+		return nil
+	}
+	encrypted, err := secret.Encrypt(ctx, []byte(rawURL))
+	if err != nil {
+		return err
+	}
+	ch.URL = encrypted
+	return nil
+}
+
+func (s *notificationService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	update := primitive.M{
+		"$set": primitive.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+	}
+	if err := mongo.Repo.UpdateByID(ctx, &domain.NotificationChannel{}, id, update); err != nil {
+		logging.LoggerWithContext(ctx).Error("delete notification channel failed", zap.String("channel_id", id.Hex()), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *notificationService) List(ctx context.Context, filter primitive.M) ([]domain.NotificationChannel, error) {
+	var channels []domain.NotificationChannel
+	if err := mongo.Repo.List(ctx, &domain.NotificationChannel{}, filter, &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// Test 向渠道发送一条测试消息，忽略路由规则与 enabled
+func (s *notificationService) Test(ctx context.Context, id primitive.ObjectID) error {
+	ch, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	provider, err := s.provider(ctx, ch)
+	if err != nil {
+		return err
+	}
+	return provider.Send(ctx, &notify.Message{
+		Subject: "devflow test notification",
+		Text:    fmt.Sprintf("Channel %s (%s) is configured correctly.", ch.Name, ch.Type),
+	})
+}
+
+func (s *notificationService) provider(ctx context.Context, ch *domain.NotificationChannel) (notify.Provider, error) {
+	target := notify.Target{Recipients: ch.Recipients}
+	if ch.URL != nil {
+		raw, err := secret.Decrypt(ctx, ch.URL)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt channel url: %w", err)
+		}
+		target.URL = string(raw)
+	}
+	return notify.NewProvider(ch.Type, target, nil)
+}
+
+// HandleEvent outbox 订阅者：按路由规则向匹配的渠道发送通知。
+// 仅当所有匹配渠道都失败时返回 error 触发重试，部分失败只记录日志，避免已送达的渠道收到重复消息
+func (s *notificationService) HandleEvent(ctx context.Context, msg OutboxMessage) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "notify"),
+		zap.String("event_id", msg.ID.Hex()),
+		zap.String("event_type", string(msg.Event.EventType())),
+	)
+
+	channels, err := s.List(ctx, primitive.M{
+		"enabled":    true,
+		"deleted_at": primitive.M{"$exists": false},
+	})
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+
+	data, err := s.templateData(ctx, msg.Event)
+	if err != nil {
+		return err
+	}
+
+	var (
+		sent int
+		errs []error
+	)
+	for i := range channels {
+		ch := &channels[i]
+		if !ch.Match(data) {
+			continue
+		}
+
+		err := s.send(ctx, ch, data)
+		if err != nil {
+			log.Warn("send notification failed", zap.String("channel", ch.Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", ch.Name, err))
+			continue
+		}
+		sent++
+	}
+
+	if sent == 0 && len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+func (s *notificationService) send(ctx context.Context, ch *domain.NotificationChannel, data *notify.TemplateData) error {
+	message, err := notify.Render(data, ch.Template)
+	if err != nil {
+		return err
+	}
+	provider, err := s.provider(ctx, ch)
+	if err != nil {
+		return err
+	}
+	return provider.Send(ctx, message)
+}
+
+// templateData 按事件加载 Application / Manifest / Job，填充模板字段
+func (s *notificationService) templateData(ctx context.Context, ev events.DomainEvent) (*notify.TemplateData, error) {
+	data := &notify.TemplateData{Event: ev.EventType()}
+
+	var manifestID primitive.ObjectID
+	switch e := ev.(type) {
+	case *events.ManifestCreatedEvent:
+		manifestID = e.ManifestID
+		data.Status = string(model.ManifestPending)
+	case *events.ManifestStatusChangedEvent:
+		manifestID = e.ManifestID
+		data.Status = string(e.Status)
+	case *events.JobCreatedEvent:
+		manifestID = e.ManifestID
+		data.JobID, data.JobType, data.Env = e.JobID.Hex(), e.Type, e.Env
+		data.Status = string(model.JobPending)
+	case *events.JobStatusChangedEvent:
+		manifestID = e.ManifestID
+		data.JobID, data.JobType, data.Env = e.JobID.Hex(), e.Type, e.Env
+		data.Status = string(e.Status)
+	default:
+		return nil, fmt.Errorf("no notification for %q", ev.EventType())
+	}
+
+	app := &model.Application{}
+	if err := mongo.Repo.FindByID(ctx, app, ev.Application()); err != nil {
+		return nil, fmt.Errorf("load application: %w", err)
+	}
+	data.Project, data.Application = app.ProjectName, app.Name
+
+	// Uninstall 与未指定 Manifest 的 Rollback Job 不关联 Manifest，模板中 Manifest 字段为空
+	if !manifestID.IsZero() {
+		m, err := ManifestService.Get(ctx, manifestID)
+		if err != nil {
+			return nil, fmt.Errorf("load manifest: %w", err)
+		}
+		data.ManifestName = m.Name
+		data.Branch = m.Branch
+		data.CommitHash = m.CommitHash
+		data.Digest = m.Digest
+		data.ManifestURL = notify.Link("/api/v1/manifests/" + manifestID.Hex())
+	}
+	if data.JobID != "" {
+		data.JobURL = notify.Link("/api/v1/jobs/" + data.JobID)
+	}
+	return data, nil
+}
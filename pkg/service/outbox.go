@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	// outboxPollInterval 无唤醒时扫描待投递 / 待重试事件的周期
+	outboxPollInterval = 2 * time.Second
+	// outboxLease 事件的占用时间，每个订阅者调用前续租，须大于 outboxHandlerTimeout；超时未续租的事件可被其他实例重新领取
+	outboxLease = time.Minute
+	// outboxHandlerTimeout 单个订阅者处理超时
+	outboxHandlerTimeout = 30 * time.Second
+	outboxMaxAttempts    = 10
+	outboxBaseBackoff    = time.Second
+	outboxMaxBackoff     = 5 * time.Minute
+)
+
+// OutboxMessage 投递给订阅者的领域事件，ID 可作为幂等键
+type OutboxMessage struct {
+	ID         primitive.ObjectID
+	OccurredAt time.Time
+	Attempt    int
+	Event      events.DomainEvent
+}
+
+// OutboxHandler 订阅者返回 error 时该事件按退避重试，已成功的订阅者不会重复收到
+type OutboxHandler func(ctx context.Context, msg OutboxMessage) error
+
+type outboxSubscriber struct {
+	name    string
+	types   map[events.Type]bool
+	handler OutboxHandler
+}
+
+func (s outboxSubscriber) accepts(t events.Type) bool {
+	return len(s.types) == 0 || s.types[t]
+}
+
+var errOutboxLeaseLost = errors.New("outbox lease lost")
+
+var OutboxService = &outboxService{wake: make(chan struct{}, 1)}
+
+type outboxService struct {
+	mu          sync.RWMutex
+	subscribers []outboxSubscriber
+	wake        chan struct{}
+	defaults    sync.Once
+}
+
+// Subscribe 注册进程内订阅者，types 为空表示订阅全部事件；name 用于记录投递进度，必须稳定且唯一
+func (s *outboxService) Subscribe(name string, handler OutboxHandler, types ...events.Type) {
+	sub := outboxSubscriber{name: name, handler: handler}
+	if len(types) > 0 {
+		sub.types = map[events.Type]bool{}
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, sub)
+}
+
+// emit 在同一事务内执行 fn 并将其返回的领域事件写入 outbox，提交后唤醒分发器
+func emit(ctx context.Context, fn func(ctx context.Context) ([]events.DomainEvent, error)) error {
+	err := store.WithTransaction(ctx, func(ctx context.Context) error {
+		evs, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		return OutboxService.append(ctx, evs...)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, wake := range []chan struct{}{OutboxService.wake, streamWake} {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *outboxService) append(ctx context.Context, evs ...events.DomainEvent) error {
+	if len(evs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	docs := make([]interface{}, 0, len(evs))
+	for _, ev := range evs {
+		payload, err := bson.Marshal(ev)
+		if err != nil {
+			return fmt.Errorf("marshal %s event: %w", ev.EventType(), err)
+		}
+		resource, aggregateID := ev.Aggregate()
+
+		doc := &domain.OutboxEvent{
+			Type:          ev.EventType(),
+			AggregateType: resource,
+			AggregateID:   aggregateID,
+			ApplicationID: ev.Application(),
+			Payload:       payload,
+			Status:        domain.OutboxPending,
+			NextAttemptAt: now,
+		}
+		doc.ID = primitive.NewObjectID()
+		doc.WithCreateDefault()
+		docs = append(docs, doc)
+	}
+
+	_, err := store.Collection(&domain.OutboxEvent{}).InsertMany(ctx, docs)
+	return err
+}
+
+// StartOutboxDispatcher 启动分发器：写入后立即投递，失败的事件按指数退避重试，ctx 取消后退出
+func StartOutboxDispatcher(ctx context.Context) {
+	log := logging.Logger.With(zap.String("component", "outbox_dispatcher"))
+
+	OutboxService.defaults.Do(registerOutboxSubscribers)
+	log.Info("outbox dispatcher started")
+
+	goWorker(func() {
+		ticker := time.NewTicker(outboxPollInterval)
+		defer ticker.Stop()
+
+		for {
+			OutboxService.drain(ctx)
+
+			select {
+			case <-ctx.Done():
+				log.Info("outbox dispatcher stopped")
+				return
+			case <-ticker.C:
+			case <-OutboxService.wake:
+			}
+		}
+	})
+}
+
+// registerOutboxSubscribers 内置订阅者
+func registerOutboxSubscribers() {
+	OutboxService.Subscribe("commit_status", CommitStatusService.HandleEvent,
+		events.ManifestStatusChanged, events.JobStatusChanged)
+	OutboxService.Subscribe("auto_deploy", AutoDeployService.HandleEvent,
+		events.ManifestStatusChanged)
+	OutboxService.Subscribe("webhook", WebhookSubscriptionService.HandleEvent)
+	OutboxService.Subscribe("notification", NotificationService.HandleEvent, notificationEvents...)
+	OutboxService.Subscribe("metrics", BusinessMetrics.HandleEvent, metricEvents...)
+}
+
+// drain 依次领取并投递到期事件，直到没有可领取的事件
+func (s *outboxService) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		rec, err := s.claim(ctx)
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return
+		}
+		if err != nil {
+			logging.LoggerWithContext(ctx).Error("claim outbox event failed", zap.Error(err))
+			return
+		}
+		s.dispatch(ctx, rec)
+	}
+}
+
+// claim 领取一条到期且未被占用的事件
+func (s *outboxService) claim(ctx context.Context) (*domain.OutboxEvent, error) {
+	filter, update := outboxClaim(time.Now())
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	rec := &domain.OutboxEvent{}
+	err := store.Collection(rec).FindOneAndUpdate(ctx, filter, update, opts).Decode(rec)
+	return rec, err
+}
+
+// outboxClaim 领取条件：Pending、已到重试时间、未被占用或租约已过期；领取后占用 outboxLease
+func outboxClaim(now time.Time) (filter, update bson.M) {
+	filter = bson.M{
+		"status":          domain.OutboxPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"locked_until": bson.M{"$exists": false}},
+			{"locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update = bson.M{"$set": bson.M{"locked_until": now.Add(outboxLease)}}
+	return filter, update
+}
+
+func (s *outboxService) dispatch(ctx context.Context, rec *domain.OutboxEvent) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "dispatch_outbox_event"),
+		zap.String("event_id", rec.ID.Hex()),
+		zap.String("event_type", string(rec.Type)),
+	)
+
+	ev, err := events.NewDomainEvent(rec.Type)
+	if err == nil {
+		err = bson.Unmarshal(rec.Payload, ev)
+	}
+	if err != nil {
+		// 无法解码的事件重试也不会成功
+		log.Error("decode outbox event failed", zap.Error(err))
+		s.finish(ctx, rec, nil, err, true)
+		return
+	}
+
+	s.mu.RLock()
+	subscribers := pendingSubscribers(rec, s.subscribers)
+	s.mu.RUnlock()
+
+	msg := OutboxMessage{ID: rec.ID, OccurredAt: rec.CreatedAt, Attempt: rec.Attempts + 1, Event: ev}
+
+	var (
+		delivered []string
+		errs      []string
+	)
+	for _, sub := range subscribers {
+		if err := s.renew(ctx, rec); err != nil {
+			// 续租失败（租约已被其他实例领取或 Mongo 不可用）时停止投递，只记录已成功的订阅者，由下一次领取继续
+			log.Warn("renew outbox lease failed", zap.Error(err))
+			s.recordDelivered(ctx, rec, delivered)
+			return
+		}
+		if err := s.invoke(ctx, sub, msg); err != nil {
+			log.Warn("outbox subscriber failed", zap.String("subscriber", sub.name), zap.Int("attempt", msg.Attempt), zap.Error(err))
+			errs = append(errs, sub.name+": "+err.Error())
+			continue
+		}
+		delivered = append(delivered, sub.name)
+	}
+
+	if len(errs) > 0 {
+		s.finish(ctx, rec, delivered, errors.New(strings.Join(errs, "; ")), false)
+		return
+	}
+	s.finish(ctx, rec, delivered, nil, false)
+}
+
+// pendingSubscribers 订阅该事件类型且尚未成功处理的订阅者
+func pendingSubscribers(rec *domain.OutboxEvent, subscribers []outboxSubscriber) []outboxSubscriber {
+	var pending []outboxSubscriber
+	for _, sub := range subscribers {
+		if sub.accepts(rec.Type) && !slices.Contains(rec.Delivered, sub.name) {
+			pending = append(pending, sub)
+		}
+	}
+	return pending
+}
+
+// recordDelivered 只追加已成功的订阅者，不改变状态与租约
+func (s *outboxService) recordDelivered(ctx context.Context, rec *domain.OutboxEvent, delivered []string) {
+	if len(delivered) == 0 {
+		return
+	}
+	update := bson.M{"$addToSet": bson.M{"delivered": bson.M{"$each": delivered}}}
+	if _, err := store.Collection(rec).UpdateByID(ctx, rec.ID, update); err != nil {
+		logging.LoggerWithContext(ctx).Error("update outbox event failed",
+			zap.String("event_id", rec.ID.Hex()),
+			zap.Error(err),
+		)
+	}
+}
+
+// renew 续租当前事件；locked_until 已被其他实例改写时返回 errOutboxLeaseLost
+func (s *outboxService) renew(ctx context.Context, rec *domain.OutboxEvent) error {
+	filter, update, lease := outboxRenew(rec, time.Now())
+	res, err := store.Collection(rec).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errOutboxLeaseLost
+	}
+	rec.LockedUntil = &lease
+	return nil
+}
+
+// outboxRenew 按领取时的 locked_until 匹配，租约被其他实例重新领取后不再命中
+func outboxRenew(rec *domain.OutboxEvent, now time.Time) (filter, update bson.M, lease time.Time) {
+	// Mongo 只保存毫秒精度，截断后才能在下一次续租时按值匹配
+	lease = now.Add(outboxLease).Truncate(time.Millisecond)
+	filter = bson.M{"_id": rec.ID, "locked_until": rec.LockedUntil}
+	update = bson.M{"$set": bson.M{"locked_until": lease}}
+	return filter, update, lease
+}
+
+// invoke 调用订阅者，panic 视为失败
+func (s *outboxService) invoke(ctx context.Context, sub outboxSubscriber, msg OutboxMessage) (err error) {
+	hctx, cancel := context.WithTimeout(ctx, outboxHandlerTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.handler(hctx, msg)
+}
+
+// finish 记录投递结果并释放租约
+func (s *outboxService) finish(ctx context.Context, rec *domain.OutboxEvent, delivered []string, dispatchErr error, permanent bool) {
+	update := outboxFinish(rec, delivered, dispatchErr, permanent, time.Now())
+	if _, err := store.Collection(rec).UpdateByID(ctx, rec.ID, update); err != nil {
+		logging.LoggerWithContext(ctx).Error("update outbox event failed",
+			zap.String("event_id", rec.ID.Hex()),
+			zap.Error(err),
+		)
+	}
+}
+
+// outboxFinish 全部成功 → Delivered；否则按退避安排重试，超过最大次数或 permanent → Failed
+func outboxFinish(rec *domain.OutboxEvent, delivered []string, dispatchErr error, permanent bool, now time.Time) bson.M {
+	set := bson.M{"updated_at": now}
+	update := bson.M{
+		"$set":   set,
+		"$unset": bson.M{"locked_until": ""},
+	}
+	if len(delivered) > 0 {
+		update["$addToSet"] = bson.M{"delivered": bson.M{"$each": delivered}}
+	}
+
+	attempts := rec.Attempts + 1
+	set["attempts"] = attempts
+	switch {
+	case dispatchErr == nil:
+		set["status"] = domain.OutboxDelivered
+		set["delivered_at"] = now
+	case permanent || attempts >= outboxMaxAttempts:
+		set["status"] = domain.OutboxFailed
+		set["last_error"] = dispatchErr.Error()
+	default:
+		set["last_error"] = dispatchErr.Error()
+		set["next_attempt_at"] = now.Add(expBackoff(outboxBaseBackoff, outboxMaxBackoff, attempts))
+	}
+	return update
+}
+
+// expBackoff 第 n 次失败后的等待时间：base, 2*base, 4*base … 上限 max
+func expBackoff(base, max time.Duration, attempts int) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOutboxClaim(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	filter, update := outboxClaim(now)
+
+	if filter["status"] != domain.OutboxPending {
+		t.Fatalf("status = %v, want Pending", filter["status"])
+	}
+	if got := filter["next_attempt_at"].(bson.M)["$lte"]; got != now {
+		t.Fatalf("next_attempt_at $lte = %v, want %v", got, now)
+	}
+
+	// 未被占用或租约已过期的事件才能被领取
+	or := filter["$or"].([]bson.M)
+	if len(or) != 2 {
+		t.Fatalf("$or = %v, want 2 clauses", or)
+	}
+	if got := or[0]["locked_until"].(bson.M)["$exists"]; got != false {
+		t.Fatalf("first clause = %v, want locked_until missing", or[0])
+	}
+	if got := or[1]["locked_until"].(bson.M)["$lt"]; got != now {
+		t.Fatalf("second clause = %v, want locked_until < now", or[1])
+	}
+
+	if got := update["$set"].(bson.M)["locked_until"]; got != now.Add(outboxLease) {
+		t.Fatalf("locked_until = %v, want %v", got, now.Add(outboxLease))
+	}
+}
+
+func TestOutboxLeaseOutlivesHandler(t *testing.T) {
+	// 订阅者超时前租约不能过期，否则其他实例会重复投递
+	if outboxLease <= outboxHandlerTimeout {
+		t.Fatalf("outboxLease %v must exceed outboxHandlerTimeout %v", outboxLease, outboxHandlerTimeout)
+	}
+}
+
+func TestOutboxRenew(t *testing.T) {
+	locked := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rec := &domain.OutboxEvent{LockedUntil: &locked}
+	rec.ID = primitive.NewObjectID()
+
+	now := locked.Add(10*time.Second + 1234567*time.Nanosecond)
+	filter, update, lease := outboxRenew(rec, now)
+
+	// 按领取时的租约匹配：其他实例重新领取后 locked_until 已变化，续租不再命中
+	if filter["_id"] != rec.ID || filter["locked_until"] != rec.LockedUntil {
+		t.Fatalf("filter = %v, want _id and current locked_until", filter)
+	}
+	if want := now.Add(outboxLease).Truncate(time.Millisecond); !lease.Equal(want) {
+		t.Fatalf("lease = %v, want %v", lease, want)
+	}
+	if lease.Nanosecond()%int(time.Millisecond) != 0 {
+		t.Fatalf("lease %v not truncated to milliseconds", lease)
+	}
+	if got := update["$set"].(bson.M)["locked_until"]; got != lease {
+		t.Fatalf("locked_until = %v, want %v", got, lease)
+	}
+}
+
+func TestOutboxFinish(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	failure := errors.New("boom")
+
+	tests := []struct {
+		name        string
+		attempts    int
+		err         error
+		permanent   bool
+		wantStatus  any
+		wantBackoff time.Duration
+	}{
+		{name: "delivered", err: nil, wantStatus: domain.OutboxDelivered},
+		{name: "first failure retries", err: failure, wantBackoff: outboxBaseBackoff},
+		{name: "third failure backs off", attempts: 2, err: failure, wantBackoff: 4 * outboxBaseBackoff},
+		{name: "last attempt fails", attempts: outboxMaxAttempts - 1, err: failure, wantStatus: domain.OutboxFailed},
+		{name: "permanent fails", err: failure, permanent: true, wantStatus: domain.OutboxFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &domain.OutboxEvent{Attempts: tt.attempts}
+			update := outboxFinish(rec, []string{"webhook"}, tt.err, tt.permanent, now)
+			set := update["$set"].(bson.M)
+
+			if got := set["attempts"]; got != tt.attempts+1 {
+				t.Fatalf("attempts = %v, want %d", got, tt.attempts+1)
+			}
+			if got := set["status"]; got != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", got, tt.wantStatus)
+			}
+			if _, ok := update["$unset"].(bson.M)["locked_until"]; !ok {
+				t.Fatalf("lease not released: %v", update)
+			}
+			if got := update["$addToSet"].(bson.M)["delivered"].(bson.M)["$each"]; len(got.([]string)) != 1 {
+				t.Fatalf("delivered = %v, want [webhook]", got)
+			}
+
+			next, retry := set["next_attempt_at"]
+			if retry != (tt.wantBackoff > 0) {
+				t.Fatalf("next_attempt_at = %v, want retry %v", next, tt.wantBackoff > 0)
+			}
+			if retry && next != now.Add(tt.wantBackoff) {
+				t.Fatalf("next_attempt_at = %v, want %v", next, now.Add(tt.wantBackoff))
+			}
+			if tt.err != nil && set["last_error"] != tt.err.Error() {
+				t.Fatalf("last_error = %v, want %q", set["last_error"], tt.err.Error())
+			}
+		})
+	}
+}
+
+func TestExpBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 5, want: 16 * time.Second},
+		{attempts: 9, want: 256 * time.Second},
+		{attempts: 10, want: 5 * time.Minute},
+		{attempts: 100, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := expBackoff(time.Second, 5*time.Minute, tt.attempts); got != tt.want {
+			t.Errorf("expBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestPendingSubscribers(t *testing.T) {
+	subs := []outboxSubscriber{
+		{name: "all"},
+		{name: "manifest", types: map[events.Type]bool{events.ManifestStatusChanged: true}},
+		{name: "job", types: map[events.Type]bool{events.JobStatusChanged: true}},
+		{name: "done"},
+	}
+	rec := &domain.OutboxEvent{Type: events.ManifestStatusChanged, Delivered: []string{"done"}}
+
+	var names []string
+	for _, sub := range pendingSubscribers(rec, subs) {
+		names = append(names, sub.name)
+	}
+	// 重试时跳过已成功的订阅者
+	if len(names) != 2 || names[0] != "all" || names[1] != "manifest" {
+		t.Fatalf("pending = %v, want [all manifest]", names)
+	}
+}
+
+func TestOutboxInvoke(t *testing.T) {
+	msg := OutboxMessage{ID: primitive.NewObjectID()}
+
+	panicking := outboxSubscriber{name: "panic", handler: func(context.Context, OutboxMessage) error {
+		panic("nil map")
+	}}
+	if err := OutboxService.invoke(context.Background(), panicking, msg); err == nil {
+		t.Fatal("panic in subscriber must be reported as failure")
+	}
+
+	deadline := outboxSubscriber{name: "deadline", handler: func(ctx context.Context, _ OutboxMessage) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("no deadline")
+		}
+		return nil
+	}}
+	if err := OutboxService.invoke(context.Background(), deadline, msg); err != nil {
+		t.Fatalf("invoke = %v, want handler timeout applied", err)
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+const defaultRetentionInterval = time.Hour
+
+// retentionModels 参与定期物理清理的软删除资源
+var retentionModels = []model.MongoModel{
+	&model.Application{},
+	&model.Manifest{},
+	&model.Job{},
+	&model.Configuration{},
+	&domain.Secret{},
+	&domain.WebhookSubscription{},
+	&domain.NotificationChannel{},
+}
+
+// StartRetentionPurger 周期性物理删除 deleted_at 早于 retention 的记录，ctx 取消后退出
+func StartRetentionPurger(ctx context.Context, retention, interval time.Duration) {
+	log := logging.Logger.With(zap.String("component", "retention_purger"))
+
+	if retention <= 0 {
+		log.Info("retention purger disabled")
+		return
+	}
+	if interval <= 0 {
+		interval = defaultRetentionInterval
+	}
+
+	log.Info("retention purger started",
+		zap.Duration("retention", retention),
+		zap.Duration("interval", interval),
+	)
+
+	goWorker(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			PurgeDeleted(ctx, time.Now().Add(-retention))
+			PurgeOutbox(ctx, time.Now().Add(-retention))
+			PurgeWebhookDeliveries(ctx, time.Now().Add(-retention))
+
+			select {
+			case <-ctx.Done():
+				log.Info("retention purger stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// PurgeDeleted 物理删除 deleted_at 早于 before 的记录
+func PurgeDeleted(ctx context.Context, before time.Time) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "purge_deleted"),
+		zap.Time("before", before),
+	)
+
+	filter := bson.M{"deleted_at": bson.M{"$lt": before}}
+
+	// 修订记录没有 deleted_at，随所属 Configuration 一起清理，需在 Configuration 删除前执行
+	if err := purgeConfigurationRevisions(ctx, filter); err != nil {
+		log.Error("purge configuration revisions failed", zap.Error(err))
+	}
+
+	for _, m := range retentionModels {
+		count, err := store.HardDeleteMany(ctx, m, filter)
+		if err != nil {
+			log.Error("purge deleted records failed",
+				zap.String("collection", m.CollectionName()),
+				zap.Error(err),
+			)
+			continue
+		}
+		if count > 0 {
+			log.Info("deleted records purged",
+				zap.String("collection", m.CollectionName()),
+				zap.Int64("count", count),
+			)
+		}
+	}
+}
+
+// purgeConfigurationRevisions 物理删除匹配 filter 的 Configuration 的全部修订
+func purgeConfigurationRevisions(ctx context.Context, filter bson.M) error {
+	ids, err := store.Collection(&model.Configuration{}).Distinct(ctx, "_id", filter)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	count, err := store.HardDeleteMany(ctx, &domain.ConfigurationRevision{}, bson.M{
+		"configuration_id": bson.M{"$in": ids},
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		logging.LoggerWithContext(ctx).Info("configuration revisions purged", zap.Int64("count", count))
+	}
+	return nil
+}
+
+// PurgeOutbox 物理删除 delivered_at 早于 before 的已投递事件，以及最后一次更新早于 before 的失败事件
+func PurgeOutbox(ctx context.Context, before time.Time) {
+	count, err := store.HardDeleteMany(ctx, &domain.OutboxEvent{}, bson.M{
+		"$or": []bson.M{
+			{"status": domain.OutboxDelivered, "delivered_at": bson.M{"$lt": before}},
+			{"status": domain.OutboxFailed, "updated_at": bson.M{"$lt": before}},
+		},
+	})
+	if err != nil {
+		logging.LoggerWithContext(ctx).Error("purge outbox events failed", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logging.LoggerWithContext(ctx).Info("outbox events purged", zap.Int64("count", count))
+	}
+}
+
+// PurgeWebhookDeliveries 物理删除 before 之前已成功或已失败的 Webhook 投递记录
+func PurgeWebhookDeliveries(ctx context.Context, before time.Time) {
+	count, err := store.HardDeleteMany(ctx, &domain.WebhookDelivery{}, bson.M{
+		"$or": []bson.M{
+			{"status": domain.DeliverySucceeded, "delivered_at": bson.M{"$lt": before}},
+			{"status": domain.DeliveryFailed, "updated_at": bson.M{"$lt": before}},
+		},
+	})
+	if err != nil {
+		logging.LoggerWithContext(ctx).Error("purge webhook deliveries failed", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logging.LoggerWithContext(ctx).Info("webhook deliveries purged", zap.Int64("count", count))
+	}
+}
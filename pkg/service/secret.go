@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/client/tekton"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/render"
+	"github.com/bsonger/devflow/pkg/secret"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var SecretService = NewSecretService()
+
+var (
+	ErrSecretNotFound      = errors.New("secret not found")
+	ErrInvalidSecretValue  = errors.New("invalid secret value")
+	ErrInvalidSecretFormat = errors.New("invalid secret format, only sealed is supported")
+	// ErrSecretProjectMismatch Application 只能引用同一项目的 Secret
+	ErrSecretProjectMismatch = errors.New("secret belongs to another project")
+)
+
+const (
+	SecretFormatSealed = "sealed"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "devflow"
+	secretIDLabel  = "devflow.io/secret-id"
+)
+
+type secretService struct{}
+
+func NewSecretService() *secretService {
+	return &secretService{}
+}
+
+// SealedSecret sealed-secrets（bitnami.com/v1alpha1）资源的最小结构
+type SealedSecret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   metav1.ObjectMeta `json:"metadata"`
+	Spec       SealedSecretSpec  `json:"spec"`
+}
+
+type SealedSecretSpec struct {
+	EncryptedData map[string]string `json:"encryptedData"`
+	Template      struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Type     corev1.SecretType `json:"type,omitempty"`
+	} `json:"template"`
+}
+
+// Create 加密 values 后保存 Secret
+func (s *secretService) Create(ctx context.Context, sec *domain.Secret, values map[string]string) (primitive.ObjectID, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "create_secret"),
+		zap.String("secret_name", sec.Name),
+	)
+
+	data, err := s.encryptValues(ctx, values, nil)
+	if err != nil {
+		log.Error("encrypt secret values failed", zap.Error(err))
+		return primitive.NilObjectID, err
+	}
+	sec.Data = data
+	if sec.Type == "" {
+		sec.Type = string(corev1.SecretTypeOpaque)
+	}
+
+	if err := mongo.Repo.Create(ctx, sec); err != nil {
+		log.Error("create secret failed", zap.Error(err))
+		return primitive.NilObjectID, err
+	}
+
+	log.Info("secret created", zap.String("secret_id", sec.GetID().Hex()), zap.Strings("keys", sec.Keys()))
+	return sec.GetID(), nil
+}
+
+func (s *secretService) Get(ctx context.Context, id primitive.ObjectID) (*domain.Secret, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "get_secret"),
+		zap.String("secret_id", id.Hex()),
+	)
+
+	sec := &domain.Secret{}
+	if err := mongo.Repo.FindByID(ctx, sec, id); err != nil {
+		log.Error("get secret failed", zap.Error(err))
+		return nil, err
+	}
+	if sec.DeletedAt != nil {
+		log.Warn("secret already deleted")
+		return nil, mongoDriver.ErrNoDocuments
+	}
+
+	log.Debug("secret fetched", zap.String("secret_name", sec.Name))
+	return sec, nil
+}
+
+// Update 替换 Secret 的 key 集合；值为 domain.MaskedValue 的 key 保留原密文
+func (s *secretService) Update(ctx context.Context, sec *domain.Secret, values map[string]string) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "update_secret"),
+		zap.String("secret_id", sec.GetID().Hex()),
+	)
+
+	current, err := s.Get(ctx, sec.GetID())
+	if err != nil {
+		return err
+	}
+
+	data, err := s.encryptValues(ctx, values, current.Data)
+	if err != nil {
+		log.Error("encrypt secret values failed", zap.Error(err))
+		return err
+	}
+
+	sec.Data = data
+	sec.CreatedAt = current.CreatedAt
+	sec.DeletedAt = current.DeletedAt
+	if sec.Type == "" {
+		sec.Type = current.Type
+	}
+	sec.WithUpdateDefault()
+
+	// data 需要整体替换（删除的 key 不能残留），不能用 $set 合并
+	update := primitive.M{
+		"$set": primitive.M{
+			"name":         sec.Name,
+			"project_name": sec.ProjectName,
+			"type":         sec.Type,
+			"data":         sec.Data,
+			"updated_at":   sec.UpdatedAt,
+		},
+	}
+	if err := mongo.Repo.UpdateByID(ctx, &domain.Secret{}, sec.GetID(), update); err != nil {
+		log.Error("update secret failed", zap.Error(err))
+		return err
+	}
+
+	log.Info("secret updated", zap.Strings("keys", sec.Keys()))
+	return nil
+}
+
+func (s *secretService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "delete_secret"),
+		zap.String("secret_id", id.Hex()),
+	)
+
+	now := time.Now()
+	update := primitive.M{
+		"$set": primitive.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+	}
+
+	if err := mongo.Repo.UpdateByID(ctx, &domain.Secret{}, id, update); err != nil {
+		log.Error("delete secret failed", zap.Error(err))
+		return err
+	}
+
+	log.Info("secret deleted")
+	return nil
+}
+
+func (s *secretService) List(ctx context.Context, filter primitive.M) ([]domain.Secret, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "list_secrets"),
+		zap.Any("filter", filter),
+	)
+
+	var secrets []domain.Secret
+	if err := mongo.Repo.List(ctx, &domain.Secret{}, filter, &secrets); err != nil {
+		log.Error("list secrets failed", zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("secrets listed", zap.Int("count", len(secrets)))
+	return secrets, nil
+}
+
+// ToKubernetesSecret 解密后生成 Kubernetes Secret
+func (s *secretService) ToKubernetesSecret(ctx context.Context, sec *domain.Secret, namespace string) (*corev1.Secret, error) {
+	data := make(map[string][]byte, len(sec.Data))
+	for k, v := range sec.Data {
+		plaintext, err := secret.Decrypt(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key %s: %w", k, err)
+		}
+		data[k] = plaintext
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sec.Name,
+			Namespace: namespace,
+			Labels:    secretLabels(sec),
+		},
+		Type: corev1.SecretType(sec.Type),
+		Data: data,
+	}, nil
+}
+
+// ToSealedSecret 解密后以 sealed-secrets 公钥重新加密，生成可提交到 git 的 SealedSecret
+func (s *secretService) ToSealedSecret(ctx context.Context, sec *domain.Secret, namespace string) (*SealedSecret, error) {
+	out := &SealedSecret{
+		APIVersion: "bitnami.com/v1alpha1",
+		Kind:       "SealedSecret",
+		Metadata: metav1.ObjectMeta{
+			Name:      sec.Name,
+			Namespace: namespace,
+		},
+	}
+	out.Spec.EncryptedData = make(map[string]string, len(sec.Data))
+	out.Spec.Template.Metadata = metav1.ObjectMeta{Name: sec.Name, Namespace: namespace, Labels: secretLabels(sec)}
+	out.Spec.Template.Type = corev1.SecretType(sec.Type)
+
+	for k, v := range sec.Data {
+		plaintext, err := secret.Decrypt(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key %s: %w", k, err)
+		}
+		sealed, err := secret.SealValue(namespace, sec.Name, plaintext)
+		if err != nil {
+			return nil, err
+		}
+		out.Spec.EncryptedData[k] = sealed
+	}
+	return out, nil
+}
+
+// Render 按 format 渲染 Secret，仅输出 SealedSecret；明文 Kubernetes Secret 只在部署时由 Apply 写入集群，不对外返回
+func (s *secretService) Render(ctx context.Context, id primitive.ObjectID, namespace, format string) (any, error) {
+	if format != "" && format != SecretFormatSealed {
+		return nil, ErrInvalidSecretFormat
+	}
+
+	sec, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.ToSealedSecret(ctx, sec, namespace)
+}
+
+// Validate 校验引用的 Secret 均存在、未删除且属于 project
+func (s *secretService) Validate(ctx context.Context, project string, refs map[string][]primitive.ObjectID) error {
+	for env, ids := range refs {
+		for _, id := range ids {
+			if _, err := s.owned(ctx, project, id); err != nil {
+				return fmt.Errorf("%w (env %s)", err, env)
+			}
+		}
+	}
+	return nil
+}
+
+// owned 读取 project 下的 Secret，不存在或属于其他项目时返回对应错误
+func (s *secretService) owned(ctx context.Context, project string, id primitive.ObjectID) (*domain.Secret, error) {
+	sec, err := s.Get(ctx, id)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, id.Hex())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sec.ProjectName != project {
+		return nil, fmt.Errorf("%w: %s", ErrSecretProjectMismatch, id.Hex())
+	}
+	return sec, nil
+}
+
+// Refs 返回 Application 在 env 引用的 Secret，渲染为 Deployment 的 envFrom
+func (s *secretService) Refs(ctx context.Context, app *domain.Application, env string) ([]render.SecretRef, error) {
+	ids := app.SecretIDs[env]
+	refs := make([]render.SecretRef, 0, len(ids))
+	for _, id := range ids {
+		sec, err := s.owned(ctx, app.ProjectName, id)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, render.SecretRef{Name: sec.Name, Revision: strconv.FormatInt(sec.UpdatedAt.UnixMilli(), 10)})
+	}
+	return refs, nil
+}
+
+// Apply 部署前将 project 下引用的 Secret 解密并写入目标 namespace（存在则更新），其他项目的 Secret 拒绝写入
+func (s *secretService) Apply(ctx context.Context, project, namespace string, ids []primitive.ObjectID) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "apply_secrets"),
+		zap.String("project", project),
+		zap.String("namespace", namespace),
+	)
+
+	secrets := tekton.KubeClient.CoreV1().Secrets(namespace)
+	for _, id := range ids {
+		sec, err := s.owned(ctx, project, id)
+		if err != nil {
+			log.Error("load secret failed", zap.String("secret_id", id.Hex()), zap.Error(err))
+			return err
+		}
+
+		desired, err := s.ToKubernetesSecret(ctx, sec, namespace)
+		if err != nil {
+			log.Error("render secret failed", zap.String("secret_name", sec.Name), zap.Error(err))
+			return err
+		}
+
+		current, err := secrets.Get(ctx, desired.Name, metav1.GetOptions{})
+		switch {
+		case k8sErrors.IsNotFound(err):
+			_, err = secrets.Create(ctx, desired, metav1.CreateOptions{})
+		case err == nil:
+			current.Labels = desired.Labels
+			current.Type = desired.Type
+			current.Data = desired.Data
+			_, err = secrets.Update(ctx, current, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			log.Error("apply secret failed", zap.String("secret_name", sec.Name), zap.Error(err))
+			return err
+		}
+
+		log.Info("secret applied", zap.String("secret_name", sec.Name))
+	}
+	return nil
+}
+
+// encryptValues 加密明文；值为占位符时沿用 existing 中的密文
+func (s *secretService) encryptValues(ctx context.Context, values map[string]string, existing map[string]*secret.EncryptedValue) (map[string]*secret.EncryptedValue, error) {
+	data := make(map[string]*secret.EncryptedValue, len(values))
+	for k, v := range values {
+		if v == domain.MaskedValue {
+			old, ok := existing[k]
+			if !ok {
+				return nil, fmt.Errorf("%w: key %s is masked but has no existing value", ErrInvalidSecretValue, k)
+			}
+			data[k] = old
+			continue
+		}
+
+		enc, err := secret.Encrypt(ctx, []byte(v))
+		if err != nil {
+			return nil, err
+		}
+		data[k] = enc
+	}
+	return data, nil
+}
+
+func secretLabels(sec *domain.Secret) map[string]string {
+	return map[string]string{
+		managedByLabel: managedByValue,
+		secretIDLabel:  sec.GetID().Hex(),
+	}
+}
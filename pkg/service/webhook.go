@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/gitops"
+	"github.com/bsonger/devflow/pkg/gitprovider"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var GitWebhookService = NewGitWebhookService()
+
+type gitWebhookService struct{}
+
+func NewGitWebhookService() *gitWebhookService {
+	return &gitWebhookService{}
+}
+
+// HandlePush 为仓库地址与触发规则都匹配的 Application 创建 Manifest，返回创建的 Manifest ID
+func (s *gitWebhookService) HandlePush(ctx context.Context, event *gitprovider.PushEvent) ([]primitive.ObjectID, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "handle_git_push"),
+		zap.String("provider", string(event.Provider)),
+		zap.String("ref", event.Ref),
+		zap.String("commit", event.Commit),
+	)
+
+	if event.Deleted || (event.Branch == "" && event.Tag == "") {
+		log.Info("push ignored: not a branch or tag update")
+		return nil, nil
+	}
+
+	// GitOps 模式下 devflow 自己推送到 manifests 仓库的提交不触发构建
+	for _, u := range event.RepoURLs {
+		if gitops.IsManifestRepo(u) {
+			log.Info("push ignored: manifests repository")
+			return nil, nil
+		}
+	}
+
+	apps, err := s.matchApplications(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(apps))
+	for _, app := range apps {
+		m := &domain.Manifest{}
+		m.ApplicationId = app.GetID()
+		m.CommitHash = event.Commit
+
+		if event.Tag != "" {
+			if !app.Trigger.MatchTag(event.Tag) {
+				log.Debug("tag not matched by trigger", zap.String("application", app.Name))
+				continue
+			}
+			// 非 semver tag 不触发构建
+			if _, err := domain.ParseVersion(event.Tag); err != nil {
+				log.Debug("tag is not a semver version", zap.String("tag", event.Tag))
+				continue
+			}
+			m.Tag = event.Tag
+		} else {
+			if !app.Trigger.MatchBranch(event.Branch) {
+				log.Debug("branch not matched by trigger", zap.String("application", app.Name))
+				continue
+			}
+			m.Branch = event.Branch
+		}
+
+		id, err := ManifestService.CreateManifest(ctx, m)
+		if errors.Is(err, ErrVersionExists) {
+			// 同一 tag 重复推送时保持幂等
+			log.Info("manifest for tag already exists", zap.String("application", app.Name), zap.String("tag", m.Tag))
+			continue
+		}
+		if err != nil {
+			log.Error("create manifest from push failed", zap.String("application", app.Name), zap.Error(err))
+			return ids, err
+		}
+
+		log.Info("manifest triggered by push",
+			zap.String("application", app.Name),
+			zap.String("manifest_id", id.Hex()),
+		)
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// matchApplications 按规范化后的仓库地址匹配未删除的 Application
+func (s *gitWebhookService) matchApplications(ctx context.Context, event *gitprovider.PushEvent) ([]domain.Application, error) {
+	urls := make(map[string]struct{}, len(event.RepoURLs))
+	for _, u := range event.RepoURLs {
+		urls[gitprovider.NormalizeRepoURL(u)] = struct{}{}
+	}
+
+	apps, err := ApplicationService.List(ctx, primitive.M{
+		"deleted_at":      primitive.M{"$exists": false},
+		"trigger.enabled": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]domain.Application, 0)
+	for _, app := range apps {
+		if _, ok := urls[gitprovider.NormalizeRepoURL(app.RepoURL)]; ok {
+			matched = append(matched, app)
+		}
+	}
+	return matched, nil
+}
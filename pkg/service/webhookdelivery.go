@@ -0,0 +1,256 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/netguard"
+	"github.com/bsonger/devflow/pkg/secret"
+	"github.com/bsonger/devflow/pkg/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookPollInterval = 5 * time.Second
+	webhookLease        = time.Minute
+	webhookTimeout      = 10 * time.Second
+	// webhookMaxRetries 失败重试次数，用尽后标记 Failed
+	webhookMaxRetries  = 8
+	webhookBaseBackoff = 10 * time.Second
+	webhookMaxBackoff  = time.Hour
+
+	// 请求头：订阅方用 X-Devflow-Signature-256 校验请求体，用 X-Devflow-Delivery 去重
+	headerWebhookEvent     = "X-Devflow-Event"
+	headerWebhookDelivery  = "X-Devflow-Delivery"
+	headerWebhookSignature = "X-Devflow-Signature-256"
+)
+
+var (
+	ErrSubscriptionInactive = errors.New("webhook subscription is deleted or disabled")
+)
+
+var WebhookDeliveryService = &webhookDeliveryService{
+	// 拨号前校验解析后的实际地址，防止域名解析或重定向到内部地址（SSRF）
+	client: netguard.NewClient(webhookTimeout),
+	wake:   make(chan struct{}, 1),
+}
+
+type webhookDeliveryService struct {
+	client *http.Client
+	wake   chan struct{}
+}
+
+func (s *webhookDeliveryService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartWebhookDispatcher 启动投递 worker：新投递立即发送，失败按指数退避重试，ctx 取消后退出
+func StartWebhookDispatcher(ctx context.Context) {
+	log := logging.Logger.With(zap.String("component", "webhook_dispatcher"))
+	log.Info("webhook dispatcher started")
+
+	goWorker(func() {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+
+		for {
+			WebhookDeliveryService.drain(ctx)
+
+			select {
+			case <-ctx.Done():
+				log.Info("webhook dispatcher stopped")
+				return
+			case <-ticker.C:
+			case <-WebhookDeliveryService.wake:
+			}
+		}
+	})
+}
+
+func (s *webhookDeliveryService) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		d, err := s.claim(ctx)
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return
+		}
+		if err != nil {
+			logging.LoggerWithContext(ctx).Error("claim webhook delivery failed", zap.Error(err))
+			return
+		}
+		s.deliver(ctx, d)
+	}
+}
+
+func (s *webhookDeliveryService) claim(ctx context.Context) (*domain.WebhookDelivery, error) {
+	now := time.Now()
+	filter := bson.M{
+		"status":          domain.DeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"locked_until": bson.M{"$exists": false}},
+			{"locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"locked_until": now.Add(webhookLease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	d := &domain.WebhookDelivery{}
+	err := store.Collection(d).FindOneAndUpdate(ctx, filter, update, opts).Decode(d)
+	return d, err
+}
+
+// deliver 发送一次并记录结果
+func (s *webhookDeliveryService) deliver(ctx context.Context, d *domain.WebhookDelivery) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "deliver_webhook"),
+		zap.String("delivery_id", d.ID.Hex()),
+		zap.String("subscription_id", d.SubscriptionID.Hex()),
+		zap.String("event_type", string(d.EventType)),
+	)
+
+	start := time.Now()
+	attempt := domain.DeliveryAttempt{At: start}
+
+	sub, err := WebhookSubscriptionService.Get(ctx, d.SubscriptionID)
+	if err == nil && !sub.Enabled {
+		err = ErrSubscriptionInactive
+	}
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		err = ErrSubscriptionInactive
+	}
+	if err == nil {
+		attempt.StatusCode, err = s.post(ctx, sub, d)
+	}
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	now := time.Now()
+	set := bson.M{"updated_at": now}
+	switch {
+	case err == nil:
+		set["status"] = domain.DeliverySucceeded
+		set["delivered_at"] = now
+		log.Info("webhook delivered", zap.Int("status_code", attempt.StatusCode))
+	case errors.Is(err, ErrSubscriptionInactive) || d.RetryCount+1 > webhookMaxRetries:
+		set["status"] = domain.DeliveryFailed
+		log.Warn("webhook delivery failed", zap.Int("retry_count", d.RetryCount), zap.Error(err))
+	default:
+		set["retry_count"] = d.RetryCount + 1
+		set["next_attempt_at"] = now.Add(expBackoff(webhookBaseBackoff, webhookMaxBackoff, d.RetryCount+1))
+		log.Warn("webhook delivery will retry", zap.Int("retry_count", d.RetryCount+1), zap.Error(err))
+	}
+
+	update := bson.M{
+		"$set":   set,
+		"$unset": bson.M{"locked_until": ""},
+		"$push": bson.M{"attempts": bson.M{
+			"$each":  []domain.DeliveryAttempt{attempt},
+			"$slice": -domain.DeliveryAttemptHistory,
+		}},
+	}
+	if _, err := store.Collection(d).UpdateByID(ctx, d.ID, update); err != nil {
+		log.Error("update webhook delivery failed", zap.Error(err))
+	}
+}
+
+// post 以 HMAC-SHA256 签名请求体并 POST，非 2xx 视为失败
+func (s *webhookDeliveryService) post(ctx context.Context, sub *domain.WebhookSubscription, d *domain.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "devflow-webhook")
+	req.Header.Set(headerWebhookEvent, string(d.EventType))
+	req.Header.Set(headerWebhookDelivery, d.ID.Hex())
+
+	if sub.Secret != nil {
+		key, err := secret.Decrypt(ctx, sub.Secret)
+		if err != nil {
+			return 0, fmt.Errorf("decrypt webhook secret: %w", err)
+		}
+		req.Header.Set(headerWebhookSignature, "sha256="+signPayload(key, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func signPayload(key, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *webhookDeliveryService) Get(ctx context.Context, id primitive.ObjectID) (*domain.WebhookDelivery, error) {
+	d := &domain.WebhookDelivery{}
+	if err := mongo.Repo.FindByID(ctx, d, id); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *webhookDeliveryService) List(ctx context.Context, filter primitive.M) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	if err := mongo.Repo.List(ctx, &domain.WebhookDelivery{}, filter, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Redeliver 以原请求体重新投递，重试次数清零，历史尝试记录保留
+func (s *webhookDeliveryService) Redeliver(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := store.Collection(&domain.WebhookDelivery{}).UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"status":          domain.DeliveryPending,
+			"retry_count":     0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		},
+		"$unset": bson.M{"delivered_at": "", "locked_until": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongoDriver.ErrNoDocuments
+	}
+
+	logging.LoggerWithContext(ctx).Info("webhook redelivery scheduled", zap.String("delivery_id", id.Hex()))
+	s.notify()
+	return nil
+}
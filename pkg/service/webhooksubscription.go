@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bsonger/devflow-common/client/logging"
+	"github.com/bsonger/devflow-common/client/mongo"
+	"github.com/bsonger/devflow-common/model"
+	"github.com/bsonger/devflow/pkg/domain"
+	"github.com/bsonger/devflow/pkg/events"
+	"github.com/bsonger/devflow/pkg/secret"
+	"github.com/bsonger/devflow/pkg/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+var WebhookSubscriptionService = NewWebhookSubscriptionService()
+
+type webhookSubscriptionService struct{}
+
+func NewWebhookSubscriptionService() *webhookSubscriptionService {
+	return &webhookSubscriptionService{}
+}
+
+// Create 校验并保存订阅，signingSecret 为空时不签名
+func (s *webhookSubscriptionService) Create(ctx context.Context, sub *domain.WebhookSubscription, signingSecret string) (primitive.ObjectID, error) {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "create_webhook_subscription"),
+		zap.String("name", sub.Name),
+	)
+
+	if err := sub.Validate(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if signingSecret != "" {
+		encrypted, err := secret.Encrypt(ctx, []byte(signingSecret))
+		if err != nil {
+			log.Error("encrypt webhook secret failed", zap.Error(err))
+			return primitive.NilObjectID, err
+		}
+		sub.Secret = encrypted
+	}
+
+	sub.WithCreateDefault()
+	if err := mongo.Repo.Create(ctx, sub); err != nil {
+		log.Error("create webhook subscription failed", zap.Error(err))
+		return primitive.NilObjectID, err
+	}
+
+	log.Info("webhook subscription created", zap.String("subscription_id", sub.GetID().Hex()))
+	return sub.GetID(), nil
+}
+
+func (s *webhookSubscriptionService) Get(ctx context.Context, id primitive.ObjectID) (*domain.WebhookSubscription, error) {
+	sub := &domain.WebhookSubscription{}
+	if err := mongo.Repo.FindByID(ctx, sub, id); err != nil {
+		return nil, err
+	}
+	if sub.DeletedAt != nil {
+		return nil, mongoDriver.ErrNoDocuments
+	}
+	return sub, nil
+}
+
+// Update 替换订阅配置；signingSecret 为空或为 domain.MaskedValue 时保留原密钥
+func (s *webhookSubscriptionService) Update(ctx context.Context, sub *domain.WebhookSubscription, signingSecret string) error {
+	log := logging.LoggerWithContext(ctx).With(
+		zap.String("operation", "update_webhook_subscription"),
+		zap.String("subscription_id", sub.GetID().Hex()),
+	)
+
+	current, err := s.Get(ctx, sub.GetID())
+	if err != nil {
+		return err
+	}
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+
+	sub.Secret = current.Secret
+	if signingSecret != "" && signingSecret != domain.MaskedValue {
+		sub.Secret, err = secret.Encrypt(ctx, []byte(signingSecret))
+		if err != nil {
+			log.Error("encrypt webhook secret failed", zap.Error(err))
+			return err
+		}
+	}
+	sub.CreatedAt = current.CreatedAt
+	sub.WithUpdateDefault()
+
+	update := primitive.M{
+		"$set": primitive.M{
+			"name":         sub.Name,
+			"url":          sub.URL,
+			"secret":       sub.Secret,
+			"event_types":  sub.EventTypes,
+			"project_name": sub.ProjectName,
+			"enabled":      sub.Enabled,
+			"updated_at":   sub.UpdatedAt,
+		},
+	}
+	if err := mongo.Repo.UpdateByID(ctx, &domain.WebhookSubscription{}, sub.GetID(), update); err != nil {
+		log.Error("update webhook subscription failed", zap.Error(err))
+		return err
+	}
+
+	log.Info("webhook subscription updated")
+	return nil
+}
+
+func (s *webhookSubscriptionService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	update := primitive.M{
+		"$set": primitive.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+	}
+	if err := mongo.Repo.UpdateByID(ctx, &domain.WebhookSubscription{}, id, update); err != nil {
+		logging.LoggerWithContext(ctx).Error("delete webhook subscription failed", zap.String("subscription_id", id.Hex()), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *webhookSubscriptionService) List(ctx context.Context, filter primitive.M) ([]domain.WebhookSubscription, error) {
+	var subs []domain.WebhookSubscription
+	if err := mongo.Repo.List(ctx, &domain.WebhookSubscription{}, filter, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// HandleEvent outbox 订阅者：为匹配的订阅各创建一条投递记录，由投递 worker 发送；重复调用不会重复创建
+func (s *webhookSubscriptionService) HandleEvent(ctx context.Context, msg OutboxMessage) error {
+	subs, err := s.List(ctx, primitive.M{
+		"enabled":    true,
+		"deleted_at": primitive.M{"$exists": false},
+	})
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+
+	var project string
+	if appID := msg.Event.Application(); !appID.IsZero() {
+		app := &model.Application{}
+		if err := mongo.Repo.FindByID(ctx, app, appID); err == nil {
+			project = app.ProjectName
+		}
+	}
+
+	event := events.FromDomainEvent(msg.ID.Hex(), msg.OccurredAt, msg.Event)
+	event.Project = project
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	eventType := msg.Event.EventType()
+	created := false
+	for i := range subs {
+		sub := &subs[i]
+		if !sub.Match(eventType, project) {
+			continue
+		}
+
+		now := time.Now()
+		delivery := &domain.WebhookDelivery{
+			SubscriptionID: sub.GetID(),
+			EventID:        msg.ID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         domain.DeliveryPending,
+			NextAttemptAt:  now,
+		}
+		delivery.ID = primitive.NewObjectID()
+		delivery.WithCreateDefault()
+
+		_, err := store.Collection(delivery).UpdateOne(ctx,
+			primitive.M{"subscription_id": sub.GetID(), "event_id": msg.ID},
+			primitive.M{"$setOnInsert": delivery},
+			options.Update().SetUpsert(true),
+		)
+		if mongoDriver.IsDuplicateKeyError(err) {
+			// 并发 upsert 时另一方已插入
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		created = true
+	}
+
+	if created {
+		WebhookDeliveryService.notify()
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// workers 跟踪后台任务的 goroutine，退出时先等待它们结束再断开 Mongo；后台任务都使用 workerCtx，可随其取消
+var workers sync.WaitGroup
+
+// goWorker 启动受 workers 跟踪的 goroutine
+func goWorker(fn func()) {
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		fn()
+	}()
+}
+
+// WaitWorkers 等待全部后台 goroutine 退出，ctx 到期时返回 ctx.Err()；后台任务的 ctx 应先取消
+func WaitWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
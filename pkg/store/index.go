@@ -0,0 +1,17 @@
+package store
+
+import (
+	"context"
+
+	"github.com/bsonger/devflow-common/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// EnsureIndexes 在 m 对应的集合上创建索引；定义相同的索引已存在时 Mongo 直接返回成功
+func EnsureIndexes(ctx context.Context, m model.MongoModel, indexes ...mongo.IndexModel) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+	_, err := Collection(m).Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bsonger/devflow-common/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// DB 直接暴露 devflow 使用的 Mongo database，
+// 用于 devflow-common Repo 未覆盖的操作（物理删除等）。
+var DB *mongo.Database
+
+func InitStore(client *mongo.Client, dbName string) {
+	DB = client.Database(dbName)
+}
+
+// Ping 检查与 Mongo 主节点的连通性，未初始化时返回错误
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("mongo store not initialized")
+	}
+	return DB.Client().Ping(ctx, readpref.Primary())
+}
+
+func Collection(m model.MongoModel) *mongo.Collection {
+	return DB.Collection(m.CollectionName())
+}
+
+// HardDeleteMany 物理删除匹配的文档，返回删除数量
+func HardDeleteMany(ctx context.Context, m model.MongoModel, filter bson.M) (int64, error) {
+	if len(filter) == 0 {
+		return 0, errors.New("hard delete filter cannot be empty")
+	}
+
+	res, err := Collection(m).DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
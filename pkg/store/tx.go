@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// transactions 当前部署是否支持多文档事务（副本集或分片集群）
+var transactions bool
+
+// DetectTransactions 通过 hello 命令判断部署是否支持事务，单机 Mongo 时 WithTransaction 退化为顺序执行
+func DetectTransactions(ctx context.Context) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := DB.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, err
+	}
+	transactions = hello.SetName != "" || hello.Msg == "isdbgrid"
+	return transactions, nil
+}
+
+// WithTransaction 在一个事务内执行 fn；fn 内的 Mongo 操作必须使用传入的 ctx。
+// 事务冲突时 fn 可能被重试，应保持幂等
+func WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !transactions {
+		return fn(ctx)
+	}
+
+	session, err := DB.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}